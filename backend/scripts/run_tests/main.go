@@ -16,10 +16,12 @@ func main() {
 		name        string
 		path        string
 		description string
+		flags       []string
 	}{
-		{"Unit Tests", "./tests/unit", "Testing individual components in isolation"},
-		{"Integration Tests", "./tests/integration", "Testing API endpoints and service integration"},
-		{"All Tests", "./...", "Running complete test suite"},
+		{"Unit Tests", "./tests/unit", "Testing individual components in isolation", nil},
+		{"Integration Tests", "./tests/integration", "Testing API endpoints and service integration", nil},
+		{"Race Tests", "./tests/unit", "Running concurrent storage updates under the race detector", []string{"-race", "-run", "Concurrent"}},
+		{"All Tests", "./...", "Running complete test suite", nil},
 	}
 
 	allPassed := true
@@ -30,7 +32,8 @@ func main() {
 		fmt.Printf("   Path: %s\n", suite.path)
 		fmt.Println(strings.Repeat("-", 50))
 
-		cmd := exec.Command("go", "test", "-v", suite.path)
+		args := append([]string{"test", "-v"}, suite.flags...)
+		cmd := exec.Command("go", append(args, suite.path)...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
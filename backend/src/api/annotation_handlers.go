@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+
+	"inspiration-blog-writer/backend/src/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnnotationRequest represents the request body for creating or updating an
+// annotation on a resource
+type AnnotationRequest struct {
+	Quote    string                     `json:"quote" binding:"required"`
+	Selector *models.AnnotationSelector `json:"selector"`
+	Note     string                     `json:"note"`
+	Tags     []string                   `json:"tags"`
+}
+
+// ListAnnotations handles GET /api/resources/:id/annotations
+func (h *ResourceHandlers) ListAnnotations(c *gin.Context) {
+	annotations, err := h.resourceService.ListAnnotations(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"annotations": annotations})
+}
+
+// CreateAnnotation handles POST /api/resources/:id/annotations
+func (h *ResourceHandlers) CreateAnnotation(c *gin.Context) {
+	var req AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err.Error())
+		return
+	}
+
+	annotation, err := h.resourceService.CreateAnnotation(c.Param("id"), req.Quote, req.Selector, req.Note, req.Tags)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"annotation": annotation})
+}
+
+// GetAnnotation handles GET /api/resources/:id/annotations/:annotationId
+func (h *ResourceHandlers) GetAnnotation(c *gin.Context) {
+	annotation, err := h.resourceService.GetAnnotation(c.Param("id"), c.Param("annotationId"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"annotation": annotation})
+}
+
+// UpdateAnnotation handles PUT /api/resources/:id/annotations/:annotationId
+func (h *ResourceHandlers) UpdateAnnotation(c *gin.Context) {
+	var req AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err.Error())
+		return
+	}
+
+	annotation, err := h.resourceService.UpdateAnnotation(c.Param("id"), c.Param("annotationId"), req.Quote, req.Selector, req.Note, req.Tags)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"annotation": annotation})
+}
+
+// DeleteAnnotation handles DELETE /api/resources/:id/annotations/:annotationId
+func (h *ResourceHandlers) DeleteAnnotation(c *gin.Context) {
+	if err := h.resourceService.DeleteAnnotation(c.Param("id"), c.Param("annotationId")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"inspiration-blog-writer/backend/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChatHandlers handles HTTP requests for chat sessions
+type ChatHandlers struct {
+	chatService *services.ChatService
+}
+
+// NewChatHandlers creates new chat handlers
+func NewChatHandlers(chatService *services.ChatService) *ChatHandlers {
+	return &ChatHandlers{
+		chatService: chatService,
+	}
+}
+
+// CreateSessionRequest represents the request body for starting a chat session
+type CreateSessionRequest struct {
+	DraftID string `json:"draftId" binding:"required"`
+}
+
+// SendMessageRequest represents the request body for sending a chat message
+type SendMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// ListSessions handles GET /api/chat/sessions?draftId=&active=true
+func (h *ChatHandlers) ListSessions(c *gin.Context) {
+	sessions, err := h.chatService.ListSessions(c.Query("draftId"), c.Query("active") == "true")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// CreateSession handles POST /api/chat/sessions
+func (h *ChatHandlers) CreateSession(c *gin.Context) {
+	var req CreateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err.Error())
+		return
+	}
+
+	session, err := h.chatService.CreateSession(req.DraftID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"session": session})
+}
+
+// GetSession handles GET /api/chat/sessions/:id
+func (h *ChatHandlers) GetSession(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "session ID is required")
+		return
+	}
+
+	session, err := h.chatService.GetSession(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// GetMessage handles GET /api/chat/sessions/:id/messages/:messageId
+func (h *ChatHandlers) GetMessage(c *gin.Context) {
+	message, err := h.chatService.GetMessage(c.Param("id"), c.Param("messageId"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// DeactivateSession handles POST /api/chat/sessions/:id/deactivate
+func (h *ChatHandlers) DeactivateSession(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "session ID is required")
+		return
+	}
+
+	session, err := h.chatService.DeactivateSession(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// SendMessage handles POST /api/chat/sessions/:id/messages. The response
+// holds the whole session with the user's message and the assistant's reply.
+// Clients that accept text/event-stream receive the reply as it is written
+// instead, see streamMessage.
+func (h *ChatHandlers) SendMessage(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "session ID is required")
+		return
+	}
+
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err.Error())
+		return
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		h.streamMessage(c, id, req.Content)
+		return
+	}
+
+	session, err := h.chatService.SendMessage(c.Request.Context(), id, req.Content)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"session": session})
+}
+
+// streamMessage sends the reply as Server-Sent Events: a "delta" event per
+// chunk of text, then "done" with the stored session, or "error" with the
+// usual error body. Errors raised before the first chunk get a regular JSON
+// error response. When the client disconnects the model request is
+// cancelled and the partial reply is kept.
+func (h *ChatHandlers) streamMessage(c *gin.Context, sessionID, content string) {
+	ctx := c.Request.Context()
+	started := false
+	start := func() {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		started = true
+	}
+
+	session, err := h.chatService.StreamMessage(ctx, sessionID, content, func(delta string) error {
+		if !started {
+			start()
+		}
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+
+	if ctx.Err() != nil {
+		// Nobody is listening any more
+		return
+	}
+	if err != nil && !started {
+		respondError(c, err)
+		return
+	}
+	if !started {
+		start()
+	}
+
+	if err != nil {
+		_, body := errorBody(c, err)
+		c.SSEvent("error", body)
+	} else {
+		c.SSEvent("done", gin.H{"session": session})
+	}
+	c.Writer.Flush()
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
+	"inspiration-blog-writer/backend/src/models"
 	"inspiration-blog-writer/backend/src/services"
 
 	"github.com/gin-gonic/gin"
@@ -27,55 +29,64 @@ type CreateDraftRequest struct {
 	Tags    []string `json:"tags"`
 }
 
-// UpdateDraftRequest represents request body for updating a draft
+// UpdateDraftRequest represents request body for updating a draft. Source
+// says who made the change ("user" or "assistant") for the revision history.
 type UpdateDraftRequest struct {
-	Title   string   `json:"title" binding:"required"`
-	Content string   `json:"content"`
-	Tags    []string `json:"tags"`
+	Title   string                `json:"title" binding:"required"`
+	Content string                `json:"content"`
+	Tags    []string              `json:"tags"`
+	Source  models.RevisionSource `json:"source"`
 }
 
 // CreateDraft handles POST /api/drafts
 func (h *DraftHandlers) CreateDraft(c *gin.Context) {
 	var req CreateDraftRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalidRequest(c, err.Error())
 		return
 	}
 
 	draft, err := h.draftService.CreateDraft(req.Title, req.Content, req.Tags)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
+	setETag(c, draft.Version)
 	c.JSON(http.StatusCreated, gin.H{"draft": draft})
 }
 
-// ListDrafts handles GET /api/drafts
+// ListDrafts handles GET /api/drafts?limit=&cursor=&sort=&order=&tag=&createdAfter=&createdBefore=
 func (h *DraftHandlers) ListDrafts(c *gin.Context) {
-	drafts, err := h.draftService.ListDrafts()
+	query, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	drafts, next, err := h.draftService.QueryDrafts(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"drafts": drafts})
+	c.JSON(http.StatusOK, gin.H{"drafts": drafts, "nextCursor": next})
 }
 
 // GetDraft handles GET /api/drafts/:id
 func (h *DraftHandlers) GetDraft(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "draft ID is required"})
+		respondInvalidRequest(c, "draft ID is required")
 		return
 	}
 
 	draft, err := h.draftService.GetDraft(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "draft not found"})
+		respondError(c, err)
 		return
 	}
 
+	setETag(c, draft.Version)
 	c.JSON(http.StatusOK, gin.H{"draft": draft})
 }
 
@@ -83,22 +94,28 @@ func (h *DraftHandlers) GetDraft(c *gin.Context) {
 func (h *DraftHandlers) UpdateDraft(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "draft ID is required"})
+		respondInvalidRequest(c, "draft ID is required")
 		return
 	}
 
 	var req UpdateDraftRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalidRequest(c, err.Error())
 		return
 	}
 
-	draft, err := h.draftService.UpdateDraft(id, req.Title, req.Content, req.Tags)
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.draftService.UpdateDraft(id, req.Title, req.Content, req.Tags, req.Source, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "draft not found"})
+		respondError(c, err)
 		return
 	}
 
+	setETag(c, draft.Version)
 	c.JSON(http.StatusOK, gin.H{"draft": draft})
 }
 
@@ -106,13 +123,13 @@ func (h *DraftHandlers) UpdateDraft(c *gin.Context) {
 func (h *DraftHandlers) DeleteDraft(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "draft ID is required"})
+		respondInvalidRequest(c, "draft ID is required")
 		return
 	}
 
 	err := h.draftService.DeleteDraft(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "draft not found"})
+		respondError(c, err)
 		return
 	}
 
@@ -123,7 +140,7 @@ func (h *DraftHandlers) DeleteDraft(c *gin.Context) {
 func (h *DraftHandlers) AddResourceToDraft(c *gin.Context) {
 	draftID := c.Param("id")
 	if draftID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "draft ID is required"})
+		respondInvalidRequest(c, "draft ID is required")
 		return
 	}
 
@@ -132,13 +149,13 @@ func (h *DraftHandlers) AddResourceToDraft(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalidRequest(c, err.Error())
 		return
 	}
 
 	err := h.draftService.AddResourceToDraft(draftID, req.ResourceID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -151,19 +168,87 @@ func (h *DraftHandlers) RemoveResourceFromDraft(c *gin.Context) {
 	resourceID := c.Param("resourceId")
 
 	if draftID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "draft ID is required"})
+		respondInvalidRequest(c, "draft ID is required")
 		return
 	}
 	if resourceID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "resource ID is required"})
+		respondInvalidRequest(c, "resource ID is required")
 		return
 	}
 
 	err := h.draftService.RemoveResourceFromDraft(draftID, resourceID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "resource removed from draft"})
 }
+
+// ListRevisions handles GET /api/drafts/:id/revisions
+func (h *DraftHandlers) ListRevisions(c *gin.Context) {
+	revisions, err := h.draftService.ListRevisions(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// GetRevision handles GET /api/drafts/:id/revisions/:number
+func (h *DraftHandlers) GetRevision(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		respondInvalidRequest(c, "revision number must be an integer")
+		return
+	}
+
+	revision, err := h.draftService.GetRevision(c.Param("id"), number)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revision": revision})
+}
+
+// DiffRevisions handles GET /api/drafts/:id/diff?from=&to=&mode=line|word
+func (h *DraftHandlers) DiffRevisions(c *gin.Context) {
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		respondInvalidRequest(c, "from must be a revision number")
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		respondInvalidRequest(c, "to must be a revision number")
+		return
+	}
+
+	result, err := h.draftService.DiffRevisions(c.Param("id"), from, to, c.Query("mode"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"diff": result})
+}
+
+// RestoreRevision handles POST /api/drafts/:id/revisions/:number/restore
+func (h *DraftHandlers) RestoreRevision(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		respondInvalidRequest(c, "revision number must be an integer")
+		return
+	}
+
+	draft, revision, err := h.draftService.RestoreRevision(c.Param("id"), number)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, draft.Version)
+	c.JSON(http.StatusOK, gin.H{"draft": draft, "revision": revision})
+}
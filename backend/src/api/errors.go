@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes returned in ErrorBody.Code
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeValidation           = "validation_failed"
+	CodeNotFound             = "not_found"
+	CodeAlreadyExists        = "already_exists"
+	CodeDuplicate            = "duplicate_resource"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeNotConfigured        = "not_configured"
+	CodeGeneration           = "generation_failed"
+	CodeModel                = "model_unavailable"
+	CodeFetchFailed          = "fetch_failed"
+	CodeInternal             = "internal_error"
+)
+
+// ErrorResponse is the JSON envelope returned for every failed request.
+// Version conflicts also carry the current server copy.
+type ErrorResponse struct {
+	Error   ErrorBody   `json:"error"`
+	Current interface{} `json:"current,omitempty"`
+	// References lists what still links to a resource that could not be
+	// deleted
+	References *services.ResourceReferences `json:"references,omitempty"`
+	// Existing is the resource a new one duplicates
+	Existing *models.CollectedResource `json:"existing,omitempty"`
+}
+
+// ErrorBody describes a single API error
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorStatus maps a service error to its HTTP status and error code
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		return http.StatusBadRequest, CodeValidation
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound
+	case errors.Is(err, services.ErrAlreadyExists):
+		return http.StatusConflict, CodeAlreadyExists
+	case errors.Is(err, services.ErrDuplicate):
+		return http.StatusConflict, CodeDuplicate
+	case errors.Is(err, services.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, CodePreconditionFailed
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict, CodeConflict
+	case errors.Is(err, services.ErrNotConfigured):
+		return http.StatusServiceUnavailable, CodeNotConfigured
+	case errors.Is(err, services.ErrGenerationFailed):
+		return http.StatusBadGateway, CodeGeneration
+	case errors.Is(err, services.ErrModelUnavailable):
+		return http.StatusBadGateway, CodeModel
+	case errors.Is(err, services.ErrFetchFailed):
+		return http.StatusBadGateway, CodeFetchFailed
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+}
+
+// respondError writes the error envelope for err. Unexpected errors are
+// recorded on the context for logging and reported without internal details.
+func respondError(c *gin.Context, err error) {
+	status, body := errorBody(c, err)
+	response := ErrorResponse{Error: body}
+
+	var conflict *services.VersionConflictError
+	if errors.As(err, &conflict) {
+		response.Current = conflict.Current
+	}
+	var inUse *services.ResourceInUseError
+	if errors.As(err, &inUse) {
+		response.References = inUse.References
+	}
+	var duplicate *services.DuplicateResourceError
+	if errors.As(err, &duplicate) {
+		response.Existing = duplicate.Existing
+	}
+
+	c.AbortWithStatusJSON(status, response)
+}
+
+// errorBody describes err for the client, hiding the details of unexpected
+// errors after recording them on the context
+func errorBody(c *gin.Context, err error) (int, ErrorBody) {
+	status, code := errorStatus(err)
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		c.Error(err)
+		message = "internal server error"
+	}
+
+	return status, ErrorBody{Code: code, Message: message}
+}
+
+// respondInvalidRequest writes a 400 envelope for malformed requests
+func respondInvalidRequest(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: ErrorBody{Code: CodeInvalidRequest, Message: message}})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"inspiration-blog-writer/backend/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag exposes an entity version as a strong entity tag
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion returns the version required by the If-Match header, or
+// services.AnyVersion for "*". When the header is missing, malformed or a
+// weak ETag, which never matches under the strong comparison If-Match uses,
+// an error response is written and ok is false.
+func ifMatchVersion(c *gin.Context) (version int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		c.AbortWithStatusJSON(http.StatusPreconditionRequired, ErrorResponse{Error: ErrorBody{
+			Code:    CodePreconditionRequired,
+			Message: "If-Match header with the ETag of the current version is required",
+		}})
+		return 0, false
+	}
+	if header == "*" {
+		return services.AnyVersion, true
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, ErrorResponse{Error: ErrorBody{
+			Code:    CodePreconditionFailed,
+			Message: "If-Match needs a strong ETag; weak ETags never match",
+		}})
+		return 0, false
+	}
+
+	tag := strings.Trim(header, `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 0 {
+		respondInvalidRequest(c, "If-Match must be an ETag returned by this API")
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+
+	"inspiration-blog-writer/backend/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdeaHandlers handles HTTP requests for interest ideas
+type IdeaHandlers struct {
+	ideaService *services.IdeaService
+}
+
+// NewIdeaHandlers creates new idea handlers
+func NewIdeaHandlers(ideaService *services.IdeaService) *IdeaHandlers {
+	return &IdeaHandlers{
+		ideaService: ideaService,
+	}
+}
+
+// CreateIdeaRequest represents the request body for creating ideas. Without
+// a title, ideas are generated for DraftID from its resources and Context;
+// with a title, a single idea is stored as given.
+type CreateIdeaRequest struct {
+	DraftID     string   `json:"draftId"`
+	ResourceIDs []string `json:"resourceIds"`
+	Context     string   `json:"context"`
+	Count       int      `json:"count"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Content     string   `json:"content"`
+	Confidence  float64  `json:"confidence"`
+	Tags        []string `json:"tags"`
+}
+
+// UpdateIdeaRequest represents the request body for updating an idea
+type UpdateIdeaRequest struct {
+	ResourceIDs []string `json:"resourceIds"`
+	Title       string   `json:"title" binding:"required"`
+	Description string   `json:"description"`
+	Content     string   `json:"content"`
+	Confidence  float64  `json:"confidence"`
+	Tags        []string `json:"tags"`
+}
+
+// ListIdeas handles GET /api/ideas with the list parameters of
+// GET /api/drafts plus draftId= and source=
+func (h *IdeaHandlers) ListIdeas(c *gin.Context) {
+	query, ok := ideaListQuery(c)
+	if !ok {
+		return
+	}
+
+	ideas, next, err := h.ideaService.QueryIdeas(query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ideas": ideas, "nextCursor": next})
+}
+
+// CreateIdea handles POST /api/ideas
+func (h *IdeaHandlers) CreateIdea(c *gin.Context) {
+	var req CreateIdeaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err.Error())
+		return
+	}
+
+	if req.Title == "" {
+		ideas, err := h.ideaService.GenerateIdeas(c.Request.Context(), req.DraftID, req.ResourceIDs, req.Context, req.Count)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"ideas": ideas})
+		return
+	}
+
+	idea, err := h.ideaService.CreateIdea(req.DraftID, req.Title, req.Description, req.Content, req.Confidence, req.ResourceIDs, req.Tags)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"idea": idea})
+}
+
+// GetIdea handles GET /api/ideas/:id
+func (h *IdeaHandlers) GetIdea(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "idea ID is required")
+		return
+	}
+
+	idea, err := h.ideaService.GetIdea(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"idea": idea})
+}
+
+// UpdateIdea handles PUT /api/ideas/:id
+func (h *IdeaHandlers) UpdateIdea(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "idea ID is required")
+		return
+	}
+
+	var req UpdateIdeaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err.Error())
+		return
+	}
+
+	idea, err := h.ideaService.UpdateIdea(id, req.Title, req.Description, req.Content, req.Confidence, req.ResourceIDs, req.Tags)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"idea": idea})
+}
+
+// DeleteIdea handles DELETE /api/ideas/:id
+func (h *IdeaHandlers) DeleteIdea(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "idea ID is required")
+		return
+	}
+
+	err := h.ideaService.DeleteIdea(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listQuery reads the paging, sorting and filtering parameters shared by the
+// list endpoints:
+//
+//	limit, cursor, sort=createdAt|updatedAt|title, order=asc|desc,
+//	tag (repeatable, all must match), createdAfter, createdBefore
+//
+// Dates are RFC 3339 timestamps or plain YYYY-MM-DD days. Dates sort newest
+// first and titles A to Z unless order says otherwise. When a parameter is
+// malformed an error response is written and ok is false.
+func listQuery(c *gin.Context) (query services.ListQuery, ok bool) {
+	query = services.ListQuery{
+		Cursor: c.Query("cursor"),
+		SortBy: c.Query("sort"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			respondInvalidRequest(c, "limit must be a number")
+			return query, false
+		}
+		query.Limit = n
+	}
+
+	switch c.Query("order") {
+	case "":
+		query.Descending = query.SortBy != services.SortByTitle
+	case "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		respondInvalidRequest(c, "order must be asc or desc")
+		return query, false
+	}
+
+	for _, tags := range c.QueryArray("tag") {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				query.Tags = append(query.Tags, tag)
+			}
+		}
+	}
+
+	var err error
+	if query.CreatedAfter, err = queryTime(c, "createdAfter"); err != nil {
+		return query, false
+	}
+	if query.CreatedBefore, err = queryTime(c, "createdBefore"); err != nil {
+		return query, false
+	}
+
+	return query, true
+}
+
+// resourceListQuery adds the resource filters category, type, domain, q
+// (free text) and health to listQuery
+func resourceListQuery(c *gin.Context) (services.ListQuery, bool) {
+	query, ok := listQuery(c)
+	query.Category = c.Query("category")
+	query.Type = models.ResourceType(c.Query("type"))
+	query.Domain = c.Query("domain")
+	query.Text = strings.TrimSpace(c.Query("q"))
+	query.Health = models.HealthStatus(c.Query("health"))
+	return query, ok
+}
+
+// ideaListQuery adds the idea filters draftId and source to listQuery
+func ideaListQuery(c *gin.Context) (services.ListQuery, bool) {
+	query, ok := listQuery(c)
+	query.DraftID = c.Query("draftId")
+	query.SourceID = c.Query("source")
+	return query, ok
+}
+
+// queryTime parses a date query parameter, writing an error response when it
+// is malformed
+func queryTime(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+	}
+	if err != nil {
+		respondInvalidRequest(c, name+" must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	return t, err
+}
@@ -21,67 +21,132 @@ func NewResourceHandlers(resourceService *services.ResourceService) *ResourceHan
 	}
 }
 
-// CreateResourceRequest represents the request body for creating a resource
+// CreateResourceRequest represents the request body for creating a resource.
+// An empty title is filled in from the page when metadata fetching is on.
+// Notes need a body instead of a URL, and their title defaults to the start
+// of the body.
 type CreateResourceRequest struct {
-	URL         string              `json:"url" binding:"required"`
-	Title       string              `json:"title" binding:"required"`
-	Description string              `json:"description"`
-	Type        models.ResourceType `json:"type"`
-	Category    string              `json:"category"`
-	Tags        []string            `json:"tags"`
+	URL         string                 `json:"url"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Type        models.ResourceType    `json:"type"`
+	Category    string                 `json:"category"`
+	Tags        []string               `json:"tags"`
+	Body        string                 `json:"body"`
+	Source      *models.ResourceSource `json:"source"`
 }
 
 // UpdateResourceRequest represents the request body for updating a resource
 type UpdateResourceRequest struct {
-	Title       string              `json:"title" binding:"required"`
-	Description string              `json:"description"`
-	Type        models.ResourceType `json:"type"`
-	Category    string              `json:"category"`
-	Tags        []string            `json:"tags"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Type        models.ResourceType    `json:"type"`
+	Category    string                 `json:"category"`
+	Tags        []string               `json:"tags"`
+	Body        string                 `json:"body"`
+	Source      *models.ResourceSource `json:"source"`
 }
 
-// ListResources handles GET /api/resources
+// ListResources handles GET /api/resources with the list parameters of
+// GET /api/drafts plus category=, type=, domain=, q= and
+// health=unchecked|ok|moved|broken
 func (h *ResourceHandlers) ListResources(c *gin.Context) {
-	resources, err := h.resourceService.ListResources()
+	query, ok := resourceListQuery(c)
+	if !ok {
+		return
+	}
+
+	resources, next, err := h.resourceService.QueryResources(query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"resources": resources, "nextCursor": next})
+}
+
+// GetFacets handles GET /api/resources/facets with the filters of
+// GET /api/resources
+func (h *ResourceHandlers) GetFacets(c *gin.Context) {
+	query, ok := resourceListQuery(c)
+	if !ok {
+		return
+	}
+
+	facets, err := h.resourceService.GetFacets(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"resources": resources})
+	c.JSON(http.StatusOK, gin.H{"facets": facets})
 }
 
-// CreateResource handles POST /api/resources
+// CreateResource handles POST /api/resources?duplicates=reject|merge|allow.
+// A duplicate merged into an existing resource answers 200 with that
+// resource instead of 201.
 func (h *ResourceHandlers) CreateResource(c *gin.Context) {
 	var req CreateResourceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalidRequest(c, err.Error())
 		return
 	}
 
-	resource, err := h.resourceService.CreateResource(req.URL, req.Title, req.Description, req.Type, req.Category, req.Tags)
+	duplicates := services.DuplicatePolicy(c.Query("duplicates"))
+	var (
+		resource *models.CollectedResource
+		created  bool
+		err      error
+	)
+	if req.Type == models.ResourceTypeNote {
+		resource, created, err = h.resourceService.CollectNote(req.Title, req.Body, req.Source, req.URL, req.Category, req.Tags, duplicates)
+	} else {
+		if req.Body != "" || req.Source != nil {
+			respondInvalidRequest(c, "only notes have a body and a source")
+			return
+		}
+		resource, created, err = h.resourceService.CollectResource(req.URL, req.Title, req.Description, req.Type, req.Category, req.Tags, duplicates)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"resource": resource})
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	setETag(c, resource.Version)
+	c.JSON(status, gin.H{"resource": resource})
+}
+
+// Dedupe handles POST /api/resources/dedupe?dryRun=true, merging the
+// resources that duplicate each other
+func (h *ResourceHandlers) Dedupe(c *gin.Context) {
+	result, err := h.resourceService.Dedupe(c.Query("dryRun") == "true")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dedupe": result})
 }
 
 // GetResource handles GET /api/resources/:id
 func (h *ResourceHandlers) GetResource(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "resource ID is required"})
+		respondInvalidRequest(c, "resource ID is required")
 		return
 	}
 
 	resource, err := h.resourceService.GetResource(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
+	setETag(c, resource.Version)
 	c.JSON(http.StatusOK, gin.H{"resource": resource})
 }
 
@@ -89,68 +154,150 @@ func (h *ResourceHandlers) GetResource(c *gin.Context) {
 func (h *ResourceHandlers) UpdateResource(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "resource ID is required"})
+		respondInvalidRequest(c, "resource ID is required")
 		return
 	}
 
 	var req UpdateResourceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalidRequest(c, err.Error())
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
-	resource, err := h.resourceService.UpdateResource(id, req.Title, req.Description, req.Type, req.Category, req.Tags)
+	resource, err := h.resourceService.UpdateResource(id, req.Title, req.Description, req.Body, req.Source, req.Type, req.Category, req.Tags, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
+	setETag(c, resource.Version)
 	c.JSON(http.StatusOK, gin.H{"resource": resource})
 }
 
-// DeleteResource handles DELETE /api/resources/:id
-func (h *ResourceHandlers) DeleteResource(c *gin.Context) {
+// RefreshMetadata handles POST /api/resources/:id/metadata, fetching the
+// resource's page again
+func (h *ResourceHandlers) RefreshMetadata(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "resource ID is required"})
+		respondInvalidRequest(c, "resource ID is required")
 		return
 	}
 
-	err := h.resourceService.DeleteResource(id)
+	resource, err := h.resourceService.RefreshMetadata(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusNoContent, nil)
+	setETag(c, resource.Version)
+	c.JSON(http.StatusOK, gin.H{"resource": resource})
 }
 
-// GetResourcesByType handles GET /api/resources?type=link
-func (h *ResourceHandlers) GetResourcesByType(c *gin.Context) {
-	resourceType := c.Query("type")
-	if resourceType == "" {
-		h.ListResources(c)
+// CheckHealth handles POST /api/resources/:id/health, checking the
+// resource's link again
+func (h *ResourceHandlers) CheckHealth(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "resource ID is required")
 		return
 	}
 
-	resources, err := h.resourceService.GetResourcesByType(models.ResourceType(resourceType))
+	resource, err := h.resourceService.CheckHealth(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"resources": resources})
+	setETag(c, resource.Version)
+	c.JSON(http.StatusOK, gin.H{"resource": resource})
 }
 
-// GetResourcesByCategory handles GET /api/resources?category=research
-func (h *ResourceHandlers) GetResourcesByCategory(c *gin.Context) {
-	category := c.Query("category")
+// GetArchive handles GET /api/resources/:id/archive, returning the archived
+// copy of the resource's page. By default the readable text is returned as
+// JSON; ?format=text returns it as plain text and ?format=html returns the
+// page as it was downloaded, sandboxed so its scripts cannot run.
+func (h *ResourceHandlers) GetArchive(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "resource ID is required")
+		return
+	}
 
-	resources, err := h.resourceService.GetResourcesByCategory(category)
+	archive, err := h.resourceService.GetArchive(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"resources": resources})
+	switch c.Query("format") {
+	case "":
+		archive.HTML = ""
+		c.JSON(http.StatusOK, gin.H{"archive": archive})
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(archive.Text))
+	case "html":
+		c.Header("Content-Security-Policy", "sandbox")
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(archive.HTML))
+	default:
+		respondInvalidRequest(c, "format must be text or html")
+	}
+}
+
+// ArchivePage handles POST /api/resources/:id/archive, fetching the
+// resource's page again and replacing its archive
+func (h *ResourceHandlers) ArchivePage(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "resource ID is required")
+		return
+	}
+
+	archive, err := h.resourceService.ArchivePage(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	archive.HTML = ""
+	c.JSON(http.StatusOK, gin.H{"archive": archive})
+}
+
+// GetReferences handles GET /api/resources/:id/references
+func (h *ResourceHandlers) GetReferences(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "resource ID is required")
+		return
+	}
+
+	references, err := h.resourceService.GetReferences(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"references": references})
+}
+
+// DeleteResource handles DELETE /api/resources/:id?references=unlink|refuse
+func (h *ResourceHandlers) DeleteResource(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		respondInvalidRequest(c, "resource ID is required")
+		return
+	}
+
+	err := h.resourceService.DeleteResource(id, services.ReferencePolicy(c.Query("references")))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
 }
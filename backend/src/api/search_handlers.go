@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"inspiration-blog-writer/backend/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandlers handles HTTP requests for full-text search
+type SearchHandlers struct {
+	searchService *services.SearchService
+}
+
+// NewSearchHandlers creates new search handlers
+func NewSearchHandlers(searchService *services.SearchService) *SearchHandlers {
+	return &SearchHandlers{
+		searchService: searchService,
+	}
+}
+
+// Search handles GET /api/search?q=&limit=
+func (h *SearchHandlers) Search(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			respondInvalidRequest(c, "limit must be a number")
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.searchService.Search(c.Query("q"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
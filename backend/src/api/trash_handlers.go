@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+
+	"inspiration-blog-writer/backend/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashHandlers handles HTTP requests for trashed drafts and resources
+type TrashHandlers struct {
+	trashService *services.TrashService
+}
+
+// NewTrashHandlers creates new trash handlers
+func NewTrashHandlers(trashService *services.TrashService) *TrashHandlers {
+	return &TrashHandlers{
+		trashService: trashService,
+	}
+}
+
+// ListTrash handles GET /api/trash
+func (h *TrashHandlers) ListTrash(c *gin.Context) {
+	trash, err := h.trashService.List()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trash": trash})
+}
+
+// EmptyTrash handles DELETE /api/trash
+func (h *TrashHandlers) EmptyTrash(c *gin.Context) {
+	purged, err := h.trashService.Empty()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+// RestoreDraft handles POST /api/trash/drafts/:id/restore
+func (h *TrashHandlers) RestoreDraft(c *gin.Context) {
+	draft, err := h.trashService.RestoreDraft(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, draft.Version)
+	c.JSON(http.StatusOK, gin.H{"draft": draft})
+}
+
+// PurgeDraft handles DELETE /api/trash/drafts/:id
+func (h *TrashHandlers) PurgeDraft(c *gin.Context) {
+	if err := h.trashService.PurgeDraft(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RestoreResource handles POST /api/trash/resources/:id/restore
+func (h *TrashHandlers) RestoreResource(c *gin.Context) {
+	resource, err := h.trashService.RestoreResource(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, resource.Version)
+	c.JSON(http.StatusOK, gin.H{"resource": resource})
+}
+
+// PurgeResource handles DELETE /api/trash/resources/:id
+func (h *TrashHandlers) PurgeResource(c *gin.Context) {
+	if err := h.trashService.PurgeResource(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
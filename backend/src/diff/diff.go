@@ -0,0 +1,213 @@
+// Package diff computes line and word differences between two texts using
+// Myers' O(ND) algorithm.
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxEditDistance bounds the work spent on very different texts. Beyond it
+// the texts are reported as entirely replaced.
+const maxEditDistance = 2000
+
+// Op is the kind of change an Edit describes
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Edit is a run of text that is unchanged, inserted or deleted. Joining the
+// Equal and Delete edits gives the old text; Equal and Insert the new one.
+type Edit struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Result is the difference between two texts
+type Result struct {
+	Edits []Edit `json:"edits"`
+	// Insertions and Deletions count changed lines or words
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// Lines compares a and b line by line
+func Lines(a, b string) Result {
+	return compute(splitLines(a), splitLines(b))
+}
+
+// Words compares a and b word by word. Whitespace is kept in the edits so
+// they reproduce both texts exactly, but is not counted as a change.
+func Words(a, b string) Result {
+	return compute(splitWords(a), splitWords(b))
+}
+
+func compute(a, b []string) Result {
+	// Common prefix and suffix are cheap to find and shrink the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var tokens []token
+	for _, text := range a[:prefix] {
+		tokens = append(tokens, token{Equal, text})
+	}
+	tokens = append(tokens, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		tokens = append(tokens, token{Equal, text})
+	}
+
+	result := Result{Edits: []Edit{}}
+	for _, t := range tokens {
+		if strings.TrimSpace(t.text) != "" {
+			switch t.op {
+			case Insert:
+				result.Insertions++
+			case Delete:
+				result.Deletions++
+			}
+		}
+
+		last := len(result.Edits) - 1
+		if last >= 0 && result.Edits[last].Op == t.op {
+			result.Edits[last].Text += t.text
+			continue
+		}
+		result.Edits = append(result.Edits, Edit{Op: t.op, Text: t.text})
+	}
+	return result
+}
+
+type token struct {
+	op   Op
+	text string
+}
+
+// myers returns the shortest edit script turning a into b, one token per
+// entry. The V array of every round is kept, trimmed to the diagonals that
+// round could reach, so the path can be traced back.
+func myers(a, b []string) []token {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEditDistance {
+		limit = maxEditDistance
+	}
+
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		tokens := make([]token, 0, n+m)
+		for _, text := range a {
+			tokens = append(tokens, token{Delete, text})
+		}
+		for _, text := range b {
+			tokens = append(tokens, token{Insert, text})
+		}
+		return tokens
+	}
+
+	var reversed []token
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		prev := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = prev[prevK+d]
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, token{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, token{Insert, b[y-1]})
+			} else {
+				reversed = append(reversed, token{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	tokens := make([]token, len(reversed))
+	for i, t := range reversed {
+		tokens[len(reversed)-1-i] = t
+	}
+	return tokens
+}
+
+// splitLines splits s after every newline, keeping the newlines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// splitWords splits s into alternating runs of whitespace and other
+// characters
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	prevSpace := false
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > 0 && space != prevSpace {
+			words = append(words, s[start:i])
+			start = i
+		}
+		prevSpace = space
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
@@ -0,0 +1,235 @@
+// Package fetch downloads web pages for collected resources, reads their
+// metadata and readable text, and checks that their links still work. Every
+// request has a timeout and every body a size limit, so a slow or huge page
+// cannot hold up the server, and only public addresses are dialled, so a
+// submitted link cannot reach into the server's own network.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Defaults for a zero Config
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxBytes     = 2 << 20
+	DefaultMaxRedirects = 5
+	DefaultUserAgent    = "IdeaSparker/1.0"
+)
+
+var (
+	// ErrUnsupportedURL is returned for URLs that are not http or https
+	ErrUnsupportedURL = errors.New("only http and https URLs can be fetched")
+	// ErrNotHTML is returned when a page is not an HTML document
+	ErrNotHTML = errors.New("page is not HTML")
+	// ErrPrivateAddress is returned when a URL, or a redirect, leads to a
+	// loopback, private, link-local or unspecified address
+	ErrPrivateAddress = errors.New("only public addresses can be fetched")
+)
+
+// StatusError is returned when a server answers with an error status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Config configures a Fetcher
+type Config struct {
+	// Timeout bounds each request, including reading the body
+	Timeout time.Duration
+	// MaxBytes is how much of a body is read; the rest is ignored
+	MaxBytes int64
+	// MaxRedirects is how many redirects are followed
+	MaxRedirects int
+	UserAgent    string
+	// AllowPrivateNetworks lets requests reach loopback, private and
+	// link-local addresses, for pages served on the local network
+	AllowPrivateNetworks bool
+	// Transport sends the requests as is, leaving address checks to it; nil
+	// uses a copy of http.DefaultTransport that dials public addresses only
+	Transport http.RoundTripper
+}
+
+// Fetcher downloads pages. It is safe for concurrent use.
+type Fetcher struct {
+	client    *http.Client
+	maxBytes  int64
+	userAgent string
+}
+
+// NewFetcher creates a fetcher, filling in defaults for zero fields of cfg
+func NewFetcher(cfg Config) *Fetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultMaxBytes
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = DefaultMaxRedirects
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+
+	transport := cfg.Transport
+	if transport == nil {
+		transport = newTransport(cfg.AllowPrivateNetworks)
+	}
+
+	maxRedirects := cfg.MaxRedirects
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return checkScheme(req.URL)
+			},
+		},
+		maxBytes:  cfg.MaxBytes,
+		userAgent: cfg.UserAgent,
+	}
+}
+
+// Page is a downloaded HTML page
+type Page struct {
+	// URL is where the page was found after redirects
+	URL *url.URL
+	// Body is the page decoded to UTF-8, cut at the size limit
+	Body []byte
+}
+
+// Get downloads the HTML page at rawURL
+func (f *Fetcher) Get(ctx context.Context, rawURL string) (*Page, error) {
+	resp, err := f.do(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.maxBytes), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("decode page: %w", err)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read page: %w", err)
+	}
+	return &Page{URL: resp.Request.URL, Body: data}, nil
+}
+
+// Probe is the answer to a link check
+type Probe struct {
+	// StatusCode is the status of the last response, after redirects
+	StatusCode int
+	// URL is where the link led after redirects
+	URL *url.URL
+}
+
+// Probe checks the link at rawURL without downloading the page. It sends a
+// HEAD request, then a GET request if the server fails or rejects the HEAD,
+// as many do. Error statuses are reported in the result; an error means the
+// link could not be followed at all.
+func (f *Fetcher) Probe(ctx context.Context, rawURL string) (*Probe, error) {
+	resp, err := f.do(ctx, http.MethodHead, rawURL)
+	if errors.Is(err, ErrUnsupportedURL) || errors.Is(err, ErrPrivateAddress) || ctx.Err() != nil {
+		return nil, err
+	}
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			return &Probe{StatusCode: resp.StatusCode, URL: resp.Request.URL}, nil
+		}
+	}
+
+	resp, err = f.do(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return &Probe{StatusCode: resp.StatusCode, URL: resp.Request.URL}, nil
+}
+
+// do sends a request for rawURL, following redirects
+func (f *Fetcher) do(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+	return f.client.Do(req)
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrUnsupportedURL
+	}
+	return nil
+}
+
+// newTransport copies http.DefaultTransport. Unless private networks are
+// allowed it checks every address it dials, after DNS resolution and on
+// every redirect, and uses no proxy, which would dial for it.
+func newTransport(allowPrivate bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivate {
+		return transport
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkAddress,
+	}
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// checkAddress refuses to dial addresses that are not public
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Lengths, in runes, past which metadata text is cut
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+)
+
+// Metadata describes a page as its author did, in the <title>, <meta> and
+// <link> tags. URLs are absolute. Empty fields were not found.
+type Metadata struct {
+	Title        string
+	Description  string
+	CanonicalURL string
+	SiteName     string
+	ImageURL     string
+	FaviconURL   string
+	PublishedAt  *time.Time
+}
+
+// Metadata downloads the page at rawURL and reads its metadata
+func (f *Fetcher) Metadata(ctx context.Context, rawURL string) (*Metadata, error) {
+	page, err := f.Get(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMetadata(page.URL, page.Body), nil
+}
+
+// publishedKeys are the <meta> names and properties that hold a publish
+// date, most reliable first
+var publishedKeys = []string{
+	"article:published_time", "og:published_time", "datepublished",
+	"date", "pubdate", "publish-date", "dc.date.issued", "dc.date",
+}
+
+// ParseMetadata reads the metadata of an HTML page found at base. OpenGraph
+// and Twitter card fields win over the plain <title> and description, which
+// sites often pad with their own name.
+func ParseMetadata(base *url.URL, body []byte) *Metadata {
+	meta := make(map[string]string)
+	var title, canonical, icon, touchIcon, timeTag string
+
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		attrs := make(map[string]string)
+		for hasAttr {
+			var key, value []byte
+			key, value, hasAttr = z.TagAttr()
+			attrs[string(key)] = string(value)
+		}
+
+		switch atom.Lookup(name) {
+		case atom.Title:
+			if title == "" && z.Next() == html.TextToken {
+				title = string(z.Text())
+			}
+		case atom.Meta:
+			key := attrs["property"]
+			if key == "" {
+				key = attrs["name"]
+			}
+			if key == "" {
+				key = attrs["itemprop"]
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			if _, seen := meta[key]; key != "" && !seen {
+				meta[key] = strings.TrimSpace(attrs["content"])
+			}
+		case atom.Link:
+			rels := strings.Fields(strings.ToLower(attrs["rel"]))
+			href := strings.TrimSpace(attrs["href"])
+			for _, rel := range rels {
+				switch {
+				case rel == "canonical" && canonical == "":
+					canonical = href
+				case rel == "icon" && icon == "":
+					icon = href
+				case rel == "apple-touch-icon" && touchIcon == "":
+					touchIcon = href
+				}
+			}
+		case atom.Time:
+			if timeTag == "" {
+				timeTag = attrs["datetime"]
+			}
+		}
+	}
+
+	result := &Metadata{
+		Title:        clean(first(meta["og:title"], meta["twitter:title"], title), maxTitleLength),
+		Description:  clean(first(meta["og:description"], meta["twitter:description"], meta["description"]), maxDescriptionLength),
+		CanonicalURL: resolve(base, first(canonical, meta["og:url"])),
+		SiteName:     clean(first(meta["og:site_name"], meta["application-name"]), maxTitleLength),
+		ImageURL:     resolve(base, first(meta["og:image"], meta["twitter:image"], meta["twitter:image:src"])),
+		FaviconURL:   resolve(base, first(icon, touchIcon, "/favicon.ico")),
+	}
+	for _, key := range publishedKeys {
+		if published, ok := parseDate(meta[key]); ok {
+			result.PublishedAt = &published
+			break
+		}
+	}
+	if published, ok := parseDate(timeTag); ok && result.PublishedAt == nil {
+		result.PublishedAt = &published
+	}
+	return result
+}
+
+// first returns the first non-blank value
+func first(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// clean collapses whitespace and cuts text to at most limit runes
+func clean(text string, limit int) string {
+	text = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+	if runes := []rune(text); len(runes) > limit {
+		text = strings.TrimSpace(string(runes[:limit-1])) + "…"
+	}
+	return text
+}
+
+// resolve makes href absolute against base, dropping anything but http and
+// https links
+func resolve(base *url.URL, href string) string {
+	if href == "" {
+		return ""
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	abs := base.ResolveReference(ref)
+	if checkScheme(abs) != nil {
+		return ""
+	}
+	return abs.String()
+}
+
+// dateLayouts are the publish date formats seen in the wild
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+func parseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,345 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Snapshot is a downloaded page with everything read from it
+type Snapshot struct {
+	Page     *Page
+	Metadata *Metadata
+	// Text is the readable text of the page, see ReadableText
+	Text string
+}
+
+// Snapshot downloads the page at rawURL and reads its metadata and readable
+// text
+func (f *Fetcher) Snapshot(ctx context.Context, rawURL string) (*Snapshot, error) {
+	page, err := f.Get(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		Page:     page,
+		Metadata: ParseMetadata(page.URL, page.Body),
+		Text:     ReadableText(page.Body),
+	}, nil
+}
+
+const (
+	// minParagraphLength is how many runes a paragraph needs to count
+	// towards the score of the element holding it
+	minParagraphLength = 25
+
+	// minArticleLength is how many runes an <article> or <main> needs to be
+	// taken as the main content without scoring
+	minArticleLength = 200
+)
+
+// boilerplateTags never hold the main content of a page
+var boilerplateTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Menu: true,
+	atom.Form: true, atom.Button: true, atom.Select: true, atom.Textarea: true,
+	atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Svg: true, atom.Canvas: true, atom.Dialog: true,
+}
+
+// boilerplateNames and contentNames are matched against the class and id of
+// an element. Elements with a class or id starting like boilerplate, as in
+// "sidebar-left" but not "has-sidebar", are dropped unless they are also
+// named like content.
+var (
+	boilerplateNames = regexp.MustCompile(`(?i)(^|\s)(comments?|sidebar|footer|nav|navbar|navigation|menu|share|sharing|social|related|recommended|promo|advert\w*|ads?|sponsored|cookies?|consent|banner|subscribe|newsletter|breadcrumbs?|popup|modal|widget|signup|pagination)($|[\s_-])`)
+	contentNames     = regexp.MustCompile(`(?i)article|content|main|post|entry|story|body|text`)
+)
+
+// blockTags start a new paragraph in the readable text
+var blockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Table: true, atom.Tr: true, atom.Td: true, atom.Th: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Br: true, atom.Hr: true,
+}
+
+// paragraphTags hold the text that scores candidates for the main content
+var paragraphTags = map[atom.Atom]bool{atom.P: true, atom.Pre: true, atom.Blockquote: true, atom.Td: true}
+
+// ReadableText extracts the main article of an HTML page as plain text, the
+// way reader views do: scripts, navigation, sidebars, comments, ads and
+// other boilerplate are dropped, and of what remains the element whose
+// paragraphs hold the most prose, and the fewest links, is kept. Paragraphs
+// are separated by blank lines and list items start with "- ".
+func ReadableText(body []byte) string {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	removeBoilerplate(doc)
+
+	root := findMainContent(doc)
+	if root == nil {
+		return ""
+	}
+	var w textWriter
+	w.write(root)
+	return w.String()
+}
+
+// removeBoilerplate detaches every element that is boilerplate by tag, name
+// or because it is hidden
+func removeBoilerplate(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.CommentNode:
+			n.RemoveChild(child)
+		case child.Type == html.ElementNode && isBoilerplate(child):
+			n.RemoveChild(child)
+		default:
+			removeBoilerplate(child)
+		}
+		child = next
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if boilerplateTags[n.DataAtom] {
+		return true
+	}
+	if _, hidden := attr(n, "hidden"); hidden || attrValue(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attrValue(n, "style")), " ", "")
+	if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	names := attrValue(n, "class") + " " + attrValue(n, "id")
+	return boilerplateNames.MatchString(names) && !contentNames.MatchString(names)
+}
+
+// findMainContent returns the element holding the page's main content: its
+// only sizeable <article> or <main>, or else the element whose paragraphs
+// score best
+func findMainContent(doc *html.Node) *html.Node {
+	for _, tag := range []atom.Atom{atom.Article, atom.Main} {
+		var found []*html.Node
+		walk(doc, func(n *html.Node) {
+			if n.DataAtom == tag && textLength(n) >= minArticleLength {
+				found = append(found, n)
+			}
+		})
+		if len(found) == 1 {
+			return found[0]
+		}
+	}
+
+	// Each paragraph adds to the score of its parent and half as much to
+	// its grandparent, so the element wrapping the article body wins
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, seen := scores[n]; !seen {
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	walk(doc, func(n *html.Node) {
+		if !paragraphTags[n.DataAtom] {
+			return
+		}
+		text := innerText(n)
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "、"))
+		score += min(float64(length)/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		score := scores[candidate] * (1 - linkDensity(candidate))
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	// No prose at all: fall back to whatever text the body has left
+	var body *html.Node
+	walk(doc, func(n *html.Node) {
+		if n.DataAtom == atom.Body && body == nil {
+			body = n
+		}
+	})
+	return body
+}
+
+// linkDensity is the share of an element's text that sits inside links
+func linkDensity(n *html.Node) float64 {
+	total := textLength(n)
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walk(n, func(child *html.Node) {
+		if child.DataAtom == atom.A {
+			links += textLength(child)
+		}
+	})
+	return min(float64(links)/float64(total), 1)
+}
+
+func textLength(n *html.Node) int {
+	return utf8.RuneCountInString(innerText(n))
+}
+
+// innerText returns the text of n with whitespace collapsed
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(child *html.Node) {
+		if child.Type == html.TextNode {
+			b.WriteString(child.Data)
+			b.WriteByte(' ')
+		}
+	})
+	return collapseSpace(b.String())
+}
+
+// walk calls fn for n and every node below it, parents first
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walk(child, fn)
+	}
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attrValue(n *html.Node, key string) string {
+	value, _ := attr(n, key)
+	return value
+}
+
+// textWriter renders elements as paragraphs of plain text
+type textWriter struct {
+	paragraphs []string
+	current    strings.Builder
+}
+
+func (w *textWriter) write(n *html.Node) {
+	switch {
+	case n.Type == html.TextNode:
+		w.current.WriteString(n.Data)
+		return
+	case n.Type != html.ElementNode && n.Type != html.DocumentNode:
+		return
+	case n.DataAtom == atom.Pre:
+		w.endParagraph()
+		var b strings.Builder
+		walk(n, func(child *html.Node) {
+			if child.Type == html.TextNode {
+				b.WriteString(child.Data)
+			}
+		})
+		if text := strings.Trim(b.String(), "\n"); strings.TrimSpace(text) != "" {
+			w.paragraphs = append(w.paragraphs, text)
+		}
+		return
+	}
+
+	block := blockTags[n.DataAtom]
+	if block {
+		w.endParagraph()
+	}
+	if n.DataAtom == atom.Li {
+		w.current.WriteString("- ")
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.write(child)
+	}
+	if block {
+		w.endParagraph()
+	}
+}
+
+func (w *textWriter) endParagraph() {
+	text := collapseSpace(w.current.String())
+	w.current.Reset()
+	if text != "" && text != "-" {
+		w.paragraphs = append(w.paragraphs, text)
+	}
+}
+
+func (w *textWriter) String() string {
+	w.endParagraph()
+	var b strings.Builder
+	for i, paragraph := range w.paragraphs {
+		if i > 0 {
+			// List items follow each other on consecutive lines
+			if strings.HasPrefix(paragraph, "- ") && strings.HasPrefix(w.paragraphs[i-1], "- ") {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(paragraph)
+	}
+	return b.String()
+}
+
+// collapseSpace turns every run of whitespace into a single space, or into
+// nothing between two CJK characters, where line breaks in the page source
+// do not separate words
+func collapseSpace(text string) string {
+	var b strings.Builder
+	var last rune
+	pending := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			pending = b.Len() > 0
+			continue
+		}
+		if pending && !(isCJKRune(last) && isCJKRune(r)) {
+			b.WriteByte(' ')
+		}
+		pending = false
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+func isCJKRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		unicode.In(r, unicode.Common) && r >= 0x3000 && r <= 0x303f
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+)
+
+const fakeEmbeddingDimensions = 32
+
+// FakeProvider is a deterministic in-process provider for tests and offline
+// development. Queued responses are returned in order; once the queue is
+// empty it echoes the last user message.
+type FakeProvider struct {
+	mu        sync.Mutex
+	responses []string
+	err       error
+	requests  []CompletionRequest
+}
+
+// NewFakeProvider creates a fake provider that returns responses in order
+func NewFakeProvider(responses ...string) *FakeProvider {
+	return &FakeProvider{responses: responses}
+}
+
+// Name returns the provider identifier
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// Model returns the fake model name
+func (p *FakeProvider) Model() string {
+	return "fake-model"
+}
+
+// Enqueue appends responses to be returned by later calls
+func (p *FakeProvider) Enqueue(responses ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses = append(p.responses, responses...)
+}
+
+// SetError makes every subsequent call fail with err; nil restores normal
+// behaviour
+func (p *FakeProvider) SetError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Requests returns every completion request received so far
+func (p *FakeProvider) Requests() []CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]CompletionRequest(nil), p.requests...)
+}
+
+// Complete returns the next queued response
+func (p *FakeProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	content, err := p.next(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.response(req, content), nil
+}
+
+// Stream delivers the next queued response word by word
+func (p *FakeProvider) Stream(ctx context.Context, req CompletionRequest, onDelta StreamHandler) (*CompletionResponse, error) {
+	content, err := p.next(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, delta := range strings.SplitAfter(content, " ") {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if delta == "" {
+			continue
+		}
+		if err := onDelta(delta); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.response(req, content), nil
+}
+
+// Embed returns a normalized bag-of-words hash vector for each text, so
+// texts sharing words have a higher cosine similarity
+func (p *FakeProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	p.mu.Lock()
+	err := p.err
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, fakeEmbeddingDimensions)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vector[h.Sum32()%fakeEmbeddingDimensions]++
+		}
+
+		var norm float64
+		for _, v := range vector {
+			norm += v * v
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for j := range vector {
+				vector[j] /= norm
+			}
+		}
+		embeddings[i] = vector
+	}
+	return embeddings, nil
+}
+
+func (p *FakeProvider) next(ctx context.Context, req CompletionRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return "", p.err
+	}
+
+	if len(p.responses) > 0 {
+		content := p.responses[0]
+		p.responses = p.responses[1:]
+		return content, nil
+	}
+
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == RoleUser {
+			return "Echo: " + req.Messages[i].Content, nil
+		}
+	}
+	return "Echo:", nil
+}
+
+func (p *FakeProvider) response(req CompletionRequest, content string) *CompletionResponse {
+	promptTokens := 0
+	for _, message := range req.Messages {
+		promptTokens += len(strings.Fields(message.Content))
+	}
+	return &CompletionResponse{
+		Content:          content,
+		Model:            p.Model(),
+		PromptTokens:     promptTokens,
+		CompletionTokens: len(strings.Fields(content)),
+	}
+}
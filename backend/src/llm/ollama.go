@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaProvider talks to a local Ollama server using its native API
+type OllamaProvider struct {
+	baseURL        string
+	model          string
+	embeddingModel string
+	client         *http.Client
+}
+
+// NewOllamaProvider creates an Ollama provider. An empty baseURL defaults to
+// the local Ollama daemon.
+func NewOllamaProvider(baseURL, model, embeddingModel string, client *http.Client) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if embeddingModel == "" {
+		embeddingModel = model
+	}
+	return &OllamaProvider{
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		model:          model,
+		embeddingModel: embeddingModel,
+		client:         client,
+	}
+}
+
+type ollamaChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+// Name returns the provider identifier
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+// Model returns the chat model used for completions
+func (p *OllamaProvider) Model() string {
+	return p.model
+}
+
+// Complete runs a non-streaming chat completion
+func (p *OllamaProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	resp, err := postJSON(ctx, p.client, p.Name(), p.baseURL+"/api/chat", nil, p.chatRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("ollama: decode response: %w", err)
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("ollama: %s", body.Error)
+	}
+
+	return &CompletionResponse{
+		Content:          body.Message.Content,
+		Model:            body.Model,
+		PromptTokens:     body.PromptEvalCount,
+		CompletionTokens: body.EvalCount,
+	}, nil
+}
+
+// Stream runs a streaming chat completion over newline-delimited JSON
+func (p *OllamaProvider) Stream(ctx context.Context, req CompletionRequest, onDelta StreamHandler) (*CompletionResponse, error) {
+	resp, err := postJSON(ctx, p.client, p.Name(), p.baseURL+"/api/chat", nil, p.chatRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &CompletionResponse{Model: p.model}
+	var content strings.Builder
+	done := false
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return nil, fmt.Errorf("ollama: decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama: %s", chunk.Error)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			if err := onDelta(chunk.Message.Content); err != nil {
+				return nil, err
+			}
+		}
+		if chunk.Done {
+			result.PromptTokens = chunk.PromptEvalCount
+			result.CompletionTokens = chunk.EvalCount
+			done = true
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ollama: read stream: %w", err)
+	}
+	if !done {
+		return nil, fmt.Errorf("ollama: stream ended before its final chunk: %w", io.ErrUnexpectedEOF)
+	}
+
+	result.Content = content.String()
+	return result, nil
+}
+
+// Embed returns embeddings for texts using the configured embedding model
+func (p *OllamaProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body := map[string]interface{}{
+		"model": p.embeddingModel,
+		"input": texts,
+	}
+
+	resp, err := postJSON(ctx, p.client, p.Name(), p.baseURL+"/api/embed", nil, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("ollama: decode embeddings: %w", err)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama: expected %d embeddings, got %d", len(texts), len(result.Embeddings))
+	}
+
+	return result.Embeddings, nil
+}
+
+func (p *OllamaProvider) chatRequest(req CompletionRequest, stream bool) ollamaChatRequest {
+	body := ollamaChatRequest{
+		Model:    p.model,
+		Messages: req.Messages,
+		Stream:   stream,
+	}
+
+	options := map[string]interface{}{}
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	if len(options) > 0 {
+		body.Options = options
+	}
+	return body
+}
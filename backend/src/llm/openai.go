@@ -0,0 +1,240 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIProvider talks to any server implementing the OpenAI chat
+// completions and embeddings APIs
+type OpenAIProvider struct {
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string
+	client         *http.Client
+
+	// noStreamOptions is set once the server has rejected stream_options,
+	// which not every OpenAI-compatible server supports
+	noStreamOptions atomic.Bool
+}
+
+// NewOpenAIProvider creates an OpenAI-compatible provider. An empty baseURL
+// defaults to the public OpenAI API.
+func NewOpenAIProvider(baseURL, apiKey, model, embeddingModel string, client *http.Client) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenAIProvider{
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		apiKey:         apiKey,
+		model:          model,
+		embeddingModel: embeddingModel,
+		client:         client,
+	}
+}
+
+type openAIChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []Message            `json:"messages"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// Name returns the provider identifier
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// Model returns the chat model used for completions
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+// Complete runs a non-streaming chat completion
+func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	resp, err := postJSON(ctx, p.client, p.Name(), p.baseURL+"/chat/completions", p.headers(), p.chatRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("openai: decode response: %w", err)
+	}
+	if len(body.Choices) == 0 {
+		return nil, fmt.Errorf("openai: response has no choices")
+	}
+
+	result := &CompletionResponse{
+		Content: body.Choices[0].Message.Content,
+		Model:   body.Model,
+	}
+	if body.Usage != nil {
+		result.PromptTokens = body.Usage.PromptTokens
+		result.CompletionTokens = body.Usage.CompletionTokens
+	}
+	return result, nil
+}
+
+// Stream runs a streaming chat completion over server-sent events. Token
+// usage is asked for with stream_options; a server that rejects the request
+// with 400 is asked again without them, and from then on never with them.
+func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest, onDelta StreamHandler) (*CompletionResponse, error) {
+	url := p.baseURL + "/chat/completions"
+	body := p.chatRequest(req, true)
+	resp, err := postJSON(ctx, p.client, p.Name(), url, p.headers(), body)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && body.StreamOptions != nil {
+		body.StreamOptions = nil
+		if resp, err = postJSON(ctx, p.client, p.Name(), url, p.headers(), body); err == nil {
+			p.noStreamOptions.Store(true)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &CompletionResponse{Model: p.model}
+	var content strings.Builder
+	done := false
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk openAIChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("openai: decode stream chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.PromptTokens = chunk.Usage.PromptTokens
+			result.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("openai: read stream: %w", err)
+	}
+	if !done {
+		return nil, fmt.Errorf("openai: stream ended before [DONE]: %w", io.ErrUnexpectedEOF)
+	}
+
+	result.Content = content.String()
+	return result, nil
+}
+
+// Embed returns embeddings for texts using the configured embedding model
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body := map[string]interface{}{
+		"model": p.embeddingModel,
+		"input": texts,
+	}
+
+	resp, err := postJSON(ctx, p.client, p.Name(), p.baseURL+"/embeddings", p.headers(), body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("openai: decode embeddings: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("openai: expected %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("openai: embedding index %d out of range", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+	return embeddings, nil
+}
+
+func (p *OpenAIProvider) chatRequest(req CompletionRequest, stream bool) openAIChatRequest {
+	body := openAIChatRequest{
+		Model:       p.model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+	}
+	if stream && !p.noStreamOptions.Load() {
+		body.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	return body
+}
+
+func (p *OpenAIProvider) headers() map[string]string {
+	if p.apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + p.apiKey}
+}
@@ -0,0 +1,155 @@
+// Package llm defines a provider-agnostic interface for language models and
+// ships OpenAI-compatible, Ollama and deterministic fake implementations.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Role identifies the author of a chat message sent to a model
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a single turn in a completion request
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest describes a chat completion call
+type CompletionRequest struct {
+	Messages []Message
+	// Temperature is the sampling temperature; nil leaves the provider's
+	// default, and 0 asks for the most deterministic output
+	Temperature *float64
+	MaxTokens   int
+}
+
+// Temperature returns value as a CompletionRequest temperature
+func Temperature(value float64) *float64 {
+	return &value
+}
+
+// CompletionResponse is the result of a completion or a finished stream
+type CompletionResponse struct {
+	Content          string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// StreamHandler receives content deltas as they arrive. Returning an error
+// aborts the stream and is returned from Stream.
+type StreamHandler func(delta string) error
+
+// Provider is implemented by every language model backend
+type Provider interface {
+	// Name returns a short identifier such as "openai" or "ollama"
+	Name() string
+	// Model returns the chat model used for completions
+	Model() string
+	// Complete runs a chat completion and returns the full response
+	Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error)
+	// Stream runs a chat completion, calling onDelta for every content delta,
+	// and returns the assembled response once the model is done
+	Stream(ctx context.Context, req CompletionRequest, onDelta StreamHandler) (*CompletionResponse, error)
+	// Embed returns one embedding vector per input text
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// ErrNotConfigured is returned when a feature needs a provider but none is set
+var ErrNotConfigured = errors.New("no language model provider configured")
+
+// APIError is returned when a provider responds with a non-2xx status
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Config selects and configures a provider at startup
+type Config struct {
+	Provider       string // "openai", "ollama", "fake" or empty for none
+	BaseURL        string
+	APIKey         string
+	Model          string
+	EmbeddingModel string
+	// Timeout bounds the wait for the response headers of each request
+	Timeout time.Duration
+}
+
+// NewProvider builds the provider described by cfg. It returns nil and no
+// error when no provider is configured.
+func NewProvider(cfg Config) (Provider, error) {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+	// The timeout only bounds the wait for response headers: a client timeout
+	// would also cut off streams that run longer than that, so a stream is
+	// limited by its request context alone
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	client := &http.Client{Transport: transport}
+
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "openai":
+		return NewOpenAIProvider(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.EmbeddingModel, client), nil
+	case "ollama":
+		return NewOllamaProvider(cfg.BaseURL, cfg.Model, cfg.EmbeddingModel, client), nil
+	case "fake":
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
+
+// postJSON sends body as JSON and returns the response when it has a 2xx
+// status. The caller must close the response body.
+func postJSON(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: encode request: %w", provider, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("%s: build request: %w", provider, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", provider, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIError{Provider: provider, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+
+	return resp, nil
+}
@@ -1,9 +1,22 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
 
 	"inspiration-blog-writer/backend/src/api"
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/search"
 	"inspiration-blog-writer/backend/src/services"
 	"inspiration-blog-writer/backend/src/storage"
 
@@ -12,15 +25,80 @@ import (
 
 func main() {
 	// Initialize storage
-	store := storage.NewMemoryStorage()
+	store, err := openStorage(getEnv("STORAGE_BACKEND", "memory"), getEnv("STORAGE_DIR", "data"))
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	// Initialize language model provider
+	provider, err := llm.NewProvider(llm.Config{
+		Provider:       getEnv("LLM_PROVIDER", ""),
+		BaseURL:        getEnv("LLM_BASE_URL", ""),
+		APIKey:         getEnv("LLM_API_KEY", ""),
+		Model:          getEnv("LLM_MODEL", ""),
+		EmbeddingModel: getEnv("LLM_EMBEDDING_MODEL", ""),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize language model provider: %v", err)
+	}
+	if provider != nil {
+		log.Printf("Using %s language model %q", provider.Name(), provider.Model())
+	}
+
+	trashRetention, err := time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil {
+		log.Fatalf("Invalid TRASH_RETENTION: %v", err)
+	}
+	fetchTimeout, err := time.ParseDuration(getEnv("FETCH_TIMEOUT", "10s"))
+	if err != nil {
+		log.Fatalf("Invalid FETCH_TIMEOUT: %v", err)
+	}
+	fetchMaxBytes, err := strconv.ParseInt(getEnv("FETCH_MAX_BYTES", strconv.Itoa(fetch.DefaultMaxBytes)), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid FETCH_MAX_BYTES: %v", err)
+	}
+	fetcher := fetch.NewFetcher(fetch.Config{
+		Timeout:              fetchTimeout,
+		MaxBytes:             fetchMaxBytes,
+		AllowPrivateNetworks: getEnv("FETCH_PRIVATE_NETWORKS", "false") == "true",
+	})
+	fetchMetadata := getEnv("FETCH_METADATA", "true") == "true"
+	archivePages := getEnv("ARCHIVE_PAGES", "true") == "true"
+	healthCheckInterval, err := time.ParseDuration(getEnv("HEALTH_CHECK_INTERVAL", "24h"))
+	if err != nil {
+		log.Fatalf("Invalid HEALTH_CHECK_INTERVAL: %v", err)
+	}
+
+	// Index existing content for search; writes keep the index current
+	index := search.NewIndex()
+	indexed, err := search.NewIndexedStorage(store, index)
+	if err != nil {
+		log.Fatalf("Failed to build search index: %v", err)
+	}
+	log.Printf("Indexed %d documents for search", index.Len())
 
 	// Initialize services
-	draftService := services.NewDraftService(store)
-	resourceService := services.NewResourceService(store)
+	draftService := services.NewDraftService(indexed)
+	resourceService := services.NewResourceService(indexed)
+	ideaService := services.NewIdeaService(indexed, provider)
+	chatService := services.NewChatService(indexed, provider)
+	trashService := services.NewTrashService(indexed, trashRetention)
+	searchService := services.NewSearchService(indexed, index)
+	metadataService := services.NewMetadataService(indexed, fetcher)
+	metadataService.SetArchiving(archivePages)
+	if fetchMetadata {
+		resourceService.SetMetadataService(metadataService)
+	}
+	healthService := services.NewHealthService(indexed, fetcher, healthCheckInterval)
+	resourceService.SetHealthService(healthService)
 
 	// Initialize handlers
 	draftHandlers := api.NewDraftHandlers(draftService)
 	resourceHandlers := api.NewResourceHandlers(resourceService)
+	ideaHandlers := api.NewIdeaHandlers(ideaService)
+	chatHandlers := api.NewChatHandlers(chatService)
+	trashHandlers := api.NewTrashHandlers(trashService)
+	searchHandlers := api.NewSearchHandlers(searchService)
 
 	// Create Gin router
 	r := gin.Default()
@@ -29,7 +107,8 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match")
+		c.Header("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -60,69 +139,142 @@ func main() {
 			drafts.DELETE("/:id", draftHandlers.DeleteDraft)
 			drafts.POST("/:id/resources", draftHandlers.AddResourceToDraft)
 			drafts.DELETE("/:id/resources/:resourceId", draftHandlers.RemoveResourceFromDraft)
+			drafts.GET("/:id/revisions", draftHandlers.ListRevisions)
+			drafts.GET("/:id/revisions/:number", draftHandlers.GetRevision)
+			drafts.POST("/:id/revisions/:number/restore", draftHandlers.RestoreRevision)
+			drafts.GET("/:id/diff", draftHandlers.DiffRevisions)
 		}
 
 		// Resources routes
 		resources := api.Group("/resources")
 		{
 			resources.GET("", resourceHandlers.ListResources)
+			resources.GET("/facets", resourceHandlers.GetFacets)
 			resources.POST("", resourceHandlers.CreateResource)
+			resources.POST("/dedupe", resourceHandlers.Dedupe)
 			resources.GET("/:id", resourceHandlers.GetResource)
 			resources.PUT("/:id", resourceHandlers.UpdateResource)
 			resources.DELETE("/:id", resourceHandlers.DeleteResource)
+			resources.GET("/:id/references", resourceHandlers.GetReferences)
+			resources.POST("/:id/metadata", resourceHandlers.RefreshMetadata)
+			resources.POST("/:id/health", resourceHandlers.CheckHealth)
+			resources.GET("/:id/archive", resourceHandlers.GetArchive)
+			resources.POST("/:id/archive", resourceHandlers.ArchivePage)
+			resources.GET("/:id/annotations", resourceHandlers.ListAnnotations)
+			resources.POST("/:id/annotations", resourceHandlers.CreateAnnotation)
+			resources.GET("/:id/annotations/:annotationId", resourceHandlers.GetAnnotation)
+			resources.PUT("/:id/annotations/:annotationId", resourceHandlers.UpdateAnnotation)
+			resources.DELETE("/:id/annotations/:annotationId", resourceHandlers.DeleteAnnotation)
 		}
 
-		// Ideas routes - placeholder handlers
+		// Ideas routes
 		ideas := api.Group("/ideas")
 		{
-			ideas.GET("", listIdeas)
-			ideas.POST("", createIdea)
-			ideas.GET("/:id", getIdea)
+			ideas.GET("", ideaHandlers.ListIdeas)
+			ideas.POST("", ideaHandlers.CreateIdea)
+			ideas.GET("/:id", ideaHandlers.GetIdea)
+			ideas.PUT("/:id", ideaHandlers.UpdateIdea)
+			ideas.DELETE("/:id", ideaHandlers.DeleteIdea)
 		}
 
-		// Chat routes - placeholder handlers
+		// Chat routes
 		chat := api.Group("/chat")
 		{
-			chat.POST("/sessions", createChatSession)
-			chat.GET("/sessions/:id", getChatSession)
-			chat.POST("/sessions/:id/messages", addChatMessage)
+			chat.GET("/sessions", chatHandlers.ListSessions)
+			chat.POST("/sessions", chatHandlers.CreateSession)
+			chat.GET("/sessions/:id", chatHandlers.GetSession)
+			chat.POST("/sessions/:id/messages", chatHandlers.SendMessage)
+			chat.GET("/sessions/:id/messages/:messageId", chatHandlers.GetMessage)
+			chat.POST("/sessions/:id/deactivate", chatHandlers.DeactivateSession)
 		}
 
+		// Trash routes
+		trash := api.Group("/trash")
+		{
+			trash.GET("", trashHandlers.ListTrash)
+			trash.DELETE("", trashHandlers.EmptyTrash)
+			trash.POST("/drafts/:id/restore", trashHandlers.RestoreDraft)
+			trash.DELETE("/drafts/:id", trashHandlers.PurgeDraft)
+			trash.POST("/resources/:id/restore", trashHandlers.RestoreResource)
+			trash.DELETE("/resources/:id", trashHandlers.PurgeResource)
+		}
+
+		// Search route
+		api.GET("/search", searchHandlers.Search)
+
 		// AI analysis route - placeholder handler
 		api.POST("/analyze", analyzeContent)
 	}
 
+	// Purge expired trash, check resource links and fetch resource metadata in
+	// the background
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	go trashService.Run(backgroundCtx, time.Hour)
+	go healthService.Run(backgroundCtx, time.Hour)
+	if fetchMetadata {
+		go metadataService.Run(backgroundCtx, time.Minute)
+	}
+
 	// Start server
 	port := ":8080"
-	log.Printf("Starting server on port %s", port)
-	log.Fatal(r.Run(port))
-}
+	srv := &http.Server{Addr: port, Handler: r}
 
-// Placeholder handlers for ideas, chat, and AI analysis - will be implemented later
-func listIdeas(c *gin.Context) {
-	c.JSON(200, []gin.H{})
-}
+	go func() {
+		log.Printf("Starting server on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
 
-func createIdea(c *gin.Context) {
-	c.JSON(201, gin.H{"message": "idea creation not implemented yet"})
-}
+	// Wait for an interrupt, then drain requests and flush storage
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server")
+	stopBackground()
 
-func getIdea(c *gin.Context) {
-	c.JSON(200, gin.H{"message": "idea retrieval not implemented yet"})
-}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
 
-func createChatSession(c *gin.Context) {
-	c.JSON(201, gin.H{"message": "chat session creation not implemented yet"})
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Failed to close storage: %v", err)
+		}
+	}
 }
 
-func getChatSession(c *gin.Context) {
-	c.JSON(200, gin.H{"message": "chat session retrieval not implemented yet"})
+// openStorage creates the storage backend selected at startup
+func openStorage(backend, dir string) (storage.Storage, error) {
+	switch backend {
+	case "memory":
+		return storage.NewMemoryStorage(), nil
+	case "file":
+		log.Printf("Using file storage in %s", dir)
+		return storage.NewFileStorage(dir)
+	case "sqlite":
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, "idea-sparker.db")
+		log.Printf("Using SQLite storage at %s", path)
+		return storage.NewSQLiteStorage(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
 }
 
-func addChatMessage(c *gin.Context) {
-	c.JSON(201, gin.H{"message": "chat message addition not implemented yet"})
+// getEnv returns the value of an environment variable or a fallback
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
 }
 
+// Placeholder handler for AI analysis - will be implemented later
 func analyzeContent(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "content analysis not implemented yet"})
 }
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Annotation is a passage highlighted in a collected resource, with the
+// reader's own note on it. A resource can have any number of annotations;
+// they are removed together with their resource.
+type Annotation struct {
+	ID         string `json:"id" bson:"_id,omitempty"`
+	ResourceID string `json:"resourceId" bson:"resourceId"`
+	// Quote is the highlighted text, exactly as it appears in the resource
+	Quote     string              `json:"quote" bson:"quote"`
+	Selector  *AnnotationSelector `json:"selector,omitempty" bson:"selector,omitempty"`
+	Note      string              `json:"note" bson:"note"`
+	Tags      []string            `json:"tags" bson:"tags"`
+	CreatedAt time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt" bson:"updatedAt"`
+}
+
+// AnnotationSelector locates a quote in the text of its resource, in the
+// manner of the W3C text position and text quote selectors: Start and End
+// are rune offsets into the archived text or the body of a note, and Prefix
+// and Suffix are the text just around the quote, so it can be found again
+// when the offsets no longer match
+type AnnotationSelector struct {
+	Start  int    `json:"start" bson:"start"`
+	End    int    `json:"end" bson:"end"`
+	Prefix string `json:"prefix,omitempty" bson:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty" bson:"suffix,omitempty"`
+}
+
+// NewAnnotation creates a new annotation on a resource with proper timestamps
+func NewAnnotation(resourceID, quote string, selector *AnnotationSelector, note string, tags []string) *Annotation {
+	now := time.Now()
+	return &Annotation{
+		ResourceID: resourceID,
+		Quote:      quote,
+		Selector:   selector,
+		Note:       note,
+		Tags:       tags,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// Update updates the annotation content and timestamps
+func (a *Annotation) Update(quote string, selector *AnnotationSelector, note string, tags []string) {
+	a.Quote = quote
+	a.Selector = selector
+	a.Note = note
+	a.Tags = tags
+	a.UpdatedAt = time.Now()
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ResourceArchive is a copy of a resource's page, kept so its content stays
+// available when the page changes or disappears. A resource has at most one
+// archive, replaced whenever the page is archived again.
+type ResourceArchive struct {
+	ResourceID string `json:"resourceId" bson:"_id,omitempty"`
+	// URL is where the page was found after redirects
+	URL   string `json:"url" bson:"url"`
+	Title string `json:"title" bson:"title"`
+	// Text is the readable text of the page's main article, without
+	// navigation, ads and other boilerplate. Paragraphs are separated by
+	// blank lines.
+	Text string `json:"text" bson:"text"`
+	// HTML is the page as it was downloaded
+	HTML       string    `json:"html,omitempty" bson:"html,omitempty"`
+	ArchivedAt time.Time `json:"archivedAt" bson:"archivedAt"`
+}
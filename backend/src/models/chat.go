@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // MessageType represents the type of chat message
@@ -13,12 +15,20 @@ const (
 	MessageTypeError     MessageType = "error"
 )
 
-// ChatMessage represents a single message in a chat session
+// ChatMessage represents a single message in a chat session. Replies also
+// record which model produced them, its token usage and latency, and the
+// resources it was given as context.
 type ChatMessage struct {
-	ID        string      `json:"id" bson:"_id,omitempty"`
-	Type      MessageType `json:"type" bson:"type"`
-	Content   string      `json:"content" bson:"content"`
-	CreatedAt time.Time   `json:"createdAt" bson:"createdAt"`
+	ID               string      `json:"id" bson:"_id,omitempty"`
+	ParentID         string      `json:"parentId,omitempty" bson:"parentId,omitempty"`
+	Type             MessageType `json:"type" bson:"type"`
+	Content          string      `json:"content" bson:"content"`
+	Model            string      `json:"model,omitempty" bson:"model,omitempty"`
+	PromptTokens     int         `json:"promptTokens,omitempty" bson:"promptTokens,omitempty"`
+	CompletionTokens int         `json:"completionTokens,omitempty" bson:"completionTokens,omitempty"`
+	LatencyMs        int64       `json:"latencyMs,omitempty" bson:"latencyMs,omitempty"`
+	ResourceIDs      []string    `json:"resourceIds,omitempty" bson:"resourceIds,omitempty"`
+	CreatedAt        time.Time   `json:"createdAt" bson:"createdAt"`
 }
 
 // ChatSession represents a conversation session between user and AI agent
@@ -43,14 +53,33 @@ func NewChatSession(draftID string) *ChatSession {
 	}
 }
 
-// AddMessage adds a new message to the chat session
-func (s *ChatSession) AddMessage(messageType MessageType, content string) {
-	s.Messages = append(s.Messages, ChatMessage{
+// AddMessage adds a new message to the chat session with a fresh ID, replying
+// to the previous message. The returned pointer can be used to fill in
+// metadata until the next message is added.
+func (s *ChatSession) AddMessage(messageType MessageType, content string) *ChatMessage {
+	message := ChatMessage{
+		ID:        uuid.New().String(),
 		Type:      messageType,
 		Content:   content,
 		CreatedAt: time.Now(),
-	})
+	}
+	if len(s.Messages) > 0 {
+		message.ParentID = s.Messages[len(s.Messages)-1].ID
+	}
+
+	s.Messages = append(s.Messages, message)
 	s.UpdatedAt = time.Now()
+	return &s.Messages[len(s.Messages)-1]
+}
+
+// Message returns the message with the given ID, or nil if there is none
+func (s *ChatSession) Message(id string) *ChatMessage {
+	for i := range s.Messages {
+		if s.Messages[i].ID == id {
+			return &s.Messages[i]
+		}
+	}
+	return nil
 }
 
 // IsActive checks if the chat session is still active
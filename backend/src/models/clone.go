@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// Clone returns a deep copy of the draft
+func (d *BlogDraft) Clone() *BlogDraft {
+	clone := *d
+	clone.Tags = cloneStrings(d.Tags)
+	clone.Resources = cloneStrings(d.Resources)
+	clone.DeletedAt = cloneTime(d.DeletedAt)
+	return &clone
+}
+
+// Clone returns a deep copy of the resource
+func (r *CollectedResource) Clone() *CollectedResource {
+	clone := *r
+	clone.Tags = cloneStrings(r.Tags)
+	if r.Source != nil {
+		source := *r.Source
+		clone.Source = &source
+	}
+	clone.Metadata.PublishedAt = cloneTime(r.Metadata.PublishedAt)
+	clone.Metadata.FetchedAt = cloneTime(r.Metadata.FetchedAt)
+	clone.Metadata.ArchivedAt = cloneTime(r.Metadata.ArchivedAt)
+	clone.Health.CheckedAt = cloneTime(r.Health.CheckedAt)
+	clone.DeletedAt = cloneTime(r.DeletedAt)
+	return &clone
+}
+
+// Clone returns a copy of the archive
+func (a *ResourceArchive) Clone() *ResourceArchive {
+	clone := *a
+	return &clone
+}
+
+// Clone returns a deep copy of the annotation
+func (a *Annotation) Clone() *Annotation {
+	clone := *a
+	if a.Selector != nil {
+		selector := *a.Selector
+		clone.Selector = &selector
+	}
+	clone.Tags = cloneStrings(a.Tags)
+	return &clone
+}
+
+// Clone returns a deep copy of the idea
+func (i *InterestIdea) Clone() *InterestIdea {
+	clone := *i
+	clone.Sources = cloneStrings(i.Sources)
+	clone.Tags = cloneStrings(i.Tags)
+	return &clone
+}
+
+// Clone returns a deep copy of the revision
+func (r *DraftRevision) Clone() *DraftRevision {
+	clone := *r
+	clone.Tags = cloneStrings(r.Tags)
+	return &clone
+}
+
+// Clone returns a deep copy of the session and its messages
+func (s *ChatSession) Clone() *ChatSession {
+	clone := *s
+	if s.Messages != nil {
+		clone.Messages = make([]ChatMessage, len(s.Messages))
+		for i, message := range s.Messages {
+			message.ResourceIDs = cloneStrings(message.ResourceIDs)
+			clone.Messages[i] = message
+		}
+	}
+	return &clone
+}
+
+// cloneStrings copies a slice, keeping nil and empty slices apart since they
+// encode differently in JSON
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	clone := make([]string, len(values))
+	copy(clone, values)
+	return clone
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
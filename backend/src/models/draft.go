@@ -4,15 +4,19 @@ import (
 	"time"
 )
 
-// BlogDraft represents a blog draft with metadata
+// BlogDraft represents a blog draft with metadata. Version starts at 1 and
+// is incremented by storage on every update. DeletedAt is set while the draft
+// is in the trash.
 type BlogDraft struct {
-	ID        string    `json:"id" bson:"_id,omitempty"`
-	Title     string    `json:"title" bson:"title"`
-	Content   string    `json:"content" bson:"content"`
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
-	Tags      []string  `json:"tags" bson:"tags"`
-	Resources []string  `json:"resources" bson:"resources"` // Resource IDs
+	ID        string     `json:"id" bson:"_id,omitempty"`
+	Title     string     `json:"title" bson:"title"`
+	Content   string     `json:"content" bson:"content"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	Tags      []string   `json:"tags" bson:"tags"`
+	Resources []string   `json:"resources" bson:"resources"` // Resource IDs
+	Version   int        `json:"version" bson:"version"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 }
 
 // NewBlogDraft creates a new blog draft with proper timestamps
@@ -25,6 +29,7 @@ func NewBlogDraft(title, content string, tags []string) *BlogDraft {
 		UpdatedAt: now,
 		Tags:      tags,
 		Resources: []string{},
+		Version:   1,
 	}
 }
 
@@ -35,3 +40,19 @@ func (d *BlogDraft) Update(title, content string, tags []string) {
 	d.Tags = tags
 	d.UpdatedAt = time.Now()
 }
+
+// Trash moves the draft to the trash
+func (d *BlogDraft) Trash() {
+	now := time.Now()
+	d.DeletedAt = &now
+}
+
+// Restore takes the draft out of the trash
+func (d *BlogDraft) Restore() {
+	d.DeletedAt = nil
+}
+
+// IsTrashed reports whether the draft is in the trash
+func (d *BlogDraft) IsTrashed() bool {
+	return d.DeletedAt != nil
+}
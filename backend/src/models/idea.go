@@ -12,23 +12,38 @@ type InterestIdea struct {
 	Content     string    `json:"content" bson:"content"`
 	Confidence  float64   `json:"confidence" bson:"confidence"`
 	Sources     []string  `json:"sources" bson:"sources"` // Resource IDs that inspired this idea
+	DraftID     string    `json:"draftId,omitempty" bson:"draftId,omitempty"`
 	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt"`
 	Tags        []string  `json:"tags" bson:"tags"`
 }
 
 // NewInterestIdea creates a new interest idea with proper timestamps
 func NewInterestIdea(title, description, content string, confidence float64, sources []string, tags []string) *InterestIdea {
+	now := time.Now()
 	return &InterestIdea{
 		Title:       title,
 		Description: description,
 		Content:     content,
 		Confidence:  confidence,
 		Sources:     sources,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
 		Tags:        tags,
 	}
 }
 
+// Update updates the interest idea content and timestamps
+func (i *InterestIdea) Update(title, description, content string, confidence float64, sources []string, tags []string) {
+	i.Title = title
+	i.Description = description
+	i.Content = content
+	i.Confidence = confidence
+	i.Sources = sources
+	i.Tags = tags
+	i.UpdatedAt = time.Now()
+}
+
 // IsValid checks if the interest idea has valid confidence score
 func (i *InterestIdea) IsValid() bool {
 	return i.Confidence >= 0.0 && i.Confidence <= 1.0
@@ -13,19 +13,110 @@ const (
 	ResourceTypeDocument ResourceType = "document"
 	ResourceTypeVideo    ResourceType = "video"
 	ResourceTypeOther    ResourceType = "other"
+	// ResourceTypeNote is a quote, passage or thought typed in by hand. Its
+	// text is in Body and its URL is optional.
+	ResourceTypeNote ResourceType = "note"
 )
 
-// CollectedResource represents a collected internet resource with metadata
+// SourceKind is what a note was taken from
+type SourceKind string
+
+const (
+	SourceBook    SourceKind = "book"
+	SourcePodcast SourceKind = "podcast"
+	SourcePerson  SourceKind = "person"
+	SourceOther   SourceKind = "other"
+)
+
+// ResourceSource attributes a note to where it came from, such as the
+// book and page a quote was copied from or the person who said it
+type ResourceSource struct {
+	Kind SourceKind `json:"kind" bson:"kind"`
+	// Name is the title of the book or podcast, or the person's name
+	Name string `json:"name" bson:"name"`
+	// Author is who wrote the book or hosts the podcast
+	Author string `json:"author,omitempty" bson:"author,omitempty"`
+	// Locator points into the source, such as a page, chapter or timestamp
+	Locator string `json:"locator,omitempty" bson:"locator,omitempty"`
+}
+
+// MetadataStatus tracks fetching the metadata of a resource's page
+type MetadataStatus string
+
+const (
+	MetadataPending MetadataStatus = "pending"
+	MetadataFetched MetadataStatus = "fetched"
+	MetadataFailed  MetadataStatus = "failed"
+)
+
+// ResourceMetadata is what was read from a resource's page. Status is empty
+// for resources that were never queued for fetching.
+type ResourceMetadata struct {
+	Status       MetadataStatus `json:"status,omitempty" bson:"status,omitempty"`
+	CanonicalURL string         `json:"canonicalUrl,omitempty" bson:"canonicalUrl,omitempty"`
+	SiteName     string         `json:"siteName,omitempty" bson:"siteName,omitempty"`
+	ImageURL     string         `json:"imageUrl,omitempty" bson:"imageUrl,omitempty"`
+	FaviconURL   string         `json:"faviconUrl,omitempty" bson:"faviconUrl,omitempty"`
+	PublishedAt  *time.Time     `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
+	FetchedAt    *time.Time     `json:"fetchedAt,omitempty" bson:"fetchedAt,omitempty"`
+	Error        string         `json:"error,omitempty" bson:"error,omitempty"`
+	// ArchivedAt is when the page was last archived, see ResourceArchive
+	ArchivedAt *time.Time `json:"archivedAt,omitempty" bson:"archivedAt,omitempty"`
+}
+
+// HealthStatus is the outcome of the last check of a resource's link
+type HealthStatus string
+
+const (
+	// HealthUnchecked is reported for links never checked, whose stored
+	// Status is empty
+	HealthUnchecked HealthStatus = "unchecked"
+	HealthOK        HealthStatus = "ok"
+	// HealthMoved links work but redirect to a different page
+	HealthMoved HealthStatus = "moved"
+	// HealthBroken links answer with an error or not at all
+	HealthBroken HealthStatus = "broken"
+)
+
+// ResourceHealth records the last check of a resource's link. Failures counts
+// the checks in a row that found it broken.
+type ResourceHealth struct {
+	Status     HealthStatus `json:"status,omitempty" bson:"status,omitempty"`
+	StatusCode int          `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	FinalURL   string       `json:"finalUrl,omitempty" bson:"finalUrl,omitempty"`
+	CheckedAt  *time.Time   `json:"checkedAt,omitempty" bson:"checkedAt,omitempty"`
+	Error      string       `json:"error,omitempty" bson:"error,omitempty"`
+	Failures   int          `json:"failures,omitempty" bson:"failures,omitempty"`
+}
+
+// State returns Status, or HealthUnchecked before the first check
+func (h ResourceHealth) State() HealthStatus {
+	if h.Status == "" {
+		return HealthUnchecked
+	}
+	return h.Status
+}
+
+// CollectedResource represents a collected internet resource with metadata.
+// Version starts at 1 and is incremented by storage on every update.
+// DeletedAt is set while the resource is in the trash. Notes keep their
+// Markdown text in Body and where it came from in Source.
 type CollectedResource struct {
-	ID          string       `json:"id" bson:"_id,omitempty"`
-	URL         string       `json:"url" bson:"url"`
-	Title       string       `json:"title" bson:"title"`
-	Description string       `json:"description" bson:"description"`
-	Type        ResourceType `json:"type" bson:"type"`
-	CreatedAt   time.Time    `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time    `json:"updatedAt" bson:"updatedAt"`
-	Category    string       `json:"category" bson:"category"`
-	Tags        []string     `json:"tags" bson:"tags"`
+	ID          string           `json:"id" bson:"_id,omitempty"`
+	URL         string           `json:"url" bson:"url"`
+	Title       string           `json:"title" bson:"title"`
+	Description string           `json:"description" bson:"description"`
+	Type        ResourceType     `json:"type" bson:"type"`
+	CreatedAt   time.Time        `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt" bson:"updatedAt"`
+	Category    string           `json:"category" bson:"category"`
+	Tags        []string         `json:"tags" bson:"tags"`
+	Metadata    ResourceMetadata `json:"metadata" bson:"metadata"`
+	Health      ResourceHealth   `json:"health" bson:"health"`
+	Version     int              `json:"version" bson:"version"`
+	DeletedAt   *time.Time       `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	Body        string           `json:"body,omitempty" bson:"body,omitempty"`
+	Source      *ResourceSource  `json:"source,omitempty" bson:"source,omitempty"`
 }
 
 // NewCollectedResource creates a new collected resource with proper timestamps
@@ -40,15 +131,42 @@ func NewCollectedResource(url, title, description string, resourceType ResourceT
 		UpdatedAt:   now,
 		Category:    category,
 		Tags:        tags,
+		Version:     1,
 	}
 }
 
+// NewNote creates a note resource with proper timestamps
+func NewNote(title, body string, source *ResourceSource, url, category string, tags []string) *CollectedResource {
+	note := NewCollectedResource(url, title, "", ResourceTypeNote, category, tags)
+	note.Body = body
+	note.Source = source
+	return note
+}
+
 // Update updates the collected resource metadata
-func (r *CollectedResource) Update(title, description string, resourceType ResourceType, category string, tags []string) {
+func (r *CollectedResource) Update(title, description, body string, source *ResourceSource, resourceType ResourceType, category string, tags []string) {
 	r.Title = title
 	r.Description = description
+	r.Body = body
+	r.Source = source
 	r.Type = resourceType
 	r.Category = category
 	r.Tags = tags
 	r.UpdatedAt = time.Now()
 }
+
+// Trash moves the resource to the trash
+func (r *CollectedResource) Trash() {
+	now := time.Now()
+	r.DeletedAt = &now
+}
+
+// Restore takes the resource out of the trash
+func (r *CollectedResource) Restore() {
+	r.DeletedAt = nil
+}
+
+// IsTrashed reports whether the resource is in the trash
+func (r *CollectedResource) IsTrashed() bool {
+	return r.DeletedAt != nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// RevisionSource records who or what produced a draft revision
+type RevisionSource string
+
+const (
+	RevisionSourceUser      RevisionSource = "user"
+	RevisionSourceAssistant RevisionSource = "assistant"
+	RevisionSourceRestore   RevisionSource = "restore"
+	// RevisionSourceOriginal marks the state of a draft that existed before
+	// revisions were recorded
+	RevisionSourceOriginal RevisionSource = "original"
+)
+
+// DraftRevision is an immutable copy of a draft's text at one point in time.
+// Revisions of a draft are numbered from 1 in the order they were recorded.
+type DraftRevision struct {
+	ID           string         `json:"id" bson:"_id,omitempty"`
+	DraftID      string         `json:"draftId" bson:"draftId"`
+	Number       int            `json:"number" bson:"number"`
+	Title        string         `json:"title" bson:"title"`
+	Content      string         `json:"content" bson:"content"`
+	Tags         []string       `json:"tags" bson:"tags"`
+	Source       RevisionSource `json:"source" bson:"source"`
+	Hash         string         `json:"hash" bson:"hash"`
+	RestoredFrom int            `json:"restoredFrom,omitempty" bson:"restoredFrom,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt" bson:"createdAt"`
+}
+
+// NewDraftRevision captures the current title, content and tags of draft
+func NewDraftRevision(draft *BlogDraft, number int, source RevisionSource) *DraftRevision {
+	tags := append([]string{}, draft.Tags...)
+	return &DraftRevision{
+		DraftID:   draft.ID,
+		Number:    number,
+		Title:     draft.Title,
+		Content:   draft.Content,
+		Tags:      tags,
+		Source:    source,
+		Hash:      ContentHash(draft.Title, draft.Content, tags),
+		CreatedAt: time.Now(),
+	}
+}
+
+// ContentHash returns a SHA-256 fingerprint of a draft's text, so identical
+// revisions can be recognised without comparing their content
+func ContentHash(title, content string, tags []string) string {
+	h := sha256.New()
+	h.Write([]byte(title))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(tags, "\x1f")))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,104 @@
+package nlp
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// cjkWordList is a small dictionary of common Chinese words, weighted
+// towards technology and writing, one word per line
+//
+//go:embed cjk_words.txt
+var cjkWordList string
+
+var cjkWords, cjkMaxWordLength = loadWords(cjkWordList)
+
+// cjkStopChars are characters too common to search for on their own:
+// particles, pronouns and the like
+var cjkStopChars = func() map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range "的了是在和与也就都而之其这那个我你他她它们着把被让给对从向于以及或但吗呢吧啊のはがをにでともへやかなだするたて이가을를은는에의도" {
+		set[r] = true
+	}
+	return set
+}()
+
+func loadWords(list string) (map[string]bool, int) {
+	words := make(map[string]bool)
+	longest := 0
+	for _, line := range strings.Split(list, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words[word] = true
+		longest = max(longest, utf8.RuneCountInString(word))
+	}
+	return words, longest
+}
+
+// isCJK reports whether r is written without spaces between words: Chinese
+// characters, Japanese kana and Korean hangul
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// cjkSegment is a range of runes in a run of CJK characters. Word segments
+// are dictionary words; the others are stretches no word covers.
+type cjkSegment struct {
+	start, end int
+	word       bool
+}
+
+// segmentCJK splits a run of CJK characters into dictionary words by forward
+// maximum matching: at each position it takes the longest word that starts
+// there. Consecutive characters that start no word form one segment.
+func segmentCJK(run []rune) []cjkSegment {
+	var segments []cjkSegment
+	for i := 0; i < len(run); {
+		length := 0
+		for l := min(cjkMaxWordLength, len(run)-i); l >= 2; l-- {
+			if cjkWords[string(run[i:i+l])] {
+				length = l
+				break
+			}
+		}
+
+		if length > 0 {
+			segments = append(segments, cjkSegment{start: i, end: i + length, word: true})
+			i += length
+			continue
+		}
+		if n := len(segments); n > 0 && !segments[n-1].word {
+			segments[n-1].end++
+		} else {
+			segments = append(segments, cjkSegment{start: i, end: i + 1})
+		}
+		i++
+	}
+	return segments
+}
+
+// cjkKeywords returns the keywords of a run of CJK characters. Chinese is
+// segmented with the dictionary and the rest falls back to overlapping
+// character pairs, since a single character rarely makes a keyword.
+func cjkKeywords(run []rune, language Language) []string {
+	segments := []cjkSegment{{start: 0, end: len(run)}}
+	if language == LanguageChinese {
+		segments = segmentCJK(run)
+	}
+
+	var terms []string
+	for _, segment := range segments {
+		if segment.word {
+			terms = append(terms, string(run[segment.start:segment.end]))
+			continue
+		}
+		for i := segment.start; i+2 <= segment.end; i++ {
+			terms = append(terms, string(run[i:i+2]))
+		}
+	}
+	return terms
+}
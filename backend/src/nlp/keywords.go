@@ -0,0 +1,172 @@
+package nlp
+
+import (
+	"math"
+	"sort"
+)
+
+// Document is a tokenized unit of text in a corpus
+type Document struct {
+	ID    string
+	Terms []string
+}
+
+// TermScore pairs a term with its weight
+type TermScore struct {
+	Term  string
+	Score float64
+}
+
+// TFIDF weights every term of every document by term frequency times smoothed
+// inverse document frequency across the corpus. The result is indexed like
+// docs.
+func TFIDF(docs []Document) []map[string]float64 {
+	df := make(map[string]int)
+	for _, doc := range docs {
+		seen := make(map[string]bool)
+		for _, term := range doc.Terms {
+			if !seen[term] {
+				seen[term] = true
+				df[term]++
+			}
+		}
+	}
+
+	n := float64(len(docs))
+	scores := make([]map[string]float64, len(docs))
+	for i, doc := range docs {
+		tf := make(map[string]float64)
+		for _, term := range doc.Terms {
+			tf[term]++
+		}
+
+		scores[i] = make(map[string]float64, len(tf))
+		for term, count := range tf {
+			idf := math.Log((1+n)/(1+float64(df[term]))) + 1
+			scores[i][term] = (count / float64(len(doc.Terms))) * idf
+		}
+	}
+	return scores
+}
+
+// TopTerms returns the n highest scoring terms, ties broken alphabetically so
+// results are deterministic
+func TopTerms(scores map[string]float64, n int) []TermScore {
+	ranked := make([]TermScore, 0, len(scores))
+	for term, score := range scores {
+		ranked = append(ranked, TermScore{Term: term, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Term < ranked[j].Term
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// TermPair is an unordered pair of terms that appear in the same documents
+type TermPair struct {
+	A, B  string
+	Count int
+}
+
+// CoOccurrences counts, for every pair of candidate terms, how many documents
+// contain both. Pairs are returned most frequent first.
+func CoOccurrences(docs []Document, candidates []string) []TermPair {
+	wanted := make(map[string]bool, len(candidates))
+	for _, term := range candidates {
+		wanted[term] = true
+	}
+
+	counts := make(map[[2]string]int)
+	for _, doc := range docs {
+		present := make(map[string]bool)
+		for _, term := range doc.Terms {
+			if wanted[term] {
+				present[term] = true
+			}
+		}
+
+		terms := make([]string, 0, len(present))
+		for term := range present {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+
+		for i := 0; i < len(terms); i++ {
+			for j := i + 1; j < len(terms); j++ {
+				counts[[2]string{terms[i], terms[j]}]++
+			}
+		}
+	}
+
+	pairs := make([]TermPair, 0, len(counts))
+	for key, count := range counts {
+		pairs = append(pairs, TermPair{A: key[0], B: key[1], Count: count})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Count != pairs[j].Count {
+			return pairs[i].Count > pairs[j].Count
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
+
+// Vocabulary stems keywords so that forms of a word such as "database" and
+// "databases" count as one term, and remembers how each stem was written so
+// it can be shown as a word again
+type Vocabulary struct {
+	forms map[string]map[string]int
+}
+
+// NewVocabulary creates an empty vocabulary
+func NewVocabulary() *Vocabulary {
+	return &Vocabulary{forms: make(map[string]map[string]int)}
+}
+
+// Stems tokenizes text written in language like Tokenize and returns the
+// stem of every keyword. Only English words are stemmed.
+func (v *Vocabulary) Stems(text string, language Language) []string {
+	terms := keywords(text, language)
+	for i, term := range terms {
+		stem := term
+		if language.stemmed() {
+			stem = Stem(term)
+		}
+		if v.forms[stem] == nil {
+			v.forms[stem] = make(map[string]int)
+		}
+		v.forms[stem][term]++
+		terms[i] = stem
+	}
+	return terms
+}
+
+// Words replaces every stem with the form it was written in most often,
+// preferring the shorter form on a tie
+func (v *Vocabulary) Words(stems []string) []string {
+	words := make([]string, len(stems))
+	for i, stem := range stems {
+		words[i] = v.Word(stem)
+	}
+	return words
+}
+
+// Word returns the form stem was written in most often
+func (v *Vocabulary) Word(stem string) string {
+	word, best := stem, 0
+	for form, count := range v.forms[stem] {
+		if count > best || (count == best && (len(form) < len(word) || (len(form) == len(word) && form < word))) {
+			word, best = form, count
+		}
+	}
+	return word
+}
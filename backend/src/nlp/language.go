@@ -0,0 +1,95 @@
+package nlp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Language is the language a text was detected to be written in. It decides
+// how the text is split into terms: English words are stemmed, and runs of
+// Chinese, Japanese and Korean characters are segmented into words or
+// character pairs.
+type Language string
+
+const (
+	LanguageEnglish  Language = "en"
+	LanguageChinese  Language = "zh"
+	LanguageJapanese Language = "ja"
+	LanguageKorean   Language = "ko"
+	// LanguageOther is a language written with spaces between words that is
+	// not English. Its words are matched as written, without stemming.
+	LanguageOther Language = "other"
+)
+
+const (
+	// cjkShare is the fraction of letters that must be CJK characters for a
+	// text to count as Chinese, Japanese or Korean. Each CJK character is
+	// roughly a word, so a low share still means most words are CJK.
+	cjkShare = 0.2
+
+	// minDetectWords is how many words a text needs before it can be
+	// detected as a language other than English. Shorter texts such as
+	// titles and queries say too little, and English is the common case.
+	minDetectWords = 12
+
+	// minStopWordShare is the fraction of English stop words that marks a
+	// longer text as English. English prose is usually a third stop words.
+	minStopWordShare = 0.05
+)
+
+// DetectLanguage guesses the language of text from the scripts it uses and,
+// for text written in Latin letters, how many English stop words it has
+func DetectLanguage(text string) Language {
+	var letters, han, kana, hangul int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.IsLetter(r):
+			letters++
+			continue
+		default:
+			continue
+		}
+		letters++
+	}
+
+	if cjk := han + kana + hangul; cjk > 0 && float64(cjk) >= cjkShare*float64(letters) {
+		switch {
+		case kana > 0:
+			return LanguageJapanese
+		case hangul > han:
+			return LanguageKorean
+		default:
+			return LanguageChinese
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) < minDetectWords {
+		return LanguageEnglish
+	}
+	stops := 0
+	for _, word := range words {
+		if IsStopWord(word) {
+			stops++
+		}
+	}
+	if float64(stops) >= minStopWordShare*float64(len(words)) {
+		return LanguageEnglish
+	}
+	return LanguageOther
+}
+
+// stemmed reports whether words in Latin letters are stemmed. English words
+// in Chinese, Japanese and Korean text are usually technical terms, so they
+// are stemmed too.
+func (l Language) stemmed() bool {
+	return l != LanguageOther
+}
@@ -0,0 +1,143 @@
+package nlp
+
+import (
+	"sort"
+	"strings"
+)
+
+// Token is a search term together with the byte range of the text it was
+// read from
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// SearchTokens splits text written in language into terms for full-text
+// search. Unlike Tokenize it keeps short words and numbers, which matter in
+// queries like "go 1.22", and records where each term came from so hits can
+// be highlighted. Stop words are dropped. Every other word is indexed both
+// as written and stemmed, whatever the language of the text: queries are
+// too short to tell their language reliably, and are stemmed or not
+// depending on how SearchTerms reads them, so either form must match.
+//
+// Runs of Chinese, Japanese and Korean characters are indexed as every
+// character, every pair of characters and every longer dictionary word they
+// contain, so that any way SearchTerms splits a query finds them. Tokens may
+// therefore overlap; they are ordered by where they start, longest first.
+func SearchTokens(text string, language Language) []Token {
+	tokens := searchTokens(text, language, false)
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].Start != tokens[j].Start {
+			return tokens[i].Start < tokens[j].Start
+		}
+		return tokens[i].End > tokens[j].End
+	})
+	return tokens
+}
+
+// SearchTerms returns the distinct terms of a search query in the order they
+// first appear. Chinese queries are segmented with the dictionary; the parts
+// it does not know, and other Chinese, Japanese and Korean text, are split
+// into character pairs.
+func SearchTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, token := range searchTokens(query, DetectLanguage(query), true) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+	return terms
+}
+
+// searchTokens tokenizes a document, or a query when query is set
+func searchTokens(text string, language Language, query bool) []Token {
+	var tokens []Token
+	scanRuns(text, false, func(start, end int, cjk bool) {
+		if cjk {
+			runes, offsets := runeOffsets(text, start, end)
+			add := func(i, j int) {
+				term := string(runes[i:j])
+				if (j-i == 1 && cjkStopChars[runes[i]]) || IsStopWord(term) {
+					return
+				}
+				tokens = append(tokens, Token{Term: term, Start: offsets[i], End: offsets[j]})
+			}
+			if query {
+				cjkQueryTokens(runes, language, add)
+			} else {
+				cjkDocumentTokens(runes, add)
+			}
+			return
+		}
+
+		term := strings.ToLower(text[start:end])
+		if IsStopWord(term) {
+			return
+		}
+		if query {
+			if language.stemmed() {
+				term = Stem(term)
+			}
+			tokens = append(tokens, Token{Term: term, Start: start, End: end})
+			return
+		}
+		tokens = append(tokens, Token{Term: term, Start: start, End: end})
+		if stem := Stem(term); stem != term {
+			tokens = append(tokens, Token{Term: stem, Start: start, End: end})
+		}
+	})
+	return tokens
+}
+
+// cjkDocumentTokens adds every character, pair of characters and longer
+// dictionary word of run
+func cjkDocumentTokens(run []rune, add func(i, j int)) {
+	for i := range run {
+		add(i, i+1)
+		if i+2 <= len(run) {
+			add(i, i+2)
+		}
+		for l := 3; l <= cjkMaxWordLength && i+l <= len(run); l++ {
+			if cjkWords[string(run[i:i+l])] {
+				add(i, i+l)
+			}
+		}
+	}
+}
+
+// cjkQueryTokens adds the dictionary words of a Chinese query and splits
+// everything else into pairs of characters, or single characters where a
+// character stands alone. Every token is one cjkDocumentTokens produces for
+// the same text, so a document containing the query always matches.
+func cjkQueryTokens(run []rune, language Language, add func(i, j int)) {
+	segments := []cjkSegment{{start: 0, end: len(run)}}
+	if language == LanguageChinese {
+		segments = segmentCJK(run)
+	}
+
+	for _, segment := range segments {
+		switch {
+		case segment.word, segment.end-segment.start == 1:
+			add(segment.start, segment.end)
+		default:
+			for i := segment.start; i+2 <= segment.end; i++ {
+				add(i, i+2)
+			}
+		}
+	}
+}
+
+// runeOffsets returns the runes of text[start:end] and the byte offset in
+// text of each of them, followed by end
+func runeOffsets(text string, start, end int) ([]rune, []int) {
+	var runes []rune
+	var offsets []int
+	for i, r := range text[start:end] {
+		runes = append(runes, r)
+		offsets = append(offsets, start+i)
+	}
+	return runes, append(offsets, end)
+}
@@ -0,0 +1,197 @@
+package nlp
+
+import "strings"
+
+// Stem reduces a lowercase English word to its stem with the Porter
+// algorithm, so "databases" and "database" both become "databas". Stems are
+// for matching, not display. Words with characters other than a to z are
+// returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := porter(word)
+	w = w.step1a().step1b().step1c().step2().step3().step4().step5()
+	return string(w)
+}
+
+// porter is a word being stemmed. The steps follow M. F. Porter, "An
+// algorithm for suffix stripping", 1980.
+type porter string
+
+// consonant reports whether the letter at i is a consonant. A y is a
+// consonant unless it follows one.
+func (w porter) consonant(i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !w.consonant(i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in w, the m of the paper
+func (w porter) measure() int {
+	m, i := 0, 0
+	for i < len(w) && w.consonant(i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !w.consonant(i) {
+			i++
+		}
+		if i == len(w) {
+			break
+		}
+		for i < len(w) && w.consonant(i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func (w porter) hasVowel() bool {
+	for i := range w {
+		if !w.consonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleConsonant reports whether w ends in a doubled consonant such as "tt"
+func (w porter) doubleConsonant() bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && w.consonant(n-1)
+}
+
+// cvc reports whether w ends consonant-vowel-consonant where the last
+// consonant is not w, x or y, as in "hop" but not "snow"
+func (w porter) cvc() bool {
+	n := len(w)
+	if n < 3 || !w.consonant(n-3) || w.consonant(n-2) || !w.consonant(n-1) {
+		return false
+	}
+	return !strings.ContainsRune("wxy", rune(w[n-1]))
+}
+
+// replace swaps the first suffix of rules that w ends with for its
+// replacement when the remaining stem's measure exceeds min. Only the first
+// matching suffix is considered, whether or not it is replaced.
+func (w porter) replace(min int, rules [][2]string) porter {
+	for _, rule := range rules {
+		if stem, found := strings.CutSuffix(string(w), rule[0]); found {
+			if porter(stem).measure() > min {
+				return porter(stem + rule[1])
+			}
+			return w
+		}
+	}
+	return w
+}
+
+// step1a removes plurals
+func (w porter) step1a() porter {
+	switch {
+	case strings.HasSuffix(string(w), "sses"), strings.HasSuffix(string(w), "ies"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(string(w), "ss"):
+		return w
+	case strings.HasSuffix(string(w), "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// step1b removes -ed and -ing, tidying up the stem left behind
+func (w porter) step1b() porter {
+	if stem, found := strings.CutSuffix(string(w), "eed"); found {
+		if porter(stem).measure() > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem porter
+	if s, found := strings.CutSuffix(string(w), "ed"); found && porter(s).hasVowel() {
+		stem = porter(s)
+	} else if s, found := strings.CutSuffix(string(w), "ing"); found && porter(s).hasVowel() {
+		stem = porter(s)
+	} else {
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(string(stem), "at"), strings.HasSuffix(string(stem), "bl"), strings.HasSuffix(string(stem), "iz"):
+		return stem + "e"
+	case stem.doubleConsonant() && !strings.ContainsRune("lsz", rune(stem[len(stem)-1])):
+		return stem[:len(stem)-1]
+	case stem.measure() == 1 && stem.cvc():
+		return stem + "e"
+	}
+	return stem
+}
+
+// step1c turns a final y into i after a vowel in the stem
+func (w porter) step1c() porter {
+	if stem, found := strings.CutSuffix(string(w), "y"); found && porter(stem).hasVowel() {
+		return porter(stem + "i")
+	}
+	return w
+}
+
+func (w porter) step2() porter {
+	return w.replace(0, [][2]string{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+		{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+		{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+		{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	})
+}
+
+func (w porter) step3() porter {
+	return w.replace(0, [][2]string{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	})
+}
+
+// step4 removes the remaining suffixes from stems long enough to keep their
+// meaning. -ion only goes after s or t.
+func (w porter) step4() porter {
+	if stem, found := strings.CutSuffix(string(w), "ion"); found {
+		if s := porter(stem); s.measure() > 1 && (strings.HasSuffix(stem, "s") || strings.HasSuffix(stem, "t")) {
+			return s
+		}
+		return w
+	}
+	return w.replace(1, [][2]string{
+		{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""},
+		{"able", ""}, {"ible", ""}, {"ant", ""}, {"ement", ""}, {"ment", ""},
+		{"ent", ""}, {"ou", ""}, {"ism", ""}, {"ate", ""}, {"iti", ""},
+		{"ous", ""}, {"ive", ""}, {"ize", ""},
+	})
+}
+
+// step5 removes a final e and undoubles a final ll
+func (w porter) step5() porter {
+	if stem, found := strings.CutSuffix(string(w), "e"); found {
+		s := porter(stem)
+		if m := s.measure(); m > 1 || (m == 1 && !s.cvc()) {
+			w = s
+		}
+	}
+	if w.measure() > 1 && w.doubleConsonant() && w[len(w)-1] == 'l' {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -0,0 +1,98 @@
+// Package nlp provides the text processing shared by keyword extraction and
+// search: language detection, tokenization, stemming, stop words and term
+// weighting.
+package nlp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minTermLength drops tokens too short to carry meaning on their own
+const minTermLength = 3
+
+// stopWords are common English words that never make useful keywords
+var stopWords = toSet(`a about above after again against all also am an and any are as at be
+because been before being below between both but by can could did do does doing down during
+each even few for from further get got had has have having he her here hers herself him himself
+his how however i if in into is it its itself just like made make many may me more most much must
+my myself new no nor not now of off on once one only or other our ours ourselves out over own
+really same she should since so some still such than that the their theirs them themselves then
+there these they this those through to too under until up upon us use used using very via was
+way we well were what when where which while who whom why will with within without would yet you
+your yours yourself yourselves http https www com org net html
+我们 你们 他们 这个 那个 这些 那些 可以 一个 没有 什么 因为 所以 但是 如果 就是 已经 还是
+自己 进行 以及 通过 如何 为什么 怎么 非常 一些 需要 使用 应该 可能`)
+
+// Tokenize splits text into lowercase keywords, dropping punctuation,
+// numbers, stop words and very short words. Chinese text is segmented into
+// dictionary words, and other Chinese, Japanese and Korean text into
+// character pairs.
+func Tokenize(text string) []string {
+	return keywords(text, DetectLanguage(text))
+}
+
+// keywords tokenizes text written in language
+func keywords(text string, language Language) []string {
+	text = strings.ToLower(text)
+	var terms []string
+	scanRuns(text, true, func(start, end int, cjk bool) {
+		if cjk {
+			for _, term := range cjkKeywords([]rune(text[start:end]), language) {
+				if !IsStopWord(term) {
+					terms = append(terms, term)
+				}
+			}
+			return
+		}
+
+		term := strings.Trim(text[start:end], "-")
+		if len([]rune(term)) < minTermLength || IsStopWord(term) || isNumber(term) {
+			return
+		}
+		terms = append(terms, term)
+	})
+	return terms
+}
+
+// scanRuns calls fn with the byte range of every run of letters and digits
+// in text, splitting runs where CJK characters meet others. With hyphens,
+// hyphenated words are kept as one run.
+func scanRuns(text string, hyphens bool, fn func(start, end int, cjk bool)) {
+	start, cjk := -1, false
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || (hyphens && r == '-')
+		if start >= 0 && (!inWord || isCJK(r) != cjk) {
+			fn(start, i, cjk)
+			start = -1
+		}
+		if inWord && start < 0 {
+			start, cjk = i, isCJK(r)
+		}
+	}
+	if start >= 0 {
+		fn(start, len(text), cjk)
+	}
+}
+
+// IsStopWord reports whether term is a common word without topical meaning
+func IsStopWord(term string) bool {
+	return stopWords[term]
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"strings"
+
+	"inspiration-blog-writer/backend/src/models"
+)
+
+// Field weights: a term in a title or tag says more about a document than
+// one in its body
+const (
+	titleWeight = 3
+	tagWeight   = 2
+	bodyWeight  = 1
+)
+
+// messageTitleLength is the number of runes of a chat message shown as the
+// title of its hits
+const messageTitleLength = 80
+
+// DraftDocument returns the searchable text of a draft
+func DraftDocument(draft *models.BlogDraft) *Document {
+	return &Document{
+		Kind:  KindDraft,
+		ID:    draft.ID,
+		Title: draft.Title,
+		Fields: []Field{
+			{Name: "title", Text: draft.Title, Weight: titleWeight},
+			{Name: "tags", Text: strings.Join(draft.Tags, " "), Weight: tagWeight},
+			{Name: "content", Text: draft.Content, Weight: bodyWeight},
+		},
+	}
+}
+
+// ResourceDocument returns the searchable text of a collected resource,
+// including the body and source of a note and the text of its archived page
+// when archive is not nil
+func ResourceDocument(resource *models.CollectedResource, archive *models.ResourceArchive) *Document {
+	doc := &Document{
+		Kind:  KindResource,
+		ID:    resource.ID,
+		Title: resource.Title,
+		Fields: []Field{
+			{Name: "title", Text: resource.Title, Weight: titleWeight},
+			{Name: "tags", Text: strings.Join(resource.Tags, " "), Weight: tagWeight},
+			{Name: "description", Text: resource.Description, Weight: bodyWeight},
+			{Name: "body", Text: resource.Body, Weight: bodyWeight},
+			{Name: "url", Text: resource.URL, Weight: bodyWeight},
+		},
+	}
+	if source := resource.Source; source != nil {
+		doc.Fields = append(doc.Fields, Field{Name: "source", Text: source.Name + " " + source.Author, Weight: bodyWeight})
+	}
+	if archive != nil {
+		doc.Fields = append(doc.Fields, Field{Name: "content", Text: archive.Text, Weight: bodyWeight})
+	}
+	return doc
+}
+
+// IdeaDocument returns the searchable text of an interest idea
+func IdeaDocument(idea *models.InterestIdea) *Document {
+	return &Document{
+		Kind:    KindIdea,
+		ID:      idea.ID,
+		Title:   idea.Title,
+		DraftID: idea.DraftID,
+		Fields: []Field{
+			{Name: "title", Text: idea.Title, Weight: titleWeight},
+			{Name: "tags", Text: strings.Join(idea.Tags, " "), Weight: tagWeight},
+			{Name: "description", Text: idea.Description, Weight: bodyWeight},
+			{Name: "content", Text: idea.Content, Weight: bodyWeight},
+		},
+	}
+}
+
+// MessageDocuments returns the searchable text of every message of a chat
+// session
+func MessageDocuments(session *models.ChatSession) []*Document {
+	docs := make([]*Document, 0, len(session.Messages))
+	for _, message := range session.Messages {
+		docs = append(docs, &Document{
+			Kind:      KindMessage,
+			ID:        message.ID,
+			Title:     messageTitle(message.Content),
+			DraftID:   session.DraftID,
+			SessionID: session.ID,
+			Fields: []Field{
+				{Name: "content", Text: message.Content, Weight: bodyWeight},
+			},
+		})
+	}
+	return docs
+}
+
+// messageTitle returns the start of the first line of content
+func messageTitle(content string) string {
+	title := strings.TrimSpace(content)
+	if newline := strings.IndexByte(title, '\n'); newline >= 0 {
+		title = title[:newline]
+	}
+	if runes := []rune(title); len(runes) > messageTitleLength {
+		title = string(runes[:messageTitleLength]) + "…"
+	}
+	return title
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"inspiration-blog-writer/backend/src/nlp"
+)
+
+// Snippet sizes in bytes: fields longer than snippetLength are cut to a
+// window starting up to snippetLead bytes before the first match
+const (
+	snippetLength = 200
+	snippetLead   = 60
+)
+
+// Fragment is a run of snippet text that either matches the query or not.
+// Joining the fragments gives the snippet; clients mark up the matches.
+type Fragment struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+// Highlight is a snippet of one field of a hit
+type Highlight struct {
+	Field     string     `json:"field"`
+	Fragments []Fragment `json:"fragments"`
+}
+
+// highlight returns a snippet of every field of an indexed document that
+// contains one of terms
+func highlight(entry *indexed, terms []string) []Highlight {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	highlights := make([]Highlight, 0)
+	for _, field := range entry.doc.Fields {
+		var matches []nlp.Token
+		for _, token := range nlp.SearchTokens(field.Text, entry.language) {
+			if wanted[token.Term] {
+				matches = append(matches, token)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		highlights = append(highlights, Highlight{Field: field.Name, Fragments: snippet(field.Text, matches)})
+	}
+	return highlights
+}
+
+// snippet cuts a window of text around the first match and splits it into
+// matching and non-matching fragments. Matches are ordered by where they
+// start; overlapping ones, as CJK tokens can be, are merged.
+func snippet(text string, matches []nlp.Token) []Fragment {
+	start, end := 0, len(text)
+	if len(text) > snippetLength {
+		start = wordStart(text, matches[0].Start-snippetLead)
+		end = wordEnd(text, start+snippetLength)
+	}
+
+	var fragments []Fragment
+	add := func(s string, match bool) {
+		if s != "" {
+			fragments = append(fragments, Fragment{Text: s, Match: match})
+		}
+	}
+
+	if start > 0 {
+		add("…", false)
+	}
+	pos := start
+	for i := 0; i < len(matches); i++ {
+		match := matches[i]
+		for i+1 < len(matches) && matches[i+1].Start <= match.End {
+			i++
+			match.End = max(match.End, matches[i].End)
+		}
+		if match.Start < pos || match.End > end {
+			continue
+		}
+		add(text[pos:match.Start], false)
+		add(text[match.Start:match.End], true)
+		pos = match.End
+	}
+	add(text[pos:end], false)
+	if end < len(text) {
+		add("…", false)
+	}
+	return fragments
+}
+
+// wordStart moves i back to the start of the word it falls in
+func wordStart(text string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if space := strings.LastIndexAny(text[:i], " \t\n"); space >= 0 && i-space < snippetLead {
+		return space + 1
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd moves i forward to the end of the word it falls in
+func wordEnd(text string, i int) int {
+	if i >= len(text) {
+		return len(text)
+	}
+	if space := strings.IndexAny(text[i:], " \t\n"); space >= 0 && space < snippetLead {
+		return i + space
+	}
+	for i < len(text) && !utf8.RuneStart(text[i]) {
+		i++
+	}
+	return i
+}
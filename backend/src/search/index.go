@@ -0,0 +1,266 @@
+// Package search keeps an in-memory inverted index of drafts, resources,
+// ideas and chat messages and ranks them against free-text queries with
+// BM25.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"inspiration-blog-writer/backend/src/nlp"
+)
+
+// BM25 parameters: k1 dampens repeated terms, b normalizes for length
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Kind is the type of entity a document was indexed from
+type Kind string
+
+const (
+	KindDraft    Kind = "draft"
+	KindResource Kind = "resource"
+	KindIdea     Kind = "idea"
+	KindMessage  Kind = "message"
+)
+
+// Field is a named piece of a document's text. Matches in fields with a
+// higher Weight count for more, e.g. a title over a body.
+type Field struct {
+	Name   string
+	Text   string
+	Weight float64
+}
+
+// Document is the searchable text of one entity
+type Document struct {
+	Kind  Kind
+	ID    string
+	Title string
+	// DraftID and SessionID locate chat messages
+	DraftID   string
+	SessionID string
+	Fields    []Field
+}
+
+type key struct {
+	kind Kind
+	id   string
+}
+
+// indexed is a stored document with its detected language and the weighted
+// frequency of its terms
+type indexed struct {
+	doc      *Document
+	language nlp.Language
+	terms    map[string]float64
+	length   float64
+}
+
+// Index is an inverted index from terms to the documents containing them. It
+// is safe for concurrent use.
+type Index struct {
+	mu          sync.RWMutex
+	docs        map[key]*indexed
+	postings    map[string]map[key]bool
+	sessions    map[string]map[key]bool
+	totalLength float64
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[key]*indexed),
+		postings: make(map[string]map[key]bool),
+		sessions: make(map[string]map[key]bool),
+	}
+}
+
+// Add indexes doc, replacing any document with the same kind and ID. The
+// language is detected from all of doc's fields together, so a short title
+// is read the same way as the body below it.
+func (x *Index) Add(doc *Document) {
+	texts := make([]string, len(doc.Fields))
+	for i, field := range doc.Fields {
+		texts[i] = field.Text
+	}
+	entry := &indexed{doc: doc, language: nlp.DetectLanguage(strings.Join(texts, "\n")), terms: make(map[string]float64)}
+	for _, field := range doc.Fields {
+		for _, token := range nlp.SearchTokens(field.Text, entry.language) {
+			entry.terms[token.Term] += field.Weight
+			entry.length++
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	k := key{doc.Kind, doc.ID}
+	x.remove(k)
+	x.docs[k] = entry
+	x.totalLength += entry.length
+	for term := range entry.terms {
+		if x.postings[term] == nil {
+			x.postings[term] = make(map[key]bool)
+		}
+		x.postings[term][k] = true
+	}
+	if doc.SessionID != "" {
+		if x.sessions[doc.SessionID] == nil {
+			x.sessions[doc.SessionID] = make(map[key]bool)
+		}
+		x.sessions[doc.SessionID][k] = true
+	}
+}
+
+// Remove drops a document from the index
+func (x *Index) Remove(kind Kind, id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(key{kind, id})
+}
+
+// RemoveSession drops every message of a chat session
+func (x *Index) RemoveSession(sessionID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for k := range x.sessions[sessionID] {
+		x.remove(k)
+	}
+}
+
+// Len returns the number of indexed documents
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return len(x.docs)
+}
+
+// remove drops a document; callers hold x.mu
+func (x *Index) remove(k key) {
+	entry, exists := x.docs[k]
+	if !exists {
+		return
+	}
+
+	delete(x.docs, k)
+	x.totalLength -= entry.length
+	for term := range entry.terms {
+		delete(x.postings[term], k)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+	if sessionID := entry.doc.SessionID; sessionID != "" {
+		delete(x.sessions[sessionID], k)
+		if len(x.sessions[sessionID]) == 0 {
+			delete(x.sessions, sessionID)
+		}
+	}
+}
+
+// Hit is a document matching a query
+type Hit struct {
+	Kind       Kind        `json:"kind"`
+	ID         string      `json:"id"`
+	Title      string      `json:"title"`
+	DraftID    string      `json:"draftId,omitempty"`
+	SessionID  string      `json:"sessionId,omitempty"`
+	Score      float64     `json:"score"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Search returns the documents containing every term of query, best match
+// first and at most limit of each kind (every one when limit is 0).
+// Documents rejected by keep, which may be nil, are left out. The totals
+// count every matching document of each kind, including those past limit.
+func (x *Index) Search(query string, limit int, keep func(Hit) bool) ([]Hit, map[Kind]int) {
+	terms := nlp.SearchTerms(query)
+	totals := make(map[Kind]int)
+	if len(terms) == 0 {
+		return []Hit{}, totals
+	}
+
+	candidates, entries := x.match(terms)
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+
+	hits := make([]Hit, 0)
+	for _, hit := range candidates {
+		if keep != nil && !keep(hit) {
+			continue
+		}
+		totals[hit.Kind]++
+		if limit > 0 && totals[hit.Kind] > limit {
+			continue
+		}
+		hit.Highlights = highlight(entries[key{hit.Kind, hit.ID}], terms)
+		hits = append(hits, hit)
+	}
+	return hits, totals
+}
+
+// match scores the documents containing every term. Entries are never
+// changed once added, so they can be read after the lock is released.
+func (x *Index) match(terms []string) ([]Hit, map[key]*indexed) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	// Walk the rarest term's postings and check the others against them
+	rarest := terms[0]
+	for _, term := range terms[1:] {
+		if len(x.postings[term]) < len(x.postings[rarest]) {
+			rarest = term
+		}
+	}
+
+	n := float64(len(x.docs))
+	averageLength := x.totalLength / math.Max(n, 1)
+	hits := make([]Hit, 0)
+	entries := make(map[key]*indexed)
+	for k := range x.postings[rarest] {
+		entry := x.docs[k]
+
+		score := 0.0
+		for _, term := range terms {
+			tf, found := entry.terms[term]
+			if !found {
+				score = -1
+				break
+			}
+			df := float64(len(x.postings[term]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*entry.length/math.Max(averageLength, 1)
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+		if score < 0 {
+			continue
+		}
+
+		hits = append(hits, Hit{
+			Kind:      k.kind,
+			ID:        k.id,
+			Title:     entry.doc.Title,
+			DraftID:   entry.doc.DraftID,
+			SessionID: entry.doc.SessionID,
+			Score:     score,
+		})
+		entries[k] = entry
+	}
+	return hits, entries
+}
@@ -0,0 +1,268 @@
+package search
+
+import (
+	"io"
+	"sync"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// IndexedStorage wraps a Storage and updates an Index with every write that
+// goes through it. Writes are serialized so the index always ends up with
+// the latest version of a document. Trashed drafts and resources are taken
+// out of the index and put back when restored.
+type IndexedStorage struct {
+	storage.Storage
+	index *Index
+	mu    sync.Mutex
+}
+
+// NewIndexedStorage indexes everything already in store and returns a
+// Storage that keeps index current
+func NewIndexedStorage(store storage.Storage, index *Index) (*IndexedStorage, error) {
+	s := &IndexedStorage{Storage: store, index: index}
+	if err := s.rebuild(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the wrapped storage when it holds resources
+func (s *IndexedStorage) Close() error {
+	if closer, ok := s.Storage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (s *IndexedStorage) rebuild() error {
+	drafts, err := s.Storage.ListDrafts()
+	if err != nil {
+		return err
+	}
+	for _, draft := range drafts {
+		s.indexDraft(draft)
+
+		sessions, err := s.Storage.ListSessions(draft.ID)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			s.indexSession(session)
+		}
+	}
+
+	resources, err := s.Storage.ListResources()
+	if err != nil {
+		return err
+	}
+	for _, resource := range resources {
+		s.indexResource(resource)
+	}
+
+	ideas, err := s.Storage.ListIdeas()
+	if err != nil {
+		return err
+	}
+	for _, idea := range ideas {
+		s.index.Add(IdeaDocument(idea))
+	}
+	return nil
+}
+
+// Draft operations
+func (s *IndexedStorage) CreateDraft(draft *models.BlogDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.CreateDraft(draft); err != nil {
+		return err
+	}
+	s.indexDraft(draft)
+	return nil
+}
+
+func (s *IndexedStorage) UpdateDraft(draft *models.BlogDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.UpdateDraft(draft); err != nil {
+		return err
+	}
+	s.indexDraft(draft)
+	return nil
+}
+
+func (s *IndexedStorage) DeleteDraft(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.Storage.ListSessions(id)
+	if err != nil {
+		return err
+	}
+	if err := s.Storage.DeleteDraft(id); err != nil {
+		return err
+	}
+	s.index.Remove(KindDraft, id)
+	for _, session := range sessions {
+		s.index.RemoveSession(session.ID)
+	}
+	return nil
+}
+
+// Resource operations
+func (s *IndexedStorage) CreateResource(resource *models.CollectedResource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.CreateResource(resource); err != nil {
+		return err
+	}
+	s.indexResource(resource)
+	return nil
+}
+
+func (s *IndexedStorage) UpdateResource(resource *models.CollectedResource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.UpdateResource(resource); err != nil {
+		return err
+	}
+	s.indexResource(resource)
+	return nil
+}
+
+func (s *IndexedStorage) SaveResourceMetadata(id string, metadata models.ResourceMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.SaveResourceMetadata(id, metadata); err != nil {
+		return err
+	}
+	// The archived text is indexed once the metadata points at it
+	resource, err := s.Storage.GetResource(id)
+	if err != nil {
+		return err
+	}
+	s.indexResource(resource)
+	return nil
+}
+
+func (s *IndexedStorage) DeleteResource(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.DeleteResource(id); err != nil {
+		return err
+	}
+	s.index.Remove(KindResource, id)
+	return nil
+}
+
+// Resource archive operations
+func (s *IndexedStorage) SaveArchive(archive *models.ResourceArchive) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.SaveArchive(archive); err != nil {
+		return err
+	}
+	resource, err := s.Storage.GetResource(archive.ResourceID)
+	if err != nil {
+		return err
+	}
+	s.indexResource(resource)
+	return nil
+}
+
+// Idea operations
+func (s *IndexedStorage) CreateIdea(idea *models.InterestIdea) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.CreateIdea(idea); err != nil {
+		return err
+	}
+	s.index.Add(IdeaDocument(idea))
+	return nil
+}
+
+func (s *IndexedStorage) UpdateIdea(idea *models.InterestIdea) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.UpdateIdea(idea); err != nil {
+		return err
+	}
+	s.index.Add(IdeaDocument(idea))
+	return nil
+}
+
+func (s *IndexedStorage) DeleteIdea(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.DeleteIdea(id); err != nil {
+		return err
+	}
+	s.index.Remove(KindIdea, id)
+	return nil
+}
+
+// Chat Session operations
+func (s *IndexedStorage) CreateSession(session *models.ChatSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.CreateSession(session); err != nil {
+		return err
+	}
+	s.indexSession(session)
+	return nil
+}
+
+func (s *IndexedStorage) UpdateSession(session *models.ChatSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Storage.UpdateSession(session); err != nil {
+		return err
+	}
+	s.indexSession(session)
+	return nil
+}
+
+func (s *IndexedStorage) indexDraft(draft *models.BlogDraft) {
+	if draft.IsTrashed() {
+		s.index.Remove(KindDraft, draft.ID)
+		return
+	}
+	s.index.Add(DraftDocument(draft))
+}
+
+// indexResource indexes a resource together with the text of its archived
+// page, if it has one
+func (s *IndexedStorage) indexResource(resource *models.CollectedResource) {
+	if resource.IsTrashed() {
+		s.index.Remove(KindResource, resource.ID)
+		return
+	}
+
+	var archive *models.ResourceArchive
+	if resource.Metadata.ArchivedAt != nil {
+		archive, _ = s.Storage.GetArchive(resource.ID)
+	}
+	s.index.Add(ResourceDocument(resource, archive))
+}
+
+// indexSession replaces the indexed messages of a session, so messages
+// removed from it are no longer found
+func (s *IndexedStorage) indexSession(session *models.ChatSession) {
+	s.index.RemoveSession(session.ID)
+	for _, doc := range MessageDocuments(session) {
+		s.index.Add(doc)
+	}
+}
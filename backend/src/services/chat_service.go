@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxChatHistory bounds how many earlier messages are sent to the model
+	// with each new question
+	maxChatHistory = 20
+
+	// chatErrorMessage is stored in the session when the model fails, so the
+	// conversation shows where a reply is missing
+	chatErrorMessage = "The assistant could not reply. Please try again."
+)
+
+const chatSystemPrompt = `You are a writing assistant helping an author with a blog post.
+Answer questions about the draft below, suggest improvements and new angles,
+and ground your answers in the author's collected resources where possible.
+Be concise and reply in Markdown.`
+
+// ChatService handles business logic for chat sessions about a draft
+type ChatService struct {
+	storage  storage.Storage
+	provider llm.Provider
+
+	// mu serializes reading a session with writing it back, so that a reply
+	// stored after a long stream cannot undo a message or a deactivation
+	// that happened meanwhile
+	mu sync.Mutex
+}
+
+// NewChatService creates a new chat service instance. Sending messages needs
+// provider; sessions can still be created and read without one.
+func NewChatService(storage storage.Storage, provider llm.Provider) *ChatService {
+	return &ChatService{
+		storage:  storage,
+		provider: provider,
+	}
+}
+
+// CreateSession starts a new chat session for a draft
+func (s *ChatService) CreateSession(draftID string) (*models.ChatSession, error) {
+	if draftID == "" {
+		return nil, newValidationError("draft ID is required")
+	}
+
+	if _, err := getDraft(s.storage, draftID); err != nil {
+		return nil, err
+	}
+
+	session := models.NewChatSession(draftID)
+	session.ID = uuid.New().String()
+
+	if err := s.storage.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetSession retrieves a chat session by ID
+func (s *ChatService) GetSession(id string) (*models.ChatSession, error) {
+	if id == "" {
+		return nil, newValidationError("session ID is required")
+	}
+
+	return s.storage.GetSession(id)
+}
+
+// GetMessage retrieves a single message of a chat session
+func (s *ChatService) GetMessage(sessionID, messageID string) (*models.ChatMessage, error) {
+	if sessionID == "" || messageID == "" {
+		return nil, newValidationError("session ID and message ID are required")
+	}
+
+	session, err := s.storage.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := session.Message(messageID)
+	if message == nil {
+		return nil, fmt.Errorf("message %w", ErrNotFound)
+	}
+
+	return message, nil
+}
+
+// ListSessions retrieves the chat sessions of a draft, oldest first. With
+// activeOnly, deactivated sessions are left out.
+func (s *ChatService) ListSessions(draftID string, activeOnly bool) ([]*models.ChatSession, error) {
+	if draftID == "" {
+		return nil, newValidationError("draft ID is required")
+	}
+
+	sessions, err := s.storage.ListSessions(draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*models.ChatSession, 0, len(sessions))
+	for _, session := range sessions {
+		if activeOnly && !session.IsActive() {
+			continue
+		}
+		filtered = append(filtered, session)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt.Before(filtered[j].CreatedAt)
+	})
+
+	return filtered, nil
+}
+
+// DeactivateSession closes a chat session so it no longer accepts messages
+func (s *ChatService) DeactivateSession(id string) (*models.ChatSession, error) {
+	if id == "" {
+		return nil, newValidationError("session ID is required")
+	}
+
+	return s.updateSession(id, func(session *models.ChatSession) error {
+		session.Deactivate()
+		return nil
+	})
+}
+
+// updateSession applies change to the stored session and writes it back,
+// unless change fails
+func (s *ChatService) updateSession(id string, change func(session *models.ChatSession) error) (*models.ChatSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.storage.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(session); err != nil {
+		return nil, err
+	}
+	if err := s.storage.UpdateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// SendMessage appends the user's message to a session and asks the model for
+// a reply, using the draft and its resources as context. The reply is stored
+// in the session and returned. When the model fails an error message is
+// stored in its place and the model error is returned. If the session is
+// deactivated before the reply arrives, the reply is dropped and ErrConflict
+// is returned.
+func (s *ChatService) SendMessage(ctx context.Context, sessionID, content string) (*models.ChatSession, error) {
+	return s.sendMessage(ctx, sessionID, content, nil)
+}
+
+// StreamMessage works like SendMessage but passes each reply delta to onDelta
+// as the model produces it. The complete reply is stored once the stream
+// ends. If ctx is cancelled or onDelta fails, the text received so far is
+// stored as the reply and the cancellation error is returned.
+func (s *ChatService) StreamMessage(ctx context.Context, sessionID, content string, onDelta llm.StreamHandler) (*models.ChatSession, error) {
+	return s.sendMessage(ctx, sessionID, content, onDelta)
+}
+
+func (s *ChatService) sendMessage(ctx context.Context, sessionID, content string, onDelta llm.StreamHandler) (*models.ChatSession, error) {
+	if sessionID == "" {
+		return nil, newValidationError("session ID is required")
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, newValidationError("message content is required")
+	}
+	if s.provider == nil {
+		return nil, ErrNotConfigured
+	}
+
+	var messages []llm.Message
+	var resourceIDs []string
+	var questionID string
+	_, err := s.updateSession(sessionID, func(session *models.ChatSession) error {
+		if !session.IsActive() {
+			return fmt.Errorf("%w: chat session is no longer active", ErrConflict)
+		}
+		var err error
+		messages, resourceIDs, err = s.buildChatMessages(session, content)
+		if err != nil {
+			return err
+		}
+		questionID = session.AddMessage(models.MessageTypeUser, content).ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := llm.CompletionRequest{Messages: messages, Temperature: llm.Temperature(0.7)}
+	started := time.Now()
+	var resp *llm.CompletionResponse
+	var modelErr, deliveryErr error
+	var partial strings.Builder
+	if onDelta == nil {
+		resp, modelErr = s.provider.Complete(ctx, req)
+	} else {
+		resp, modelErr = s.provider.Stream(ctx, req, func(delta string) error {
+			partial.WriteString(delta)
+			if err := onDelta(delta); err != nil {
+				deliveryErr = err
+				return err
+			}
+			return nil
+		})
+	}
+
+	// A client that went away is not a model failure: keep what it was shown
+	abandoned := modelErr != nil && (ctx.Err() != nil || deliveryErr != nil)
+	if modelErr != nil && !abandoned {
+		log.Printf("Chat reply for session %s failed: %v", sessionID, modelErr)
+	}
+	latency := time.Since(started).Milliseconds()
+
+	// The session is read again since other messages may have been added
+	// while the model was replying
+	session, err := s.updateSession(sessionID, func(session *models.ChatSession) error {
+		if !session.IsActive() {
+			return fmt.Errorf("%w: chat session was closed before the reply arrived", ErrConflict)
+		}
+
+		var reply *models.ChatMessage
+		switch {
+		case abandoned:
+			if partial.Len() == 0 {
+				return nil
+			}
+			reply = session.AddMessage(models.MessageTypeAssistant, partial.String())
+		case modelErr != nil:
+			reply = session.AddMessage(models.MessageTypeError, chatErrorMessage)
+		default:
+			reply = session.AddMessage(models.MessageTypeAssistant, resp.Content)
+		}
+
+		reply.ParentID = questionID
+		reply.Model = s.provider.Model()
+		reply.LatencyMs = latency
+		reply.ResourceIDs = resourceIDs
+		if resp != nil {
+			if resp.Model != "" {
+				reply.Model = resp.Model
+			}
+			reply.PromptTokens = resp.PromptTokens
+			reply.CompletionTokens = resp.CompletionTokens
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case abandoned:
+		return session, modelErr
+	case modelErr != nil:
+		return session, fmt.Errorf("%w: %w", ErrModelUnavailable, modelErr)
+	default:
+		return session, nil
+	}
+}
+
+// buildChatMessages renders the draft context, the recent conversation and
+// the new question as model messages, and returns the IDs of the resources
+// included. Error messages are left out since the model never said them.
+func (s *ChatService) buildChatMessages(session *models.ChatSession, question string) ([]llm.Message, []string, error) {
+	draft, err := getDraft(s.storage, session.DraftID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resources := make([]*models.CollectedResource, 0, len(draft.Resources))
+	resourceIDs := make([]string, 0, len(draft.Resources))
+	for _, resourceID := range draft.Resources {
+		resource, err := getResource(s.storage, resourceID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, resource)
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+
+	archives, err := loadArchives(s.storage, resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	annotations, err := loadAnnotations(s.storage, resources)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: chatSystemPrompt + "\n\n" + buildDraftContext(draft, resources, archives, annotations)},
+	}
+
+	history := session.Messages
+	if len(history) > maxChatHistory {
+		history = history[len(history)-maxChatHistory:]
+	}
+	for _, message := range history {
+		switch message.Type {
+		case models.MessageTypeUser:
+			messages = append(messages, llm.Message{Role: llm.RoleUser, Content: message.Content})
+		case models.MessageTypeAssistant:
+			messages = append(messages, llm.Message{Role: llm.RoleAssistant, Content: message.Content})
+		}
+	}
+
+	return append(messages, llm.Message{Role: llm.RoleUser, Content: question}), resourceIDs, nil
+}
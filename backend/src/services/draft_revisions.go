@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"inspiration-blog-writer/backend/src/diff"
+	"inspiration-blog-writer/backend/src/models"
+
+	"github.com/google/uuid"
+)
+
+// Diff modes accepted by DiffRevisions
+const (
+	DiffModeLine = "line"
+	DiffModeWord = "word"
+)
+
+// RevisionDiff is the difference between two revisions of a draft
+type RevisionDiff struct {
+	DraftID     string      `json:"draftId"`
+	From        int         `json:"from"`
+	To          int         `json:"to"`
+	Mode        string      `json:"mode"`
+	Title       diff.Result `json:"title"`
+	Content     diff.Result `json:"content"`
+	TagsChanged bool        `json:"tagsChanged"`
+}
+
+// ListRevisions retrieves the revision history of a draft, oldest first
+func (s *DraftService) ListRevisions(draftID string) ([]*models.DraftRevision, error) {
+	if draftID == "" {
+		return nil, newValidationError("draft ID is required")
+	}
+
+	if _, err := getDraft(s.storage, draftID); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ListRevisions(draftID)
+}
+
+// GetRevision retrieves a single revision of a draft by its number
+func (s *DraftService) GetRevision(draftID string, number int) (*models.DraftRevision, error) {
+	revisions, err := s.ListRevisions(draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, revision := range revisions {
+		if revision.Number == number {
+			return revision, nil
+		}
+	}
+
+	return nil, fmt.Errorf("revision %w", ErrNotFound)
+}
+
+// DiffRevisions compares two revisions of a draft line by line or word by
+// word
+func (s *DraftService) DiffRevisions(draftID string, from, to int, mode string) (*RevisionDiff, error) {
+	compare := diff.Lines
+	switch mode {
+	case "", DiffModeLine:
+		mode = DiffModeLine
+	case DiffModeWord:
+		compare = diff.Words
+	default:
+		return nil, newValidationError("mode must be line or word")
+	}
+
+	older, err := s.GetRevision(draftID, from)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.GetRevision(draftID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionDiff{
+		DraftID: draftID,
+		From:    from,
+		To:      to,
+		Mode:    mode,
+		// Titles are single lines, so only a word diff is useful
+		Title:       diff.Words(older.Title, newer.Title),
+		Content:     compare(older.Content, newer.Content),
+		TagsChanged: !sameTags(older.Tags, newer.Tags),
+	}, nil
+}
+
+// RestoreRevision makes an old revision the draft's current text. The
+// restore is recorded as a new revision, so no history is lost.
+func (s *DraftService) RestoreRevision(draftID string, number int) (*models.BlogDraft, *models.DraftRevision, error) {
+	defer s.lockDraft(draftID)()
+
+	revision, err := s.GetRevision(draftID, number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	draft, err := getDraft(s.storage, draftID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	draft.Update(revision.Title, revision.Content, append([]string{}, revision.Tags...))
+	if err := s.storage.UpdateDraft(draft); err != nil {
+		return nil, nil, err
+	}
+
+	restored, err := s.recordRevision(draft, models.RevisionSourceRestore, number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return draft, restored, nil
+}
+
+// recordRevision stores the draft's current text as its next revision. When
+// the text matches the latest revision nothing is stored and the latest is
+// returned, except for restores (restoredFrom > 0) which are always recorded.
+// Callers hold the draft's lock; a number taken by another process sharing
+// the storage is retried with the next one.
+func (s *DraftService) recordRevision(draft *models.BlogDraft, source models.RevisionSource, restoredFrom int) (*models.DraftRevision, error) {
+	for attempt := 0; ; attempt++ {
+		revisions, err := s.storage.ListRevisions(draft.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		revision := models.NewDraftRevision(draft, len(revisions)+1, source)
+		revision.RestoredFrom = restoredFrom
+		if len(revisions) > 0 {
+			latest := revisions[len(revisions)-1]
+			if restoredFrom == 0 && latest.Hash == revision.Hash {
+				return latest, nil
+			}
+			revision.Number = latest.Number + 1
+		}
+		revision.ID = uuid.New().String()
+
+		err = s.storage.CreateRevision(revision)
+		if errors.Is(err, ErrAlreadyExists) && attempt < maxConflictRetries {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return revision, nil
+	}
+}
+
+// ensureBaseline records the current text of a draft that predates revision
+// history, so its first update can still be undone
+func (s *DraftService) ensureBaseline(draft *models.BlogDraft) error {
+	revisions, err := s.storage.ListRevisions(draft.ID)
+	if err != nil || len(revisions) > 0 {
+		return err
+	}
+
+	_, err = s.recordRevision(draft, models.RevisionSourceOriginal, 0)
+	return err
+}
+
+// lockDraft locks the revision history of a draft and returns the unlock
+// function. The lock is picked by a hash of the ID from a fixed set, so any
+// ID can be locked without keeping state for it.
+func (s *DraftService) lockDraft(id string) func() {
+	hash := fnv.New32a()
+	hash.Write([]byte(id))
+	mu := &s.revisionLocks[hash.Sum32()%revisionLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,15 +2,31 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"sync"
+
 	"inspiration-blog-writer/backend/src/models"
 	"inspiration-blog-writer/backend/src/storage"
 
 	"github.com/google/uuid"
 )
 
+// maxConflictRetries bounds how often retryOnConflict repeats an update
+const maxConflictRetries = 10
+
+// revisionLockStripes is the number of locks drafts share for recording
+// revisions
+const revisionLockStripes = 64
+
 // DraftService handles business logic for blog drafts
 type DraftService struct {
 	storage storage.Storage
+
+	// revisionLocks are held from reading a draft until its new revision is
+	// stored, so that concurrent updates cannot take the same revision number
+	// or leave a latest revision that is not the draft's text. Drafts share
+	// them by a hash of their ID, see lockDraft.
+	revisionLocks [revisionLockStripes]sync.Mutex
 }
 
 // NewDraftService creates a new draft service instance
@@ -23,7 +39,7 @@ func NewDraftService(storage storage.Storage) *DraftService {
 // CreateDraft creates a new blog draft
 func (s *DraftService) CreateDraft(title, content string, tags []string) (*models.BlogDraft, error) {
 	if title == "" {
-		return nil, errors.New("title is required")
+		return nil, newValidationError("title is required")
 	}
 
 	draft := models.NewBlogDraft(title, content, tags)
@@ -34,75 +50,210 @@ func (s *DraftService) CreateDraft(title, content string, tags []string) (*model
 		return nil, err
 	}
 
+	if _, err := s.recordRevision(draft, models.RevisionSourceUser, 0); err != nil {
+		return nil, err
+	}
+
 	return draft, nil
 }
 
 // GetDraft retrieves a blog draft by ID
 func (s *DraftService) GetDraft(id string) (*models.BlogDraft, error) {
 	if id == "" {
-		return nil, errors.New("draft ID is required")
+		return nil, newValidationError("draft ID is required")
+	}
+
+	draft, err := getDraft(s.storage, id)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.storage.GetDraft(id)
+	return withoutDanglingResources(draft, func(resourceID string) (bool, error) {
+		_, err := getResource(s.storage, resourceID)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	})
 }
 
 // ListDrafts retrieves all blog drafts
 func (s *DraftService) ListDrafts() ([]*models.BlogDraft, error) {
-	return s.storage.ListDrafts()
+	drafts, err := listDrafts(s.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withoutDanglingResources(drafts)
 }
 
-// UpdateDraft updates an existing blog draft
-func (s *DraftService) UpdateDraft(id, title, content string, tags []string) (*models.BlogDraft, error) {
+// QueryDrafts retrieves one page of blog drafts and the cursor of the next
+// page, which is empty on the last one
+func (s *DraftService) QueryDrafts(query ListQuery) ([]*models.BlogDraft, string, error) {
+	query, err := pageQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	drafts, next, err := s.storage.QueryDrafts(query)
+	if err != nil {
+		return nil, "", queryError(err)
+	}
+
+	drafts, err = s.withoutDanglingResources(drafts)
+	if err != nil {
+		return nil, "", err
+	}
+	return drafts, next, nil
+}
+
+// withoutDanglingResources prunes the resource IDs that no longer resolve
+// from every draft in drafts
+func (s *DraftService) withoutDanglingResources(drafts []*models.BlogDraft) ([]*models.BlogDraft, error) {
+	resources, err := listResources(s.storage)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		known[resource.ID] = true
+	}
+
+	for i, draft := range drafts {
+		drafts[i], err = withoutDanglingResources(draft, func(resourceID string) (bool, error) {
+			return known[resourceID], nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return drafts, nil
+}
+
+// withoutDanglingResources returns draft, or a copy of it without the
+// resource IDs that no longer resolve: resources in the trash, and links
+// stored before resource deletion removed them
+func withoutDanglingResources(draft *models.BlogDraft, exists func(resourceID string) (bool, error)) (*models.BlogDraft, error) {
+	kept := make([]string, 0, len(draft.Resources))
+	for _, resourceID := range draft.Resources {
+		ok, err := exists(resourceID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, resourceID)
+		}
+	}
+	if len(kept) == len(draft.Resources) {
+		return draft, nil
+	}
+
+	pruned := *draft
+	pruned.Resources = kept
+	return &pruned, nil
+}
+
+// UpdateDraft updates an existing blog draft and records the result as a
+// new revision attributed to source (the user when empty). The update only
+// applies to the given version of the draft, or to any with AnyVersion.
+func (s *DraftService) UpdateDraft(id, title, content string, tags []string, source models.RevisionSource, version int) (*models.BlogDraft, error) {
 	if id == "" {
-		return nil, errors.New("draft ID is required")
+		return nil, newValidationError("draft ID is required")
 	}
 	if title == "" {
-		return nil, errors.New("title is required")
+		return nil, newValidationError("title is required")
 	}
+	switch source {
+	case "":
+		source = models.RevisionSourceUser
+	case models.RevisionSourceUser, models.RevisionSourceAssistant:
+	default:
+		return nil, newValidationError("source must be user or assistant")
+	}
+
+	defer s.lockDraft(id)()
 
 	// Get existing draft
-	draft, err := s.storage.GetDraft(id)
+	draft, err := getDraft(s.storage, id)
 	if err != nil {
 		return nil, err
 	}
+	if version != AnyVersion && version != draft.Version {
+		return nil, &VersionConflictError{
+			Err:     fmt.Errorf("draft version %d is not current: %w", version, ErrPreconditionFailed),
+			Current: draft,
+		}
+	}
+
+	if err := s.ensureBaseline(draft); err != nil {
+		return nil, err
+	}
 
 	// Update draft
 	draft.Update(title, content, tags)
 
 	err = s.storage.UpdateDraft(draft)
+	if errors.Is(err, ErrConflict) {
+		return nil, s.draftConflict(id, err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if _, err := s.recordRevision(draft, source, 0); err != nil {
+		return nil, err
+	}
+
 	return draft, nil
 }
 
-// DeleteDraft deletes a blog draft by ID
+// draftConflict attaches the current draft to a storage version conflict
+func (s *DraftService) draftConflict(id string, err error) error {
+	current, getErr := s.storage.GetDraft(id)
+	if getErr != nil {
+		return err
+	}
+	return &VersionConflictError{Err: err, Current: current}
+}
+
+// DeleteDraft moves a blog draft to the trash, see TrashService
 func (s *DraftService) DeleteDraft(id string) error {
 	if id == "" {
-		return errors.New("draft ID is required")
+		return newValidationError("draft ID is required")
+	}
+
+	draft, err := getDraft(s.storage, id)
+	if err != nil {
+		return err
 	}
 
-	return s.storage.DeleteDraft(id)
+	draft.Trash()
+	return s.storage.UpdateDraft(draft)
 }
 
 // AddResourceToDraft adds a resource to a draft
 func (s *DraftService) AddResourceToDraft(draftID, resourceID string) error {
 	if draftID == "" {
-		return errors.New("draft ID is required")
+		return newValidationError("draft ID is required")
 	}
 	if resourceID == "" {
-		return errors.New("resource ID is required")
+		return newValidationError("resource ID is required")
 	}
 
+	return retryOnConflict(func() error {
+		return s.addResource(draftID, resourceID)
+	})
+}
+
+func (s *DraftService) addResource(draftID, resourceID string) error {
 	// Get draft
-	draft, err := s.storage.GetDraft(draftID)
+	draft, err := getDraft(s.storage, draftID)
 	if err != nil {
 		return err
 	}
 
 	// Check if resource exists
-	_, err = s.storage.GetResource(resourceID)
+	_, err = getResource(s.storage, resourceID)
 	if err != nil {
 		return err
 	}
@@ -115,20 +266,41 @@ func (s *DraftService) AddResourceToDraft(draftID, resourceID string) error {
 	}
 
 	draft.Resources = append(draft.Resources, resourceID)
-	return s.storage.UpdateDraft(draft)
+	if err := s.storage.UpdateDraft(draft); err != nil {
+		return err
+	}
+
+	// A refusing ResourceService.DeleteResource checks for links after
+	// trashing, so checking for the trash after linking means the two
+	// cannot both go through
+	if _, err := getResource(s.storage, resourceID); err != nil {
+		if unlinkErr := retryOnConflict(func() error {
+			return s.removeResource(draftID, resourceID)
+		}); unlinkErr != nil {
+			return unlinkErr
+		}
+		return err
+	}
+	return nil
 }
 
 // RemoveResourceFromDraft removes a resource from a draft
 func (s *DraftService) RemoveResourceFromDraft(draftID, resourceID string) error {
 	if draftID == "" {
-		return errors.New("draft ID is required")
+		return newValidationError("draft ID is required")
 	}
 	if resourceID == "" {
-		return errors.New("resource ID is required")
+		return newValidationError("resource ID is required")
 	}
 
+	return retryOnConflict(func() error {
+		return s.removeResource(draftID, resourceID)
+	})
+}
+
+func (s *DraftService) removeResource(draftID, resourceID string) error {
 	// Get draft
-	draft, err := s.storage.GetDraft(draftID)
+	draft, err := getDraft(s.storage, draftID)
 	if err != nil {
 		return err
 	}
@@ -143,3 +315,16 @@ func (s *DraftService) RemoveResourceFromDraft(draftID, resourceID string) error
 
 	return nil // Resource not found in draft, but that's okay
 }
+
+// retryOnConflict runs update again while it loses the race against a
+// concurrent update of the same draft. It is meant for changes that do not
+// depend on what the client last saw, such as linking a resource.
+func retryOnConflict(update func() error) error {
+	var err error
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		if err = update(); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
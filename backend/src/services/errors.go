@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// Errors returned by the service layer. Storage sentinels are re-exported so
+// that callers only need to depend on this package.
+var (
+	ErrNotFound      = storage.ErrNotFound
+	ErrAlreadyExists = storage.ErrAlreadyExists
+	ErrConflict      = storage.ErrConflict
+	ErrValidation    = errors.New("validation failed")
+
+	// ErrPreconditionFailed is returned when an update names a version that
+	// is no longer the current one
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrDuplicate is returned when a resource is collected a second time.
+	// It matches ErrConflict with errors.Is.
+	ErrDuplicate = fmt.Errorf("resource already collected: %w", ErrConflict)
+
+	// ErrNotConfigured is returned when a feature needs a language model but
+	// none is configured
+	ErrNotConfigured = llm.ErrNotConfigured
+	// ErrModelUnavailable is returned when the configured language model
+	// cannot be reached or rejects the request
+	ErrModelUnavailable = errors.New("language model unavailable")
+	// ErrGenerationFailed is returned when the model keeps producing
+	// unusable output
+	ErrGenerationFailed = errors.New("idea generation failed")
+
+	// ErrFetchFailed is returned when a resource's page cannot be fetched
+	// for a request that needs it
+	ErrFetchFailed = errors.New("page could not be fetched")
+)
+
+// AnyVersion can be passed as the expected version of an update to skip the
+// version check
+const AnyVersion = -1
+
+// VersionConflictError reports an update based on an outdated version. It
+// matches its cause (ErrPreconditionFailed or ErrConflict) with errors.Is and
+// carries the current stored value so callers can show it or retry.
+type VersionConflictError struct {
+	Err     error
+	Current interface{}
+}
+
+func (e *VersionConflictError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// ResourceInUseError is returned when a resource cannot be deleted because
+// drafts or ideas still link to it. It matches ErrConflict with errors.Is.
+type ResourceInUseError struct {
+	References *ResourceReferences
+}
+
+func (e *ResourceInUseError) Error() string {
+	return fmt.Sprintf("resource is still referenced by %d draft(s) and %d idea(s): %v",
+		len(e.References.Drafts), len(e.References.Ideas), ErrConflict)
+}
+
+func (e *ResourceInUseError) Unwrap() error {
+	return ErrConflict
+}
+
+// DuplicateResourceError is returned when a new resource duplicates one
+// already collected, see DuplicatePolicy. It matches ErrDuplicate with
+// errors.Is and carries the existing resource.
+type DuplicateResourceError struct {
+	Existing *models.CollectedResource
+	// Reason is DuplicateURL or DuplicateTitle
+	Reason string
+}
+
+func (e *DuplicateResourceError) Error() string {
+	return fmt.Sprintf("%v as %s (same %s)", ErrDuplicate, e.Existing.ID, e.Reason)
+}
+
+func (e *DuplicateResourceError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// ValidationError describes input rejected by a service. It matches
+// ErrValidation with errors.Is while keeping a human-readable message.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Is reports whether target is ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// newValidationError creates a ValidationError with the given message
+func newValidationError(message string) error {
+	return &ValidationError{Message: message}
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/nlp"
+)
+
+const (
+	// tagWeight is how many times a tag counts relative to a word in a title
+	// or description, since tags are chosen deliberately by the user
+	tagWeight = 2
+
+	// highlightWeight is how many times a word of a highlight or of the
+	// note on it counts, since the user marked the passage themselves
+	highlightWeight = 2
+
+	// contextBoost multiplies the score of terms the user asked about
+	contextBoost = 1.5
+
+	// pairCandidates is how many top resource terms are checked for
+	// co-occurrence
+	pairCandidates = 12
+)
+
+// HeuristicIdeaGenerator produces ideas without a language model by
+// extracting keywords from the draft and its resources. It suggests topics
+// the resources cover but the draft does not, pairs of topics that keep
+// appearing together, and central draft topics that lack supporting sources.
+type HeuristicIdeaGenerator struct{}
+
+// NewHeuristicIdeaGenerator creates an offline idea generator
+func NewHeuristicIdeaGenerator() *HeuristicIdeaGenerator {
+	return &HeuristicIdeaGenerator{}
+}
+
+// topicSupport aggregates a term's weight across resources
+type topicSupport struct {
+	term      string
+	score     float64
+	resources []*models.CollectedResource
+}
+
+// Generate returns up to input.Count idea candidates, best first
+func (g *HeuristicIdeaGenerator) Generate(ctx context.Context, input IdeaGenerationInput) ([]*models.InterestIdea, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Match keywords by stem, then show each stem as the word the draft and
+	// resources use most
+	vocabulary := nlp.NewVocabulary()
+	docs := make([]nlp.Document, 0, len(input.Resources)+1)
+	for _, resource := range input.Resources {
+		docs = append(docs, nlp.Document{ID: resource.ID, Terms: resourceTerms(vocabulary, resource, input.Archives[resource.ID], input.Annotations[resource.ID])})
+	}
+	docs = append(docs, nlp.Document{ID: input.Draft.ID, Terms: draftTerms(vocabulary, input.Draft)})
+	contextTerms := vocabulary.Stems(input.Context, nlp.DetectLanguage(input.Context))
+	for i := range docs {
+		docs[i].Terms = vocabulary.Words(docs[i].Terms)
+	}
+	draftTerms := docs[len(docs)-1].Terms
+
+	scores := nlp.TFIDF(docs)
+	covered := make(map[string]bool, len(draftTerms))
+	for _, term := range draftTerms {
+		covered[term] = true
+	}
+	wanted := make(map[string]bool)
+	for _, term := range vocabulary.Words(contextTerms) {
+		wanted[term] = true
+	}
+
+	// Aggregate each term's weight and the resources that mention it
+	support := make(map[string]*topicSupport)
+	for i, resource := range input.Resources {
+		for term, score := range scores[i] {
+			if wanted[term] {
+				score *= contextBoost
+			}
+			topic, ok := support[term]
+			if !ok {
+				topic = &topicSupport{term: term}
+				support[term] = topic
+			}
+			topic.score += score
+			topic.resources = append(topic.resources, resource)
+		}
+	}
+
+	var candidates []*models.InterestIdea
+	candidates = append(candidates, uncoveredTopicIdeas(input, support, covered)...)
+	candidates = append(candidates, pairedTopicIdeas(input, docs[:len(input.Resources)], support)...)
+	candidates = append(candidates, unsupportedDraftIdeas(input, scores[len(scores)-1], support, wanted)...)
+
+	// Best first, without repeating a title
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	seen := make(map[string]bool)
+	ideas := make([]*models.InterestIdea, 0, input.Count)
+	for _, idea := range candidates {
+		if len(ideas) == input.Count {
+			break
+		}
+		if seen[idea.Title] {
+			continue
+		}
+		seen[idea.Title] = true
+		ideas = append(ideas, idea)
+	}
+
+	return ideas, nil
+}
+
+// uncoveredTopicIdeas suggests terms the resources weigh heavily but the
+// draft never mentions
+func uncoveredTopicIdeas(input IdeaGenerationInput, support map[string]*topicSupport, covered map[string]bool) []*models.InterestIdea {
+	topics := rankTopics(support, func(t *topicSupport) bool { return !covered[t.term] })
+	if len(topics) == 0 {
+		return nil
+	}
+
+	maxScore := topics[0].score
+	ideas := make([]*models.InterestIdea, 0, input.Count)
+	for _, topic := range topics {
+		if len(ideas) == input.Count {
+			break
+		}
+
+		share := float64(len(topic.resources)) / float64(len(input.Resources))
+		confidence := 0.35 + 0.4*share + 0.2*(topic.score/maxScore)
+
+		description := fmt.Sprintf("Your sources (%s) discuss %s, but \"%s\" does not mention it yet.",
+			resourceTitles(topic.resources), topic.term, input.Draft.Title)
+		content := fmt.Sprintf("- Summarize what your sources say about %s\n- Explain why %s matters for \"%s\"\n- Add a concrete example or counterpoint",
+			topic.term, topic.term, input.Draft.Title)
+
+		ideas = append(ideas, newHeuristicIdea(
+			fmt.Sprintf("What your draft misses about %s", topic.term),
+			description, content, confidence, topic.resources, []string{topic.term}))
+	}
+	return ideas
+}
+
+// pairedTopicIdeas suggests connecting two terms that appear together in
+// more than one resource
+func pairedTopicIdeas(input IdeaGenerationInput, resourceDocs []nlp.Document, support map[string]*topicSupport) []*models.InterestIdea {
+	if len(input.Resources) < 2 {
+		return nil
+	}
+
+	top := rankTopics(support, func(t *topicSupport) bool { return true })
+	if len(top) > pairCandidates {
+		top = top[:pairCandidates]
+	}
+	terms := make([]string, len(top))
+	for i, topic := range top {
+		terms[i] = topic.term
+	}
+
+	byID := make(map[string]*models.CollectedResource, len(input.Resources))
+	for _, resource := range input.Resources {
+		byID[resource.ID] = resource
+	}
+
+	var ideas []*models.InterestIdea
+	for _, pair := range nlp.CoOccurrences(resourceDocs, terms) {
+		if pair.Count < 2 || len(ideas) == input.Count {
+			break
+		}
+
+		var shared []*models.CollectedResource
+		for _, doc := range resourceDocs {
+			if slices.Contains(doc.Terms, pair.A) && slices.Contains(doc.Terms, pair.B) {
+				shared = append(shared, byID[doc.ID])
+			}
+		}
+
+		share := float64(pair.Count) / float64(len(input.Resources))
+		description := fmt.Sprintf("%s and %s keep appearing together (%s), which suggests a connection worth a section of its own.",
+			pair.A, pair.B, resourceTitles(shared))
+		content := fmt.Sprintf("- Define %s and %s for your readers\n- Show how they influence each other\n- Tie the connection back to \"%s\"",
+			pair.A, pair.B, input.Draft.Title)
+
+		ideas = append(ideas, newHeuristicIdea(
+			fmt.Sprintf("Connect %s and %s", pair.A, pair.B),
+			description, content, 0.3+0.5*share, shared, []string{pair.A, pair.B}))
+	}
+	return ideas
+}
+
+// unsupportedDraftIdeas suggests expanding the draft's central terms that no
+// resource backs up. This is the only source of ideas for a draft without
+// resources.
+func unsupportedDraftIdeas(input IdeaGenerationInput, draftScores map[string]float64, support map[string]*topicSupport, wanted map[string]bool) []*models.InterestIdea {
+	scores := make(map[string]float64, len(draftScores))
+	for term, score := range draftScores {
+		if _, backed := support[term]; backed {
+			continue
+		}
+		if wanted[term] {
+			score *= contextBoost
+		}
+		scores[term] = score
+	}
+
+	top := nlp.TopTerms(scores, input.Count)
+	if len(top) == 0 {
+		return nil
+	}
+
+	maxScore := top[0].Score
+	ideas := make([]*models.InterestIdea, 0, len(top))
+	for _, term := range top {
+		description := fmt.Sprintf("%s is central to \"%s\", but none of your collected resources cover it.",
+			term.Term, input.Draft.Title)
+		content := fmt.Sprintf("- Collect a source that supports your point about %s\n- Expand the argument with an example\n- Address the strongest objection",
+			term.Term)
+
+		ideas = append(ideas, newHeuristicIdea(
+			fmt.Sprintf("Go deeper on %s", term.Term),
+			description, content, 0.2+0.3*(term.Score/maxScore), nil, []string{term.Term}))
+	}
+	return ideas
+}
+
+// rankTopics returns the topics accepted by keep, highest score first
+func rankTopics(support map[string]*topicSupport, keep func(*topicSupport) bool) []*topicSupport {
+	topics := make([]*topicSupport, 0, len(support))
+	for _, topic := range support {
+		if keep(topic) {
+			topics = append(topics, topic)
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if topics[i].score != topics[j].score {
+			return topics[i].score > topics[j].score
+		}
+		return topics[i].term < topics[j].term
+	})
+	return topics
+}
+
+func newHeuristicIdea(title, description, content string, confidence float64, sources []*models.CollectedResource, tags []string) *models.InterestIdea {
+	ids := make([]string, 0, len(sources))
+	for _, resource := range sources {
+		ids = append(ids, resource.ID)
+	}
+	confidence = math.Round(math.Min(math.Max(confidence, 0), 1)*100) / 100
+	return models.NewInterestIdea(title, description, content, confidence, ids, tags)
+}
+
+// resourceTerms tokenizes a resource's title, description, tags,
+// highlights, the start of a note's body and of its archived page in the
+// language of the resource
+func resourceTerms(vocabulary *nlp.Vocabulary, resource *models.CollectedResource, archive *models.ResourceArchive, highlights []*models.Annotation) []string {
+	tags := strings.Join(resource.Tags, " ")
+	text := resource.Title + " " + resource.Description + " " + excerpt(resource.Body, maxPromptNoteChars)
+	if archive != nil {
+		text += " " + excerpt(archive.Text, maxPromptArchiveChars)
+	}
+	var marked []string
+	for _, highlight := range highlights {
+		marked = append(marked, highlight.Quote, highlight.Note, strings.Join(highlight.Tags, " "))
+	}
+	highlighted := strings.Join(marked, " ")
+
+	language := nlp.DetectLanguage(text + " " + tags + " " + highlighted)
+	terms := vocabulary.Stems(text, language)
+	tagTerms := vocabulary.Stems(tags, language)
+	for i := 0; i < tagWeight; i++ {
+		terms = append(terms, tagTerms...)
+	}
+	highlightTerms := vocabulary.Stems(highlighted, language)
+	for i := 0; i < highlightWeight; i++ {
+		terms = append(terms, highlightTerms...)
+	}
+	return terms
+}
+
+// draftTerms tokenizes a draft's title, content and tags
+func draftTerms(vocabulary *nlp.Vocabulary, draft *models.BlogDraft) []string {
+	text := draft.Title + " " + draft.Content + " " + strings.Join(draft.Tags, " ")
+	return vocabulary.Stems(text, nlp.DetectLanguage(text))
+}
+
+func resourceTitles(resources []*models.CollectedResource) string {
+	titles := make([]string, 0, 2)
+	for _, resource := range resources {
+		if len(titles) == 2 {
+			break
+		}
+		titles = append(titles, fmt.Sprintf("\"%s\"", resource.Title))
+	}
+	if len(resources) > 2 {
+		titles = append(titles, fmt.Sprintf("%d more", len(resources)-2))
+	}
+	return strings.Join(titles, ", ")
+}
+
+// fallbackIdeaGenerator uses primary and switches to fallback when the
+// primary's model cannot be reached, so idea generation keeps working offline
+type fallbackIdeaGenerator struct {
+	primary  IdeaGenerator
+	fallback IdeaGenerator
+}
+
+func (g *fallbackIdeaGenerator) Generate(ctx context.Context, input IdeaGenerationInput) ([]*models.InterestIdea, error) {
+	ideas, err := g.primary.Generate(ctx, input)
+	if err == nil || ctx.Err() != nil || !errors.Is(err, ErrModelUnavailable) {
+		return ideas, err
+	}
+
+	log.Printf("Language model unavailable, using offline idea generator: %v", err)
+	return g.fallback.Generate(ctx, input)
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+)
+
+const (
+	// defaultIdeaCount is how many ideas are requested when the caller does
+	// not ask for a specific number
+	defaultIdeaCount = 3
+	maxIdeaCount     = 10
+
+	// maxRepairAttempts bounds how often unusable model output is sent back
+	// to the model with a repair prompt
+	maxRepairAttempts = 2
+
+	// maxPromptDraftChars keeps long drafts from crowding out the resources
+	maxPromptDraftChars = 6000
+
+	// maxPromptNoteChars and maxPromptArchiveChars are how much of a note's
+	// body and of an archived page go into a model prompt or the heuristic
+	// generator's keywords
+	maxPromptNoteChars    = 2000
+	maxPromptArchiveChars = 1500
+
+	// maxPromptArchivesChars bounds the archived text of all resources in a
+	// prompt, so drafts with many resources stay within the model's context
+	maxPromptArchivesChars = 12000
+
+	// maxPromptHighlightsChars bounds the highlights of all resources in a
+	// prompt. It is spent before any archived text, since highlights are
+	// what the author marked as mattering to them.
+	maxPromptHighlightsChars = 8000
+)
+
+const ideaSystemPrompt = `You are a creative writing assistant that sparks blog post ideas.
+You receive a blog draft and the resources its author collected. Suggest new,
+specific angles the author has not covered yet, grounded in the resources.
+
+Reply with JSON only, no prose and no code fences, using exactly this schema:
+{"ideas":[{"title":string,"description":string,"content":string,"confidence":number,"sources":[string],"tags":[string]}]}
+
+- "confidence" is between 0 and 1 and reflects how well the idea is supported
+- "sources" lists the IDs of the resources that inspired the idea
+- "content" is a short outline in Markdown`
+
+// IdeaGenerationInput is everything a generator may use to produce ideas
+type IdeaGenerationInput struct {
+	Draft     *models.BlogDraft
+	Resources []*models.CollectedResource
+	// Archives holds the archived pages of the resources that have one,
+	// keyed by resource ID
+	Archives map[string]*models.ResourceArchive
+	// Annotations holds the highlights of the resources that have any,
+	// keyed by resource ID, oldest first
+	Annotations map[string][]*models.Annotation
+	Context     string
+	Count       int
+}
+
+// IdeaGenerator produces unsaved idea candidates for a draft
+type IdeaGenerator interface {
+	Generate(ctx context.Context, input IdeaGenerationInput) ([]*models.InterestIdea, error)
+}
+
+// LLMIdeaGenerator asks a language model for ideas and repairs unusable
+// output by sending the validation problem back to the model
+type LLMIdeaGenerator struct {
+	provider llm.Provider
+}
+
+// NewLLMIdeaGenerator creates a generator backed by provider
+func NewLLMIdeaGenerator(provider llm.Provider) *LLMIdeaGenerator {
+	return &LLMIdeaGenerator{provider: provider}
+}
+
+// generatedIdea mirrors the JSON schema requested from the model
+type generatedIdea struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Content     string   `json:"content"`
+	Confidence  float64  `json:"confidence"`
+	Sources     []string `json:"sources"`
+	Tags        []string `json:"tags"`
+}
+
+// Generate calls the model and returns validated idea candidates
+func (g *LLMIdeaGenerator) Generate(ctx context.Context, input IdeaGenerationInput) ([]*models.InterestIdea, error) {
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: ideaSystemPrompt},
+		{Role: llm.RoleUser, Content: buildIdeaPrompt(input)},
+	}
+
+	var lastProblem error
+	for attempt := 0; attempt <= maxRepairAttempts; attempt++ {
+		resp, err := g.provider.Complete(ctx, llm.CompletionRequest{Messages: messages, Temperature: llm.Temperature(0.7)})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrModelUnavailable, err)
+		}
+
+		ideas, problem := parseGeneratedIdeas(resp.Content, input.Resources)
+		if problem == nil {
+			return ideas, nil
+		}
+		lastProblem = problem
+
+		messages = append(messages,
+			llm.Message{Role: llm.RoleAssistant, Content: resp.Content},
+			llm.Message{Role: llm.RoleUser, Content: fmt.Sprintf(
+				"Your previous reply could not be used: %v. Reply again with JSON only, exactly matching the schema.", problem)},
+		)
+	}
+
+	return nil, fmt.Errorf("%w: model output unusable after %d repair attempts: %v", ErrGenerationFailed, maxRepairAttempts, lastProblem)
+}
+
+// buildIdeaPrompt renders the draft, resources and user context as the user
+// message sent to the model
+func buildIdeaPrompt(input IdeaGenerationInput) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Suggest %d ideas.\n\n", input.Count)
+	if input.Context != "" {
+		fmt.Fprintf(&b, "Author's request: %s\n\n", input.Context)
+	}
+	b.WriteString(buildDraftContext(input.Draft, input.Resources, input.Archives, input.Annotations))
+
+	return b.String()
+}
+
+// buildDraftContext renders a draft and its resources as Markdown for a
+// model prompt, with the highlights of each resource ahead of an excerpt of
+// its archived page. Long drafts and pages are truncated.
+func buildDraftContext(draft *models.BlogDraft, resources []*models.CollectedResource, archives map[string]*models.ResourceArchive, annotations map[string][]*models.Annotation) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Draft: %s\n", draft.Title)
+	if len(draft.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(draft.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s\n", excerpt(draft.Content, maxPromptDraftChars))
+
+	if len(resources) > 0 {
+		b.WriteString("\n## Resources\n")
+		if len(annotations) > 0 {
+			b.WriteString("Highlights are passages the author marked in a resource, with their own notes. " +
+				"They show best what the author cares about, so weigh them above the rest of a resource.\n\n")
+		}
+		archiveBudget, highlightBudget := maxPromptArchivesChars, maxPromptHighlightsChars
+		for _, resource := range resources {
+			fmt.Fprintf(&b, "- id: %s\n  title: %s\n", resource.ID, resource.Title)
+			if resource.URL != "" {
+				fmt.Fprintf(&b, "  url: %s\n", resource.URL)
+			}
+			if resource.Description != "" {
+				fmt.Fprintf(&b, "  description: %s\n", resource.Description)
+			}
+			if source := resource.Source; source != nil {
+				fmt.Fprintf(&b, "  source: %s\n", formatSource(source))
+			}
+			if len(resource.Tags) > 0 {
+				fmt.Fprintf(&b, "  tags: %s\n", strings.Join(resource.Tags, ", "))
+			}
+			if highlights := annotations[resource.ID]; len(highlights) > 0 && highlightBudget > 0 {
+				highlightBudget -= writeHighlights(&b, highlights, highlightBudget)
+			}
+			if resource.Body != "" {
+				writeIndented(&b, "note", excerpt(resource.Body, maxPromptNoteChars))
+			}
+			if archive := archives[resource.ID]; archive != nil && archiveBudget > 0 {
+				text := excerpt(archive.Text, min(maxPromptArchiveChars, archiveBudget))
+				if text != "" {
+					archiveBudget -= len(text)
+					writeIndented(&b, "archived text", text)
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// writeIndented writes a labelled block of text under a resource of the
+// prompt
+func writeIndented(b *strings.Builder, label, text string) {
+	fmt.Fprintf(b, "  %s:\n", label)
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			b.WriteString("    " + line)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeHighlights writes the highlights of a resource as a list under it,
+// stopping before the one that would take more than budget bytes, and
+// returns how many bytes were written
+func writeHighlights(b *strings.Builder, highlights []*models.Annotation, budget int) int {
+	var list strings.Builder
+	for _, highlight := range highlights {
+		var item strings.Builder
+		quote := excerpt(strings.Join(strings.Fields(highlight.Quote), " "), maxPromptNoteChars)
+		fmt.Fprintf(&item, "    - %q\n", quote)
+		if note := strings.Join(strings.Fields(highlight.Note), " "); note != "" {
+			fmt.Fprintf(&item, "      note: %s\n", note)
+		}
+		if len(highlight.Tags) > 0 {
+			fmt.Fprintf(&item, "      tags: %s\n", strings.Join(highlight.Tags, ", "))
+		}
+		if list.Len()+item.Len() > budget {
+			break
+		}
+		list.WriteString(item.String())
+	}
+	if list.Len() == 0 {
+		return 0
+	}
+
+	b.WriteString("  highlights:\n")
+	b.WriteString(list.String())
+	return list.Len()
+}
+
+// formatSource describes where a note came from, e.g. "Deep Work by Cal
+// Newport (book), p. 42"
+func formatSource(source *models.ResourceSource) string {
+	text := source.Name
+	if source.Author != "" {
+		text += " by " + source.Author
+	}
+	text += " (" + string(source.Kind) + ")"
+	if source.Locator != "" {
+		text += ", " + source.Locator
+	}
+	return text
+}
+
+// excerpt returns the start of a resource's text, at most limit bytes long.
+// It ends after the last paragraph that fits unless that would drop more
+// than half of it.
+func excerpt(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= limit {
+		return text
+	}
+
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	short := text[:cut]
+	if end := strings.LastIndex(short, "\n\n"); end > cut/2 {
+		short = short[:end]
+	}
+	return short + "\n[truncated]"
+}
+
+// parseGeneratedIdeas extracts and validates ideas from raw model output.
+// Sources that do not match a provided resource are dropped rather than
+// failing the whole response.
+func parseGeneratedIdeas(raw string, resources []*models.CollectedResource) ([]*models.InterestIdea, error) {
+	payload := extractJSONObject(raw)
+	if payload == "" {
+		return nil, errors.New("no JSON object found")
+	}
+
+	var parsed struct {
+		Ideas []generatedIdea `json:"ideas"`
+	}
+	if err := json.Unmarshal([]byte(payload), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	if len(parsed.Ideas) == 0 {
+		return nil, errors.New(`"ideas" is empty`)
+	}
+
+	known := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		known[resource.ID] = true
+	}
+
+	ideas := make([]*models.InterestIdea, 0, len(parsed.Ideas))
+	for i, candidate := range parsed.Ideas {
+		title := strings.TrimSpace(candidate.Title)
+		if title == "" {
+			return nil, fmt.Errorf("idea %d has no title", i+1)
+		}
+
+		sources := make([]string, 0, len(candidate.Sources))
+		for _, id := range candidate.Sources {
+			if known[id] {
+				sources = append(sources, id)
+			}
+		}
+
+		idea := models.NewInterestIdea(title, strings.TrimSpace(candidate.Description), candidate.Content,
+			candidate.Confidence, sources, candidate.Tags)
+		if !idea.IsValid() {
+			return nil, fmt.Errorf("idea %d has confidence %v outside 0..1", i+1, candidate.Confidence)
+		}
+		ideas = append(ideas, idea)
+	}
+
+	return ideas, nil
+}
+
+// extractJSONObject returns the outermost {...} span in s, which tolerates
+// code fences and chatter around the JSON
+func extractJSONObject(s string) string {
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return s[start : end+1]
+}
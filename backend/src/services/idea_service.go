@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+
+	"github.com/google/uuid"
+)
+
+// IdeaService handles business logic for interest ideas
+type IdeaService struct {
+	storage   storage.Storage
+	generator IdeaGenerator
+}
+
+// NewIdeaService creates a new idea service instance. Ideas are generated by
+// provider, falling back to the offline heuristic generator when provider is
+// nil or unreachable.
+func NewIdeaService(storage storage.Storage, provider llm.Provider) *IdeaService {
+	service := &IdeaService{
+		storage:   storage,
+		generator: NewHeuristicIdeaGenerator(),
+	}
+	if provider != nil {
+		service.generator = &fallbackIdeaGenerator{
+			primary:  NewLLMIdeaGenerator(provider),
+			fallback: service.generator,
+		}
+	}
+	return service
+}
+
+// CreateIdea creates a new interest idea, optionally linked to a draft
+func (s *IdeaService) CreateIdea(draftID, title, description, content string, confidence float64, sources []string, tags []string) (*models.InterestIdea, error) {
+	if title == "" {
+		return nil, newValidationError("title is required")
+	}
+
+	idea := models.NewInterestIdea(title, description, content, confidence, sources, tags)
+	idea.ID = uuid.New().String()
+	idea.DraftID = draftID
+
+	if err := s.validate(idea, nil); err != nil {
+		return nil, err
+	}
+
+	err := s.storage.CreateIdea(idea)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recheckSources(idea, nil); err != nil {
+		if deleteErr := s.storage.DeleteIdea(idea.ID); deleteErr != nil {
+			return nil, deleteErr
+		}
+		return nil, err
+	}
+
+	return idea, nil
+}
+
+// GenerateIdeas sparks new ideas for a draft from its content and the given
+// resources (or every resource attached to the draft when none are given),
+// then persists them
+func (s *IdeaService) GenerateIdeas(ctx context.Context, draftID string, resourceIDs []string, userContext string, count int) ([]*models.InterestIdea, error) {
+	if draftID == "" {
+		return nil, newValidationError("draft ID is required")
+	}
+	if count < 0 || count > maxIdeaCount {
+		return nil, newValidationError("count must be between 1 and 10")
+	}
+	if count == 0 {
+		count = defaultIdeaCount
+	}
+	draft, err := getDraft(s.storage, draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromDraft := len(resourceIDs) == 0
+	if fromDraft {
+		resourceIDs = draft.Resources
+	}
+	resources := make([]*models.CollectedResource, 0, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		resource, err := getResource(s.storage, resourceID)
+		if fromDraft && errors.Is(err, ErrNotFound) {
+			// Trashed resources stay linked to the draft but are not used
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	archives, err := loadArchives(s.storage, resources)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := loadAnnotations(s.storage, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	ideas, err := s.generator.Generate(ctx, IdeaGenerationInput{
+		Draft:       draft,
+		Resources:   resources,
+		Archives:    archives,
+		Annotations: annotations,
+		Context:     userContext,
+		Count:       count,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ideas) > count {
+		ideas = ideas[:count]
+	}
+
+	// The ideas are stored all or none: those stored before a failure are
+	// deleted again
+	for i, idea := range ideas {
+		idea.ID = uuid.New().String()
+		idea.DraftID = draft.ID
+		if err := s.storage.CreateIdea(idea); err != nil {
+			for _, stored := range ideas[:i] {
+				if deleteErr := s.storage.DeleteIdea(stored.ID); deleteErr != nil {
+					return nil, fmt.Errorf("%w (remove stored ideas: %v)", err, deleteErr)
+				}
+			}
+			return nil, err
+		}
+	}
+
+	return ideas, nil
+}
+
+// GetIdea retrieves an interest idea by ID
+func (s *IdeaService) GetIdea(id string) (*models.InterestIdea, error) {
+	if id == "" {
+		return nil, newValidationError("idea ID is required")
+	}
+
+	idea, err := s.storage.GetIdea(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ideas, err := s.withoutDanglingSources([]*models.InterestIdea{idea})
+	if err != nil {
+		return nil, err
+	}
+	return ideas[0], nil
+}
+
+// QueryIdeas retrieves one page of interest ideas and the cursor of the next
+// page, which is empty on the last one
+func (s *IdeaService) QueryIdeas(query ListQuery) ([]*models.InterestIdea, string, error) {
+	query, err := pageQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ideas, next, err := s.storage.QueryIdeas(query)
+	if err != nil {
+		return nil, "", queryError(err)
+	}
+
+	ideas, err = s.withoutDanglingSources(ideas)
+	if err != nil {
+		return nil, "", err
+	}
+	return ideas, next, nil
+}
+
+// UpdateIdea updates an existing interest idea
+func (s *IdeaService) UpdateIdea(id, title, description, content string, confidence float64, sources []string, tags []string) (*models.InterestIdea, error) {
+	if id == "" {
+		return nil, newValidationError("idea ID is required")
+	}
+	if title == "" {
+		return nil, newValidationError("title is required")
+	}
+
+	// Get existing idea
+	idea, err := s.storage.GetIdea(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update idea
+	previous := *idea
+	cited := idea.Sources
+	idea.Update(title, description, content, confidence, sources, tags)
+
+	if err := s.validate(idea, cited); err != nil {
+		return nil, err
+	}
+
+	err = s.storage.UpdateIdea(idea)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recheckSources(idea, cited); err != nil {
+		if restoreErr := s.storage.UpdateIdea(&previous); restoreErr != nil {
+			return nil, restoreErr
+		}
+		return nil, err
+	}
+
+	return idea, nil
+}
+
+// DeleteIdea deletes an interest idea by ID
+func (s *IdeaService) DeleteIdea(id string) error {
+	if id == "" {
+		return newValidationError("idea ID is required")
+	}
+
+	return s.storage.DeleteIdea(id)
+}
+
+// validate checks the confidence score and that every referenced draft and
+// resource exists. Sources in cited, which the stored idea already had, are
+// dropped instead when their resource has been trashed or deleted since, so
+// an idea read with its dangling sources pruned can be written back as is.
+func (s *IdeaService) validate(idea *models.InterestIdea, cited []string) error {
+	if !idea.IsValid() {
+		return newValidationError("confidence must be between 0 and 1")
+	}
+
+	if idea.DraftID != "" {
+		if _, err := getDraft(s.storage, idea.DraftID); err != nil {
+			return err
+		}
+	}
+
+	kept := make([]string, 0, len(idea.Sources))
+	for _, resourceID := range idea.Sources {
+		_, err := getResource(s.storage, resourceID)
+		if errors.Is(err, ErrNotFound) && slices.Contains(cited, resourceID) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		kept = append(kept, resourceID)
+	}
+	if len(kept) != len(idea.Sources) {
+		idea.Sources = kept
+	}
+
+	return nil
+}
+
+// recheckSources checks again, after the idea is stored, that the resources
+// it newly cites are not in the trash. A refusing
+// ResourceService.DeleteResource checks for links after trashing, so the
+// citation and the delete cannot both go through.
+func (s *IdeaService) recheckSources(idea *models.InterestIdea, cited []string) error {
+	for _, resourceID := range idea.Sources {
+		if slices.Contains(cited, resourceID) {
+			continue
+		}
+		if _, err := getResource(s.storage, resourceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutDanglingSources prunes the sources of every idea in ideas whose
+// resource is in the trash or gone, as DraftService does for the resources
+// of drafts
+func (s *IdeaService) withoutDanglingSources(ideas []*models.InterestIdea) ([]*models.InterestIdea, error) {
+	resources, err := listResources(s.storage)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		known[resource.ID] = true
+	}
+
+	for i, idea := range ideas {
+		kept := make([]string, 0, len(idea.Sources))
+		for _, resourceID := range idea.Sources {
+			if known[resourceID] {
+				kept = append(kept, resourceID)
+			}
+		}
+		if len(kept) != len(idea.Sources) {
+			pruned := *idea
+			pruned.Sources = kept
+			ideas[i] = &pruned
+		}
+	}
+	return ideas, nil
+}
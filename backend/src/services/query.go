@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// Page sizes of list queries
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ListQuery selects one page of drafts, resources or ideas, see
+// storage.ListQuery
+type ListQuery = storage.ListQuery
+
+// Fields a ListQuery can sort by
+const (
+	SortByCreatedAt = storage.SortByCreatedAt
+	SortByUpdatedAt = storage.SortByUpdatedAt
+	SortByTitle     = storage.SortByTitle
+)
+
+// pageQuery checks a query coming from a client, applies the default page
+// size and checks its filters
+func pageQuery(query ListQuery) (ListQuery, error) {
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return query, newValidationError("limit must be between 1 and 200")
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	switch query.SortBy {
+	case "", SortByCreatedAt, SortByUpdatedAt, SortByTitle:
+	default:
+		return query, newValidationError("sort must be createdAt, updatedAt or title")
+	}
+
+	return filterQuery(query)
+}
+
+// filterQuery checks the filters of a query coming from a client and keeps
+// trashed items out of the results
+func filterQuery(query ListQuery) (ListQuery, error) {
+	if !query.CreatedAfter.IsZero() && !query.CreatedBefore.IsZero() && !query.CreatedAfter.Before(query.CreatedBefore) {
+		return query, newValidationError("createdAfter must be before createdBefore")
+	}
+
+	switch query.Health {
+	case "", models.HealthUnchecked, models.HealthOK, models.HealthMoved, models.HealthBroken:
+	default:
+		return query, newValidationError("health must be unchecked, ok, moved or broken")
+	}
+
+	query.Domain = normalizeDomain(query.Domain)
+	query.Trash = storage.ExcludeTrashed
+	return query, nil
+}
+
+// normalizeDomain accepts a bare host or a whole URL and returns its
+// lower-case host without a leading "www."
+func normalizeDomain(domain string) string {
+	domain = strings.TrimSpace(domain)
+	if strings.Contains(domain, "://") {
+		if u, err := url.Parse(domain); err == nil {
+			domain = u.Hostname()
+		}
+	}
+	domain = strings.TrimSuffix(strings.ToLower(domain), "/")
+	return strings.TrimPrefix(domain, "www.")
+}
+
+// queryError reports a cursor the storage rejected as invalid input
+func queryError(err error) error {
+	if errors.Is(err, storage.ErrInvalidQuery) {
+		return newValidationError(err.Error())
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateAnnotation highlights a passage of a resource, with an optional note
+// and tags on it
+func (s *ResourceService) CreateAnnotation(resourceID, quote string, selector *models.AnnotationSelector, note string, tags []string) (*models.Annotation, error) {
+	if resourceID == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+	if _, err := getResource(s.storage, resourceID); err != nil {
+		return nil, err
+	}
+
+	annotation := models.NewAnnotation(resourceID, quote, selector, note, tags)
+	if err := validateAnnotation(annotation); err != nil {
+		return nil, err
+	}
+
+	annotation.ID = uuid.New().String()
+	if err := s.storage.CreateAnnotation(annotation); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+// ListAnnotations retrieves the annotations of a resource, oldest first
+func (s *ResourceService) ListAnnotations(resourceID string) ([]*models.Annotation, error) {
+	if resourceID == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+	if _, err := getResource(s.storage, resourceID); err != nil {
+		return nil, err
+	}
+	return s.storage.ListAnnotations(resourceID)
+}
+
+// GetAnnotation retrieves an annotation of a resource by ID
+func (s *ResourceService) GetAnnotation(resourceID, id string) (*models.Annotation, error) {
+	if resourceID == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+	if id == "" {
+		return nil, newValidationError("annotation ID is required")
+	}
+	if _, err := getResource(s.storage, resourceID); err != nil {
+		return nil, err
+	}
+
+	annotation, err := s.storage.GetAnnotation(id)
+	if err != nil {
+		return nil, err
+	}
+	if annotation.ResourceID != resourceID {
+		return nil, fmt.Errorf("annotation %w", ErrNotFound)
+	}
+	return annotation, nil
+}
+
+// UpdateAnnotation replaces the quote, selector, note and tags of an
+// annotation
+func (s *ResourceService) UpdateAnnotation(resourceID, id, quote string, selector *models.AnnotationSelector, note string, tags []string) (*models.Annotation, error) {
+	annotation, err := s.GetAnnotation(resourceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	annotation.Update(quote, selector, note, tags)
+	if err := validateAnnotation(annotation); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.UpdateAnnotation(annotation); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+// DeleteAnnotation deletes an annotation of a resource
+func (s *ResourceService) DeleteAnnotation(resourceID, id string) error {
+	if _, err := s.GetAnnotation(resourceID, id); err != nil {
+		return err
+	}
+	return s.storage.DeleteAnnotation(id)
+}
+
+// validateAnnotation checks that an annotation quotes some text and that its
+// selector, if any, spans it
+func validateAnnotation(annotation *models.Annotation) error {
+	if strings.TrimSpace(annotation.Quote) == "" {
+		return newValidationError("quote is required")
+	}
+	if selector := annotation.Selector; selector != nil {
+		if selector.Start < 0 {
+			return newValidationError("selector start must not be negative")
+		}
+		if selector.End <= selector.Start {
+			return newValidationError("selector end must be after its start")
+		}
+	}
+	return nil
+}
+
+// loadAnnotations returns the annotations of the resources that have any,
+// keyed by resource ID
+func loadAnnotations(store storage.Storage, resources []*models.CollectedResource) (map[string][]*models.Annotation, error) {
+	annotations := make(map[string][]*models.Annotation)
+	for _, resource := range resources {
+		list, err := store.ListAnnotations(resource.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) > 0 {
+			annotations[resource.ID] = list
+		}
+	}
+	return annotations, nil
+}
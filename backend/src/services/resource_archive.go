@@ -0,0 +1,28 @@
+package services
+
+import (
+	"errors"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// loadArchives returns the archived pages of the resources that have one,
+// keyed by resource ID
+func loadArchives(store storage.Storage, resources []*models.CollectedResource) (map[string]*models.ResourceArchive, error) {
+	archives := make(map[string]*models.ResourceArchive)
+	for _, resource := range resources {
+		if resource.Metadata.ArchivedAt == nil {
+			continue
+		}
+		archive, err := store.GetArchive(resource.ID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		archives[resource.ID] = archive
+	}
+	return archives, nil
+}
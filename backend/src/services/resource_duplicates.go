@@ -0,0 +1,427 @@
+package services
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/nlp"
+)
+
+// DuplicatePolicy decides what collecting a resource that is already
+// collected does
+type DuplicatePolicy string
+
+const (
+	// DuplicatesReject fails with DuplicateResourceError. It is the default.
+	DuplicatesReject DuplicatePolicy = "reject"
+	// DuplicatesMerge adds the new tags, and the category and description if
+	// the existing resource has none, to the existing resource
+	DuplicatesMerge DuplicatePolicy = "merge"
+	// DuplicatesAllow collects the resource anyway
+	DuplicatesAllow DuplicatePolicy = "allow"
+)
+
+// Ways two resources can be duplicates, reported in DuplicateResourceError
+// and DedupeGroup
+const (
+	DuplicateURL   = "url"
+	DuplicateTitle = "title"
+)
+
+const (
+	// minTitleTerms is how many terms a title needs before it is distinctive
+	// enough to match another resource on its own
+	minTitleTerms = 3
+
+	// titleSimilarity is the share of terms two titles must have in common to
+	// count as the same
+	titleSimilarity = 0.9
+)
+
+// trackingParams are the click IDs ad and social networks add to links,
+// which never select a page. Parameters starting with utm_ are dropped too;
+// others, such as ref, pick a branch or a page on some sites and are kept.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "gbraid": true, "wbraid": true,
+	"msclkid": true, "yclid": true, "twclid": true, "ttclid": true, "li_fat_id": true,
+}
+
+// mobileLabels are host name labels of mobile editions of a site, as in
+// m.example.com or en.m.wikipedia.org
+var mobileLabels = map[string]bool{"m": true, "mobile": true, "amp": true}
+
+// CanonicalURL normalizes an http or https URL so that links to the same page
+// compare equal: the host is lowercased and loses default ports and mobile
+// subdomains, tracking parameters and the fragment are dropped, the
+// remaining parameters are sorted and a trailing slash is removed. Fragments
+// starting with / or !, which route single-page apps, are kept. Other
+// strings are returned trimmed but otherwise unchanged. The result is only
+// for comparing; resources keep the URL as given.
+func CanonicalURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return raw
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if labels := strings.Split(host, "."); len(labels) > 2 {
+		kept := labels[:0]
+		for i, label := range labels {
+			if i >= len(labels)-2 || !mobileLabels[label] {
+				kept = append(kept, label)
+			}
+		}
+		host = strings.Join(kept, ".")
+	}
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	if !strings.HasPrefix(u.Fragment, "/") && !strings.HasPrefix(u.Fragment, "!") {
+		u.Fragment, u.RawFragment = "", ""
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u.String()
+}
+
+// urlKey is what two canonical URLs of the same page have in common: http and
+// https, and a www. prefix, are taken to lead to the same page
+func urlKey(canonical string) string {
+	if canonical == "" {
+		return ""
+	}
+	key := canonical
+	if rest, found := strings.CutPrefix(key, "https://"); found {
+		key = rest
+	} else if rest, found := strings.CutPrefix(key, "http://"); found {
+		key = rest
+	}
+	return strings.TrimPrefix(key, "www.")
+}
+
+// urlKeys returns the keys of a resource's own URL and of the canonical URL
+// its page declares
+func urlKeys(resource *models.CollectedResource) []string {
+	keys := []string{urlKey(CanonicalURL(resource.URL))}
+	if page := resource.Metadata.CanonicalURL; page != "" {
+		if key := urlKey(CanonicalURL(page)); key != keys[0] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// titleSeparators split a page title from the site name sites append to it
+var titleSeparators = []string{" | ", " - ", " – ", " — ", " :: ", " · "}
+
+// titleTerms returns the distinct search terms of a resource's title, without
+// a trailing site name such as " | Example Blog"
+func titleTerms(resource *models.CollectedResource) []string {
+	title := resource.Title
+	for _, separator := range titleSeparators {
+		if i := strings.LastIndex(title, separator); i > 0 {
+			title = title[:i]
+			break
+		}
+	}
+	terms := nlp.SearchTerms(title)
+	sort.Strings(terms)
+	return terms
+}
+
+// similarTitles reports whether two sets of sorted title terms are nearly the
+// same. Short titles such as "Introduction" never match.
+func similarTitles(a, b []string) bool {
+	if len(a) < minTitleTerms || len(b) < minTitleTerms {
+		return false
+	}
+	shared := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			shared++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	union := len(a) + len(b) - shared
+	return float64(shared) >= titleSimilarity*float64(union)
+}
+
+// duplicateOf returns how resource duplicates candidate, or "" when it does
+// not
+func duplicateOf(resource, candidate *models.CollectedResource) string {
+	for _, key := range urlKeys(resource) {
+		for _, other := range urlKeys(candidate) {
+			if key != "" && key == other {
+				return DuplicateURL
+			}
+		}
+	}
+	if resource.Title != "" && candidate.Title != "" && similarTitles(titleTerms(resource), titleTerms(candidate)) {
+		return DuplicateTitle
+	}
+	return ""
+}
+
+// findDuplicate returns the oldest collected resource that resource
+// duplicates, and how, or nil when there is none
+func (s *ResourceService) findDuplicate(resource *models.CollectedResource) (*models.CollectedResource, string, error) {
+	resources, err := listResources(s.storage)
+	if err != nil {
+		return nil, "", err
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].CreatedAt.Before(resources[j].CreatedAt)
+	})
+
+	for _, candidate := range resources {
+		if reason := duplicateOf(resource, candidate); reason != "" {
+			return candidate, reason, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// merge adds source's tags, category and description to the resource with
+// the given ID, see mergeInto, and returns it
+func (s *ResourceService) merge(id string, source *models.CollectedResource) (*models.CollectedResource, error) {
+	var target *models.CollectedResource
+	err := retryOnConflict(func() error {
+		var err error
+		if target, err = getResource(s.storage, id); err != nil {
+			return err
+		}
+		if !mergeInto(target, source) {
+			return nil
+		}
+		target.UpdatedAt = time.Now()
+		return s.storage.UpdateResource(target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// DedupeGroup is a resource kept by Dedupe and the duplicates merged into it
+type DedupeGroup struct {
+	Kept       *models.CollectedResource `json:"kept"`
+	Duplicates []Duplicate               `json:"duplicates"`
+}
+
+// Duplicate is a resource found to duplicate another
+type Duplicate struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	// Reason is DuplicateURL or DuplicateTitle
+	Reason string `json:"reason"`
+}
+
+// DedupeResult reports what Dedupe found and, unless it was a dry run, merged
+type DedupeResult struct {
+	Groups []DedupeGroup `json:"groups"`
+	// Merged is the number of duplicates moved to the trash
+	Merged int  `json:"merged"`
+	DryRun bool `json:"dryRun"`
+}
+
+// Dedupe finds the collected resources that duplicate each other, as
+// CollectResource would, and merges each group into its oldest resource: it
+// gains the others' tags, and their category and description where it has
+// none, drafts and ideas are linked to it instead, and the others are moved
+// to the trash. With dryRun nothing is changed.
+func (s *ResourceService) Dedupe(dryRun bool) (*DedupeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resources, err := listResources(s.storage)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].CreatedAt.Before(resources[j].CreatedAt)
+	})
+
+	// Each resource joins the group of the first older resource it
+	// duplicates
+	var groups []*dedupeGroup
+	for _, resource := range resources {
+		joined := false
+		for _, group := range groups {
+			if reason := group.match(resource); reason != "" {
+				group.add(resource, reason)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			groups = append(groups, &dedupeGroup{members: []*models.CollectedResource{resource}})
+		}
+	}
+
+	result := &DedupeResult{Groups: make([]DedupeGroup, 0), DryRun: dryRun}
+	for _, group := range groups {
+		if len(group.members) == 1 {
+			continue
+		}
+
+		kept := group.members[0]
+		if !dryRun {
+			if kept, err = s.mergeGroup(group.members); err != nil {
+				return result, err
+			}
+			result.Merged += len(group.duplicates)
+		}
+		result.Groups = append(result.Groups, DedupeGroup{Kept: kept, Duplicates: group.duplicates})
+	}
+	return result, nil
+}
+
+// dedupeGroup collects resources that duplicate each other, oldest first
+type dedupeGroup struct {
+	members    []*models.CollectedResource
+	duplicates []Duplicate
+}
+
+// match returns how resource duplicates a member of the group, or ""
+func (g *dedupeGroup) match(resource *models.CollectedResource) string {
+	for _, member := range g.members {
+		if reason := duplicateOf(resource, member); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (g *dedupeGroup) add(resource *models.CollectedResource, reason string) {
+	g.members = append(g.members, resource)
+	g.duplicates = append(g.duplicates, Duplicate{ID: resource.ID, URL: resource.URL, Title: resource.Title, Reason: reason})
+}
+
+// mergeGroup merges the later members into the first and returns it
+func (s *ResourceService) mergeGroup(members []*models.CollectedResource) (*models.CollectedResource, error) {
+	kept := members[0]
+	for _, duplicate := range members[1:] {
+		var err error
+		if kept, err = s.merge(kept.ID, duplicate); err != nil {
+			return nil, err
+		}
+		if err := s.relink(duplicate.ID, kept.ID); err != nil {
+			return nil, err
+		}
+
+		err = retryOnConflict(func() error {
+			current, err := getResource(s.storage, duplicate.ID)
+			if err != nil {
+				return err
+			}
+			current.Trash()
+			return s.storage.UpdateResource(current)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+// relink points the drafts and ideas that link to resource from at resource
+// to instead
+func (s *ResourceService) relink(from, to string) error {
+	draftIDs, ideaIDs, err := s.storage.ResourceReferences(from)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range draftIDs {
+		err := retryOnConflict(func() error {
+			draft, err := s.storage.GetDraft(id)
+			if err != nil {
+				return err
+			}
+			draft.Resources = replaceID(draft.Resources, from, to)
+			return s.storage.UpdateDraft(draft)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, id := range ideaIDs {
+		idea, err := s.storage.GetIdea(id)
+		if err != nil {
+			return err
+		}
+		idea.Sources = replaceID(idea.Sources, from, to)
+		if err := s.storage.UpdateIdea(idea); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceID replaces from with to in ids, keeping each ID once
+func replaceID(ids []string, from, to string) []string {
+	replaced := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == from {
+			id = to
+		}
+		if !slices.Contains(replaced, id) {
+			replaced = append(replaced, id)
+		}
+	}
+	return replaced
+}
+
+// mergeInto adds the tags, and the category, description and the source of
+// a note where target has none, of source to target. It reports whether
+// target changed.
+func mergeInto(target, source *models.CollectedResource) bool {
+	changed := false
+	for _, tag := range source.Tags {
+		if !slices.Contains(target.Tags, tag) {
+			target.Tags = append(target.Tags, tag)
+			changed = true
+		}
+	}
+	if target.Category == "" && source.Category != "" {
+		target.Category = source.Category
+		changed = true
+	}
+	if target.Description == "" && source.Description != "" {
+		target.Description = source.Description
+		changed = true
+	}
+	if target.Type == models.ResourceTypeNote && target.Source == nil && source.Source != nil {
+		target.Source = source.Source
+		changed = true
+	}
+	return changed
+}
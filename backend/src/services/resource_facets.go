@@ -0,0 +1,91 @@
+package services
+
+import (
+	"sort"
+
+	"inspiration-blog-writer/backend/src/models"
+)
+
+// FacetCount is the number of resources sharing one value of a facet
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ResourceFacets counts the resources matching a query per type, category,
+// tag and link health, most common first. Resources without a category are
+// not counted under Categories.
+type ResourceFacets struct {
+	Total      int          `json:"total"`
+	Types      []FacetCount `json:"types"`
+	Categories []FacetCount `json:"categories"`
+	Tags       []FacetCount `json:"tags"`
+	Health     []FacetCount `json:"health"`
+}
+
+// GetFacets counts the resources matching the filters of query. Paging and
+// sorting are ignored.
+func (s *ResourceService) GetFacets(query ListQuery) (*ResourceFacets, error) {
+	query.Limit, query.Cursor = 0, ""
+	query, err := filterQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, _, err := s.storage.QueryResources(query)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	types := make(map[string]int)
+	categories := make(map[string]int)
+	tags := make(map[string]int)
+	health := make(map[string]int)
+	for _, resource := range resources {
+		types[string(resource.Type)]++
+		if resource.Category != "" {
+			categories[resource.Category]++
+		}
+		for _, tag := range uniqueTags(resource) {
+			tags[tag]++
+		}
+		health[string(resource.Health.State())]++
+	}
+
+	return &ResourceFacets{
+		Total:      len(resources),
+		Types:      facetCounts(types),
+		Categories: facetCounts(categories),
+		Tags:       facetCounts(tags),
+		Health:     facetCounts(health),
+	}, nil
+}
+
+// uniqueTags returns the tags of resource without repeats, so a resource
+// tagged twice with the same tag counts once
+func uniqueTags(resource *models.CollectedResource) []string {
+	seen := make(map[string]bool, len(resource.Tags))
+	tags := make([]string, 0, len(resource.Tags))
+	for _, tag := range resource.Tags {
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// facetCounts orders counts by count, then by value
+func facetCounts(counts map[string]int) []FacetCount {
+	facets := make([]FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	return facets
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+const (
+	// healthWorkers is how many links are checked at once
+	healthWorkers = 8
+
+	// healthWorkersPerHost is how many of them may be on the same host, so a
+	// round of checks does not hammer a site many resources come from
+	healthWorkersPerHost = 2
+)
+
+// LinkProber checks whether a link works
+type LinkProber interface {
+	Probe(ctx context.Context, url string) (*fetch.Probe, error)
+}
+
+// HealthService checks the links of collected resources and records in
+// CollectedResource.Health whether they still work, moved or broke
+type HealthService struct {
+	storage storage.Storage
+	prober  LinkProber
+	maxAge  time.Duration
+}
+
+// HealthReport counts the outcomes of a round of link checks
+type HealthReport struct {
+	Checked int `json:"checked"`
+	OK      int `json:"ok"`
+	Moved   int `json:"moved"`
+	Broken  int `json:"broken"`
+}
+
+// NewHealthService creates a link health service. Links are checked again
+// once their last check is older than maxAge; zero only checks them through
+// Check and CheckDue.
+func NewHealthService(storage storage.Storage, prober LinkProber, maxAge time.Duration) *HealthService {
+	return &HealthService{
+		storage: storage,
+		prober:  prober,
+		maxAge:  maxAge,
+	}
+}
+
+// Run checks the links that are due every interval until ctx is cancelled
+func (s *HealthService) Run(ctx context.Context, interval time.Duration) {
+	if s.maxAge <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := s.CheckDue(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Checking resource links failed: %v", err)
+		}
+		if report != nil && report.Checked > 0 {
+			log.Printf("Checked %d resource link(s): %d moved, %d broken", report.Checked, report.Moved, report.Broken)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckDue checks the links of the resources never checked, or last checked
+// more than the service's maxAge before now
+func (s *HealthService) CheckDue(ctx context.Context, now time.Time) (*HealthReport, error) {
+	resources, err := listResources(s.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]*models.CollectedResource, 0, len(resources))
+	for _, resource := range resources {
+		checkedAt := resource.Health.CheckedAt
+		if resource.URL == "" {
+			// Notes typed in by hand have no link to check
+			continue
+		}
+		if checkedAt == nil || (s.maxAge > 0 && now.Sub(*checkedAt) >= s.maxAge) {
+			due = append(due, resource)
+		}
+	}
+	return s.checkAll(ctx, due)
+}
+
+// Check checks a resource's link right away. A broken link is not an error:
+// the resource is returned with the failure recorded in its health.
+func (s *HealthService) Check(ctx context.Context, id string) (*models.CollectedResource, error) {
+	if id == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+	return s.check(ctx, id)
+}
+
+// checkAll checks the links of resources concurrently, at most
+// healthWorkersPerHost at a time on any one host
+func (s *HealthService) checkAll(ctx context.Context, resources []*models.CollectedResource) (*HealthReport, error) {
+	workers := make(chan struct{}, healthWorkers)
+	hosts := make(map[string]chan struct{})
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		report   HealthReport
+		firstErr error
+	)
+	for _, resource := range resources {
+		host := linkHost(resource.URL)
+		if hosts[host] == nil {
+			hosts[host] = make(chan struct{}, healthWorkersPerHost)
+		}
+		hostSlots := hosts[host]
+
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+
+			// Waiting for the host first keeps the shared slots free for
+			// other hosts
+			hostSlots <- struct{}{}
+			defer func() { <-hostSlots }()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			checked, err := s.check(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.Is(err, ErrNotFound) && firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			report.Checked++
+			switch checked.Health.Status {
+			case models.HealthOK:
+				report.OK++
+			case models.HealthMoved:
+				report.Moved++
+			case models.HealthBroken:
+				report.Broken++
+			}
+		}(resource.ID)
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return &report, firstErr
+}
+
+// check probes the link of a resource and stores the outcome
+func (s *HealthService) check(ctx context.Context, id string) (*models.CollectedResource, error) {
+	resource, err := getResource(s.storage, id)
+	if err != nil {
+		return nil, err
+	}
+	if resource.URL == "" {
+		return nil, newValidationError("resource has no URL to check")
+	}
+
+	probe, probeErr := s.prober.Probe(ctx, resource.URL)
+	if err := ctx.Err(); err != nil {
+		// Shutting down; the link is checked again on the next run
+		return nil, err
+	}
+
+	// The result is stored without a new version: it is not something users
+	// edit, so clients holding the version can still save their changes
+	applyHealth(resource, probe, probeErr, time.Now())
+	if err := s.storage.SaveResourceHealth(id, resource.Health); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// applyHealth records the outcome of probing a resource's link. A link that
+// answers 401, 403 or 429 exists but turned the checker away, so it is not
+// flagged. A link that ends up on another page, not just https or www., has
+// moved.
+func applyHealth(resource *models.CollectedResource, probe *fetch.Probe, probeErr error, now time.Time) {
+	health := models.ResourceHealth{CheckedAt: &now, Failures: resource.Health.Failures}
+
+	switch {
+	case probeErr != nil:
+		health.Status = models.HealthBroken
+		health.Error = probeErr.Error()
+	case probe.StatusCode >= 400 && !refusedChecker(probe.StatusCode):
+		health.Status = models.HealthBroken
+		health.StatusCode = probe.StatusCode
+		health.FinalURL = probe.URL.String()
+		health.Error = (&fetch.StatusError{StatusCode: probe.StatusCode}).Error()
+	default:
+		health.Status = models.HealthOK
+		health.StatusCode = probe.StatusCode
+		health.FinalURL = probe.URL.String()
+		if urlKey(CanonicalURL(health.FinalURL)) != urlKey(CanonicalURL(resource.URL)) {
+			health.Status = models.HealthMoved
+		}
+	}
+
+	if health.Status == models.HealthBroken {
+		health.Failures++
+	} else {
+		health.Failures = 0
+	}
+	resource.Health = health
+}
+
+func refusedChecker(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden || statusCode == http.StatusTooManyRequests
+}
+
+// linkHost returns the host whose concurrency limit a link counts against
+func linkHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
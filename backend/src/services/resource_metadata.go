@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+const (
+	// metadataQueueSize is how many new resources can wait for the worker.
+	// Resources that do not fit stay pending until the next sweep.
+	metadataQueueSize = 256
+
+	// metadataRetries is how often fetched metadata is applied again when
+	// the resource was edited while its page was being fetched
+	metadataRetries = 3
+)
+
+// MetadataFetcher reads the metadata of a web page
+type MetadataFetcher interface {
+	Metadata(ctx context.Context, url string) (*fetch.Metadata, error)
+}
+
+// PageSnapshotter is a MetadataFetcher that can also keep the page it read,
+// which the metadata service archives
+type PageSnapshotter interface {
+	Snapshot(ctx context.Context, url string) (*fetch.Snapshot, error)
+}
+
+// MetadataService fetches the pages of collected resources in the background
+// and fills in what the user left empty: the title and description, plus the
+// canonical URL, site name, image, favicon and publish date in
+// CollectedResource.Metadata. When its fetcher is a PageSnapshotter it also
+// archives the page, see ResourceArchive.
+type MetadataService struct {
+	storage     storage.Storage
+	fetcher     MetadataFetcher
+	snapshotter PageSnapshotter
+	queue       chan string
+}
+
+// NewMetadataService creates a metadata service. Nothing is fetched until Run
+// is started, except through Refresh.
+func NewMetadataService(storage storage.Storage, fetcher MetadataFetcher) *MetadataService {
+	snapshotter, _ := fetcher.(PageSnapshotter)
+	return &MetadataService{
+		storage:     storage,
+		fetcher:     fetcher,
+		snapshotter: snapshotter,
+		queue:       make(chan string, metadataQueueSize),
+	}
+}
+
+// SetArchiving turns archiving of fetched pages off, or back on when the
+// fetcher can snapshot pages
+func (s *MetadataService) SetArchiving(enabled bool) {
+	s.snapshotter = nil
+	if enabled {
+		s.snapshotter, _ = s.fetcher.(PageSnapshotter)
+	}
+}
+
+// Archiving reports whether fetched pages are archived
+func (s *MetadataService) Archiving() bool {
+	return s.snapshotter != nil
+}
+
+// Enqueue asks for a resource's metadata to be fetched. It never blocks.
+func (s *MetadataService) Enqueue(id string) {
+	select {
+	case s.queue <- id:
+	default:
+	}
+}
+
+// Run fetches queued resources until ctx is cancelled. At start and then
+// every interval it also sweeps storage for resources still pending, such as
+// those queued before a restart.
+func (s *MetadataService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.sweep(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			if _, err := s.fetch(ctx, id, true); err != nil && !errors.Is(err, ErrNotFound) && ctx.Err() == nil {
+				log.Printf("Failed to store metadata of resource %s: %v", id, err)
+			}
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *MetadataService) sweep(ctx context.Context) {
+	count, err := s.FetchPending(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Failed to fetch pending resource metadata: %v", err)
+	}
+	if count > 0 {
+		log.Printf("Fetched metadata of %d pending resource(s)", count)
+	}
+}
+
+// FetchPending fetches the metadata of every resource still pending and
+// returns how many were fetched
+func (s *MetadataService) FetchPending(ctx context.Context) (int, error) {
+	resources, err := listResources(s.storage)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, resource := range resources {
+		if resource.Metadata.Status != models.MetadataPending {
+			continue
+		}
+		if _, err := s.fetch(ctx, resource.ID, true); err != nil && !errors.Is(err, ErrNotFound) {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Refresh fetches a resource's metadata again right away. A page that cannot
+// be fetched is not an error: the resource is returned with the failure
+// recorded in its metadata.
+func (s *MetadataService) Refresh(ctx context.Context, id string) (*models.CollectedResource, error) {
+	if id == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+	return s.fetch(ctx, id, false)
+}
+
+// Archive fetches a resource's page again and replaces its archive. Unlike
+// Refresh, a page that cannot be fetched is an error matching ErrFetchFailed,
+// and the previous archive is kept.
+func (s *MetadataService) Archive(ctx context.Context, id string) (*models.ResourceArchive, error) {
+	if id == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+	if !s.Archiving() {
+		return nil, newValidationError("page archiving is not enabled")
+	}
+
+	resource, err := s.fetch(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if resource.Metadata.Status == models.MetadataFailed {
+		return nil, fmt.Errorf("%w: %s", ErrFetchFailed, resource.Metadata.Error)
+	}
+	return s.storage.GetArchive(id)
+}
+
+// fetch reads the page of a resource and stores what was found, archiving
+// the page when archiving is on. With pendingOnly, resources fetched in the
+// meantime are left alone.
+func (s *MetadataService) fetch(ctx context.Context, id string, pendingOnly bool) (*models.CollectedResource, error) {
+	resource, err := getResource(s.storage, id)
+	if err != nil {
+		return nil, err
+	}
+	if pendingOnly && resource.Metadata.Status != models.MetadataPending {
+		return resource, nil
+	}
+	if resource.URL == "" {
+		return nil, newValidationError("resource has no URL to fetch")
+	}
+
+	metadata, archive, fetchErr := s.read(ctx, resource.URL)
+	if err := ctx.Err(); err != nil {
+		// Shutting down; the resource stays pending for the next run
+		return nil, err
+	}
+
+	// The archive is stored first so the resource never points at an
+	// archive that is not there. A page that cannot be fetched keeps the
+	// archive it had.
+	now := time.Now()
+	if archive != nil {
+		archive.ResourceID, archive.ArchivedAt = id, now
+		if err := s.storage.SaveArchive(archive); err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		title, description := resource.Title, resource.Description
+		applyMetadata(resource, metadata, fetchErr, now)
+		if archive != nil {
+			resource.Metadata.ArchivedAt = &archive.ArchivedAt
+		}
+		if resource.Title == title && resource.Description == description {
+			// Nothing users edit changed, so clients holding the version
+			// can still save theirs
+			err = s.storage.SaveResourceMetadata(id, resource.Metadata)
+			break
+		}
+		err = s.storage.UpdateResource(resource)
+		if !errors.Is(err, ErrConflict) || attempt == metadataRetries {
+			break
+		}
+
+		// Edited in the meantime: fill in the new version instead, which
+		// keeps whatever the user typed
+		if resource, err = getResource(s.storage, id); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// read fetches the metadata of a page and, when archiving, its archive
+func (s *MetadataService) read(ctx context.Context, url string) (*fetch.Metadata, *models.ResourceArchive, error) {
+	if s.snapshotter == nil {
+		metadata, err := s.fetcher.Metadata(ctx, url)
+		return metadata, nil, err
+	}
+
+	snapshot, err := s.snapshotter.Snapshot(ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot.Metadata, &models.ResourceArchive{
+		URL:   snapshot.Page.URL.String(),
+		Title: snapshot.Metadata.Title,
+		Text:  snapshot.Text,
+		HTML:  string(snapshot.Page.Body),
+	}, nil
+}
+
+// applyMetadata fills in the fields of resource that are empty from its
+// fetched page. Without a title the resource is named after its URL.
+func applyMetadata(resource *models.CollectedResource, metadata *fetch.Metadata, fetchErr error, now time.Time) {
+	if fetchErr != nil {
+		resource.Metadata.Status = models.MetadataFailed
+		resource.Metadata.Error = fetchErr.Error()
+		resource.Metadata.FetchedAt = &now
+	} else {
+		resource.Metadata = models.ResourceMetadata{
+			Status:       models.MetadataFetched,
+			CanonicalURL: metadata.CanonicalURL,
+			SiteName:     metadata.SiteName,
+			ImageURL:     metadata.ImageURL,
+			FaviconURL:   metadata.FaviconURL,
+			PublishedAt:  metadata.PublishedAt,
+			FetchedAt:    &now,
+			ArchivedAt:   resource.Metadata.ArchivedAt,
+		}
+		if resource.Title == "" {
+			resource.Title = metadata.Title
+		}
+		if resource.Description == "" {
+			resource.Description = metadata.Description
+		}
+	}
+
+	if resource.Title == "" {
+		resource.Title = resource.URL
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"inspiration-blog-writer/backend/src/models"
+)
+
+// noteTitleLength is the number of runes of a note's first line used as its
+// title when none is given
+const noteTitleLength = 80
+
+// CollectNote creates a note: a quote, passage or thought typed in by hand,
+// with its Markdown text in body. The title defaults to the start of the
+// body and the URL is optional; when given, its page is fetched like any
+// other resource's. Duplicates are handled as in CollectResource.
+func (s *ResourceService) CollectNote(title, body string, source *models.ResourceSource, url, category string, tags []string, duplicates DuplicatePolicy) (*models.CollectedResource, bool, error) {
+	if title == "" {
+		title = noteTitle(body)
+	}
+	return s.collect(models.NewNote(title, body, source, strings.TrimSpace(url), category, tags), duplicates)
+}
+
+// validateResource checks what a resource of its type needs: a URL, or the
+// body of a note. Only notes have a body and a source, which must be
+// complete.
+func validateResource(resource *models.CollectedResource) error {
+	if resource.Type == models.ResourceTypeNote {
+		if strings.TrimSpace(resource.Body) == "" {
+			return newValidationError("body is required for notes")
+		}
+	} else {
+		if resource.URL == "" {
+			return newValidationError("URL is required")
+		}
+		if resource.Body != "" || resource.Source != nil {
+			return newValidationError("only notes have a body and a source")
+		}
+	}
+
+	if source := resource.Source; source != nil {
+		switch source.Kind {
+		case models.SourceBook, models.SourcePodcast, models.SourcePerson, models.SourceOther:
+		default:
+			return newValidationError("source kind must be book, podcast, person or other")
+		}
+		if strings.TrimSpace(source.Name) == "" {
+			return newValidationError("source name is required")
+		}
+	}
+	return nil
+}
+
+// noteTitle returns the start of the first line of a note's body, without
+// Markdown heading, quote and list markers
+func noteTitle(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#>*-+ "))
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > noteTitleLength {
+			line = string([]rune(line)[:noteTitleLength]) + "…"
+		}
+		return line
+	}
+	return ""
+}
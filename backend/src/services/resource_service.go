@@ -1,7 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
 	"inspiration-blog-writer/backend/src/models"
 	"inspiration-blog-writer/backend/src/storage"
 
@@ -10,7 +15,13 @@ import (
 
 // ResourceService handles business logic for collected resources
 type ResourceService struct {
-	storage storage.Storage
+	storage  storage.Storage
+	metadata *MetadataService
+	health   *HealthService
+
+	// mu serializes looking for a duplicate with creating the resource, so
+	// that the same link collected twice at once is still caught
+	mu sync.Mutex
 }
 
 // NewResourceService creates a new resource service instance
@@ -20,65 +31,190 @@ func NewResourceService(storage storage.Storage) *ResourceService {
 	}
 }
 
-// CreateResource creates a new collected resource
+// SetMetadataService makes CreateResource queue new resources for metadata
+// fetching, after which the title may be left empty. Without one, resources
+// keep the fields they were created with.
+func (s *ResourceService) SetMetadataService(metadata *MetadataService) {
+	s.metadata = metadata
+}
+
+// SetHealthService enables CheckHealth
+func (s *ResourceService) SetHealthService(health *HealthService) {
+	s.health = health
+}
+
+// CreateResource creates a new collected resource, failing with
+// DuplicateResourceError when it is already collected. With a metadata
+// service, its page is fetched in the background to fill in empty fields.
 func (s *ResourceService) CreateResource(url, title, description string, resourceType models.ResourceType, category string, tags []string) (*models.CollectedResource, error) {
-	if url == "" {
-		return nil, errors.New("URL is required")
-	}
-	if title == "" {
-		return nil, errors.New("title is required")
-	}
+	resource, _, err := s.CollectResource(url, title, description, resourceType, category, tags, DuplicatesReject)
+	return resource, err
+}
+
+// CollectResource creates a new collected resource under its canonical URL,
+// see CanonicalURL. A resource with the same URL or a nearly identical title
+// is a duplicate, handled according to duplicates (DuplicatesReject when
+// empty). created is false when the resource merged into an existing one,
+// which is returned instead.
+func (s *ResourceService) CollectResource(url, title, description string, resourceType models.ResourceType, category string, tags []string, duplicates DuplicatePolicy) (resource *models.CollectedResource, created bool, err error) {
 	if resourceType == "" {
 		resourceType = models.ResourceTypeOther
 	}
+	return s.collect(models.NewCollectedResource(strings.TrimSpace(url), title, description, resourceType, category, tags), duplicates)
+}
+
+// collect validates and stores a new resource, see CollectResource
+func (s *ResourceService) collect(resource *models.CollectedResource, duplicates DuplicatePolicy) (*models.CollectedResource, bool, error) {
+	if err := validateResource(resource); err != nil {
+		return nil, false, err
+	}
+	if resource.Title == "" && s.metadata == nil {
+		return nil, false, newValidationError("title is required")
+	}
+	switch duplicates {
+	case "":
+		duplicates = DuplicatesReject
+	case DuplicatesReject, DuplicatesMerge, DuplicatesAllow:
+	default:
+		return nil, false, newValidationError("duplicates must be reject, merge or allow")
+	}
 
-	resource := models.NewCollectedResource(url, title, description, resourceType, category, tags)
 	resource.ID = uuid.New().String()
+	fetchPage := s.metadata != nil && resource.URL != ""
+	if fetchPage {
+		resource.Metadata.Status = models.MetadataPending
+	}
 
-	err := s.storage.CreateResource(resource)
-	if err != nil {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if duplicates != DuplicatesAllow {
+		existing, reason, err := s.findDuplicate(resource)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil && duplicates == DuplicatesReject {
+			return nil, false, &DuplicateResourceError{Existing: existing, Reason: reason}
+		}
+		if existing != nil {
+			merged, err := s.merge(existing.ID, resource)
+			return merged, false, err
+		}
+	}
+
+	if err := s.storage.CreateResource(resource); err != nil {
+		return nil, false, err
+	}
+
+	if fetchPage {
+		s.metadata.Enqueue(resource.ID)
+	}
+	return resource, true, nil
+}
+
+// RefreshMetadata fetches a resource's page again and fills in empty fields
+func (s *ResourceService) RefreshMetadata(ctx context.Context, id string) (*models.CollectedResource, error) {
+	if s.metadata == nil {
+		return nil, newValidationError("metadata fetching is not enabled")
+	}
+	return s.metadata.Refresh(ctx, id)
+}
+
+// GetArchive returns the archived copy of a resource's page
+func (s *ResourceService) GetArchive(id string) (*models.ResourceArchive, error) {
+	if id == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+	if _, err := getResource(s.storage, id); err != nil {
 		return nil, err
 	}
+	return s.storage.GetArchive(id)
+}
 
-	return resource, nil
+// ArchivePage fetches a resource's page again and replaces its archive
+func (s *ResourceService) ArchivePage(ctx context.Context, id string) (*models.ResourceArchive, error) {
+	if s.metadata == nil {
+		return nil, newValidationError("metadata fetching is not enabled")
+	}
+	return s.metadata.Archive(ctx, id)
+}
+
+// CheckHealth checks a resource's link right away and records the outcome
+func (s *ResourceService) CheckHealth(ctx context.Context, id string) (*models.CollectedResource, error) {
+	if s.health == nil {
+		return nil, newValidationError("link checking is not enabled")
+	}
+	return s.health.Check(ctx, id)
 }
 
 // GetResource retrieves a collected resource by ID
 func (s *ResourceService) GetResource(id string) (*models.CollectedResource, error) {
 	if id == "" {
-		return nil, errors.New("resource ID is required")
+		return nil, newValidationError("resource ID is required")
 	}
 
-	return s.storage.GetResource(id)
+	return getResource(s.storage, id)
 }
 
-// ListResources retrieves all collected resources
+// ListResources retrieves all collected resources that are not in the trash
 func (s *ResourceService) ListResources() ([]*models.CollectedResource, error) {
-	return s.storage.ListResources()
+	return listResources(s.storage)
+}
+
+// QueryResources retrieves one page of collected resources and the cursor of
+// the next page, which is empty on the last one
+func (s *ResourceService) QueryResources(query ListQuery) ([]*models.CollectedResource, string, error) {
+	query, err := pageQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resources, next, err := s.storage.QueryResources(query)
+	if err != nil {
+		return nil, "", queryError(err)
+	}
+	return resources, next, nil
 }
 
-// UpdateResource updates an existing collected resource
-func (s *ResourceService) UpdateResource(id, title, description string, resourceType models.ResourceType, category string, tags []string) (*models.CollectedResource, error) {
+// UpdateResource updates an existing collected resource. The update only
+// applies to the given version of the resource, or to any with AnyVersion.
+// Notes may leave the title empty, see CollectNote.
+func (s *ResourceService) UpdateResource(id, title, description, body string, source *models.ResourceSource, resourceType models.ResourceType, category string, tags []string, version int) (*models.CollectedResource, error) {
 	if id == "" {
-		return nil, errors.New("resource ID is required")
+		return nil, newValidationError("resource ID is required")
+	}
+	if title == "" && resourceType == models.ResourceTypeNote {
+		title = noteTitle(body)
 	}
 	if title == "" {
-		return nil, errors.New("title is required")
+		return nil, newValidationError("title is required")
 	}
 	if resourceType == "" {
 		resourceType = models.ResourceTypeOther
 	}
 
 	// Get existing resource
-	resource, err := s.storage.GetResource(id)
+	resource, err := getResource(s.storage, id)
 	if err != nil {
 		return nil, err
 	}
+	if version != AnyVersion && version != resource.Version {
+		return nil, &VersionConflictError{
+			Err:     fmt.Errorf("resource version %d is not current: %w", version, ErrPreconditionFailed),
+			Current: resource,
+		}
+	}
 
 	// Update resource
-	resource.Update(title, description, resourceType, category, tags)
+	resource.Update(title, description, body, source, resourceType, category, tags)
+	if err := validateResource(resource); err != nil {
+		return nil, err
+	}
 
 	err = s.storage.UpdateResource(resource)
+	if errors.Is(err, ErrConflict) {
+		return nil, s.resourceConflict(id, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -86,49 +222,149 @@ func (s *ResourceService) UpdateResource(id, title, description string, resource
 	return resource, nil
 }
 
-// DeleteResource deletes a collected resource by ID
-func (s *ResourceService) DeleteResource(id string) error {
-	if id == "" {
-		return errors.New("resource ID is required")
+// resourceConflict attaches the current resource to a storage version
+// conflict
+func (s *ResourceService) resourceConflict(id string, err error) error {
+	current, getErr := s.storage.GetResource(id)
+	if getErr != nil {
+		return err
 	}
+	return &VersionConflictError{Err: err, Current: current}
+}
+
+// ReferencePolicy decides what deleting a resource does to the drafts and
+// ideas that link to it
+type ReferencePolicy string
+
+const (
+	// ReferencesUnlink removes the resource from them. It is the default.
+	ReferencesUnlink ReferencePolicy = "unlink"
+	// ReferencesRefuse keeps the resource and fails with ResourceInUseError
+	ReferencesRefuse ReferencePolicy = "refuse"
+)
+
+// Reference identifies a draft or idea that links to a resource
+type Reference struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
 
-	return s.storage.DeleteResource(id)
+// ResourceReferences lists the drafts and ideas that link to a resource
+type ResourceReferences struct {
+	Drafts []Reference `json:"drafts"`
+	Ideas  []Reference `json:"ideas"`
 }
 
-// GetResourcesByType retrieves resources filtered by type
-func (s *ResourceService) GetResourcesByType(resourceType models.ResourceType) ([]*models.CollectedResource, error) {
-	resources, err := s.storage.ListResources()
+// GetReferences retrieves the drafts and ideas that link to a resource
+func (s *ResourceService) GetReferences(id string) (*ResourceReferences, error) {
+	if id == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+
+	if _, err := getResource(s.storage, id); err != nil {
+		return nil, err
+	}
+
+	draftIDs, ideaIDs, err := s.storage.ResourceReferences(id)
 	if err != nil {
 		return nil, err
 	}
 
-	var filtered []*models.CollectedResource
-	for _, resource := range resources {
-		if resource.Type == resourceType {
-			filtered = append(filtered, resource)
+	references := &ResourceReferences{
+		Drafts: make([]Reference, 0, len(draftIDs)),
+		Ideas:  make([]Reference, 0, len(ideaIDs)),
+	}
+	for _, draftID := range draftIDs {
+		draft, err := s.storage.GetDraft(draftID)
+		if err != nil {
+			return nil, err
+		}
+		references.Drafts = append(references.Drafts, Reference{ID: draft.ID, Title: draft.Title})
+	}
+	for _, ideaID := range ideaIDs {
+		idea, err := s.storage.GetIdea(ideaID)
+		if err != nil {
+			return nil, err
 		}
+		references.Ideas = append(references.Ideas, Reference{ID: idea.ID, Title: idea.Title})
 	}
 
-	return filtered, nil
+	return references, nil
 }
 
-// GetResourcesByCategory retrieves resources filtered by category
-func (s *ResourceService) GetResourcesByCategory(category string) ([]*models.CollectedResource, error) {
-	if category == "" {
-		return s.ListResources()
+// DeleteResource moves a collected resource to the trash, see TrashService.
+// Drafts and ideas that link to it are handled according to policy
+// (ReferencesUnlink when empty): they stop showing the resource right away
+// and lose the link when it is purged.
+func (s *ResourceService) DeleteResource(id string, policy ReferencePolicy) error {
+	if id == "" {
+		return newValidationError("resource ID is required")
 	}
 
-	resources, err := s.storage.ListResources()
-	if err != nil {
-		return nil, err
+	switch policy {
+	case "", ReferencesUnlink:
+	case ReferencesRefuse:
+	default:
+		return newValidationError("references must be unlink or refuse")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if policy == ReferencesRefuse {
+		if err := s.refuseReferenced(id); err != nil {
+			return err
+		}
 	}
 
-	var filtered []*models.CollectedResource
-	for _, resource := range resources {
-		if resource.Category == category {
-			filtered = append(filtered, resource)
+	// Background checks update resources too, so a version conflict only
+	// means the resource has to be read again
+	err := retryOnConflict(func() error {
+		resource, err := getResource(s.storage, id)
+		if err != nil {
+			return err
 		}
+		resource.Trash()
+		return s.storage.UpdateResource(resource)
+	})
+	if err != nil || policy != ReferencesRefuse {
+		return err
 	}
 
-	return filtered, nil
+	// Drafts and ideas link resources without holding s.mu, so a link made
+	// after the check above is only seen now. Linking checks the resource
+	// again after its own write and backs out when it finds it trashed, so
+	// one side always sees the other.
+	draftIDs, ideaIDs, err := s.storage.ResourceReferences(id)
+	if err != nil || len(draftIDs) == 0 && len(ideaIDs) == 0 {
+		return err
+	}
+	err = retryOnConflict(func() error {
+		resource, err := s.storage.GetResource(id)
+		if err != nil {
+			return err
+		}
+		resource.Restore()
+		return s.storage.UpdateResource(resource)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.refuseReferenced(id); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: resource was linked while being deleted", ErrConflict)
+}
+
+// refuseReferenced returns a ResourceInUseError when drafts or ideas link to
+// the resource
+func (s *ResourceService) refuseReferenced(id string) error {
+	references, err := s.GetReferences(id)
+	if err != nil {
+		return err
+	}
+	if len(references.Drafts) > 0 || len(references.Ideas) > 0 {
+		return &ResourceInUseError{References: references}
+	}
+	return nil
 }
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"strings"
+
+	"inspiration-blog-writer/backend/src/search"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// Number of hits returned per entity type
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// SearchResults are the hits of a query grouped by entity type, best match
+// first. Totals counts every hit of each type, including those past the
+// limit.
+type SearchResults struct {
+	Query     string              `json:"query"`
+	Drafts    []search.Hit        `json:"drafts"`
+	Resources []search.Hit        `json:"resources"`
+	Ideas     []search.Hit        `json:"ideas"`
+	Messages  []search.Hit        `json:"messages"`
+	Totals    map[search.Kind]int `json:"totals"`
+}
+
+// SearchService handles full-text search across drafts, resources, ideas and
+// chat messages
+type SearchService struct {
+	storage storage.Storage
+	index   *search.Index
+}
+
+// NewSearchService creates a new search service instance. index must be kept
+// up to date with storage, e.g. by a search.IndexedStorage.
+func NewSearchService(storage storage.Storage, index *search.Index) *SearchService {
+	return &SearchService{
+		storage: storage,
+		index:   index,
+	}
+}
+
+// Search finds the items containing every word of query, returning at most
+// limit hits of each type
+func (s *SearchService) Search(query string, limit int) (*SearchResults, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, newValidationError("query is required")
+	}
+	if limit < 0 || limit > maxSearchLimit {
+		return nil, newValidationError("limit must be between 1 and 50")
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	hits, totals := s.index.Search(query, limit, s.visible)
+
+	results := &SearchResults{
+		Query:     query,
+		Drafts:    make([]search.Hit, 0),
+		Resources: make([]search.Hit, 0),
+		Ideas:     make([]search.Hit, 0),
+		Messages:  make([]search.Hit, 0),
+		Totals:    totals,
+	}
+	for _, hit := range hits {
+		switch hit.Kind {
+		case search.KindDraft:
+			results.Drafts = append(results.Drafts, hit)
+		case search.KindResource:
+			results.Resources = append(results.Resources, hit)
+		case search.KindIdea:
+			results.Ideas = append(results.Ideas, hit)
+		case search.KindMessage:
+			results.Messages = append(results.Messages, hit)
+		}
+	}
+	return results, nil
+}
+
+// visible hides the chat messages of drafts in the trash. Trashed drafts and
+// resources themselves are never indexed.
+func (s *SearchService) visible(hit search.Hit) bool {
+	if hit.Kind != search.KindMessage {
+		return true
+	}
+	_, err := getDraft(s.storage, hit.DraftID)
+	return err == nil
+}
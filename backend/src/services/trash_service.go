@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// Trash holds the drafts and resources that were deleted but not yet purged,
+// most recently deleted first
+type Trash struct {
+	Drafts    []*models.BlogDraft         `json:"drafts"`
+	Resources []*models.CollectedResource `json:"resources"`
+}
+
+// TrashService handles listing, restoring and purging trashed drafts and
+// resources
+type TrashService struct {
+	storage   storage.Storage
+	retention time.Duration
+}
+
+// NewTrashService creates a new trash service instance. Items are purged
+// automatically once they have been in the trash for longer than retention;
+// zero keeps them until purged by hand.
+func NewTrashService(storage storage.Storage, retention time.Duration) *TrashService {
+	return &TrashService{
+		storage:   storage,
+		retention: retention,
+	}
+}
+
+// List retrieves everything in the trash
+func (s *TrashService) List() (*Trash, error) {
+	drafts, err := s.storage.ListDrafts()
+	if err != nil {
+		return nil, err
+	}
+	resources, err := s.storage.ListResources()
+	if err != nil {
+		return nil, err
+	}
+
+	trash := &Trash{
+		Drafts:    make([]*models.BlogDraft, 0),
+		Resources: make([]*models.CollectedResource, 0),
+	}
+	for _, draft := range drafts {
+		if draft.IsTrashed() {
+			trash.Drafts = append(trash.Drafts, draft)
+		}
+	}
+	for _, resource := range resources {
+		if resource.IsTrashed() {
+			trash.Resources = append(trash.Resources, resource)
+		}
+	}
+
+	sort.SliceStable(trash.Drafts, func(i, j int) bool {
+		return trash.Drafts[i].DeletedAt.After(*trash.Drafts[j].DeletedAt)
+	})
+	sort.SliceStable(trash.Resources, func(i, j int) bool {
+		return trash.Resources[i].DeletedAt.After(*trash.Resources[j].DeletedAt)
+	})
+
+	return trash, nil
+}
+
+// RestoreDraft takes a draft out of the trash
+func (s *TrashService) RestoreDraft(id string) (*models.BlogDraft, error) {
+	draft, err := s.trashedDraft(id)
+	if err != nil {
+		return nil, err
+	}
+
+	draft.Restore()
+	if err := s.storage.UpdateDraft(draft); err != nil {
+		return nil, err
+	}
+
+	return draft, nil
+}
+
+// RestoreResource takes a resource out of the trash. Drafts and ideas that
+// linked to it show it again.
+func (s *TrashService) RestoreResource(id string) (*models.CollectedResource, error) {
+	resource, err := s.trashedResource(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resource.Restore()
+	if err := s.storage.UpdateResource(resource); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// PurgeDraft permanently deletes a trashed draft with its revisions
+func (s *TrashService) PurgeDraft(id string) error {
+	if _, err := s.trashedDraft(id); err != nil {
+		return err
+	}
+
+	return s.storage.DeleteDraft(id)
+}
+
+// PurgeResource permanently deletes a trashed resource and removes it from
+// the drafts and ideas that linked to it
+func (s *TrashService) PurgeResource(id string) error {
+	if _, err := s.trashedResource(id); err != nil {
+		return err
+	}
+
+	return s.storage.DeleteResource(id)
+}
+
+// Empty permanently deletes everything in the trash and returns the number
+// of items purged
+func (s *TrashService) Empty() (int, error) {
+	return s.purge(func(time.Time) bool { return true })
+}
+
+// PurgeExpired permanently deletes the items that have been in the trash for
+// longer than the retention period at now, and returns how many there were
+func (s *TrashService) PurgeExpired(now time.Time) (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	cutoff := now.Add(-s.retention)
+	return s.purge(func(deletedAt time.Time) bool {
+		return deletedAt.Before(cutoff)
+	})
+}
+
+// Run purges expired items every interval until ctx is cancelled
+func (s *TrashService) Run(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeExpired(time.Now())
+		if err != nil {
+			log.Printf("Purging expired trash failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d expired items from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *TrashService) purge(expired func(deletedAt time.Time) bool) (int, error) {
+	trash, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, draft := range trash.Drafts {
+		if !expired(*draft.DeletedAt) {
+			continue
+		}
+		if err := s.storage.DeleteDraft(draft.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	for _, resource := range trash.Resources {
+		if !expired(*resource.DeletedAt) {
+			continue
+		}
+		if err := s.storage.DeleteResource(resource.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (s *TrashService) trashedDraft(id string) (*models.BlogDraft, error) {
+	if id == "" {
+		return nil, newValidationError("draft ID is required")
+	}
+
+	draft, err := s.storage.GetDraft(id)
+	if err != nil {
+		return nil, err
+	}
+	if !draft.IsTrashed() {
+		return nil, fmt.Errorf("draft %w in trash", ErrNotFound)
+	}
+	return draft, nil
+}
+
+func (s *TrashService) trashedResource(id string) (*models.CollectedResource, error) {
+	if id == "" {
+		return nil, newValidationError("resource ID is required")
+	}
+
+	resource, err := s.storage.GetResource(id)
+	if err != nil {
+		return nil, err
+	}
+	if !resource.IsTrashed() {
+		return nil, fmt.Errorf("resource %w in trash", ErrNotFound)
+	}
+	return resource, nil
+}
+
+// getDraft retrieves a draft that is not in the trash. Services use it
+// instead of the storage lookup so trashed drafts behave as deleted.
+func getDraft(store storage.Storage, id string) (*models.BlogDraft, error) {
+	draft, err := store.GetDraft(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.IsTrashed() {
+		return nil, fmt.Errorf("draft %w", ErrNotFound)
+	}
+	return draft, nil
+}
+
+// getResource retrieves a resource that is not in the trash
+func getResource(store storage.Storage, id string) (*models.CollectedResource, error) {
+	resource, err := store.GetResource(id)
+	if err != nil {
+		return nil, err
+	}
+	if resource.IsTrashed() {
+		return nil, fmt.Errorf("resource %w", ErrNotFound)
+	}
+	return resource, nil
+}
+
+// listDrafts retrieves the drafts that are not in the trash
+func listDrafts(store storage.Storage) ([]*models.BlogDraft, error) {
+	drafts, err := store.ListDrafts()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]*models.BlogDraft, 0, len(drafts))
+	for _, draft := range drafts {
+		if !draft.IsTrashed() {
+			active = append(active, draft)
+		}
+	}
+	return active, nil
+}
+
+// listResources retrieves the resources that are not in the trash
+func listResources(store storage.Storage) ([]*models.CollectedResource, error) {
+	resources, err := store.ListResources()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]*models.CollectedResource, 0, len(resources))
+	for _, resource := range resources {
+		if !resource.IsTrashed() {
+			active = append(active, resource)
+		}
+	}
+	return active, nil
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by every Storage implementation. Callers should
+// test for them with errors.Is; the concrete error carries the entity name,
+// e.g. "draft not found".
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrConflict      = errors.New("conflict")
+	ErrInvalidQuery  = errors.New("invalid query")
+)
+
+// notFound returns an ErrNotFound for the given entity kind
+func notFound(kind string) error {
+	return fmt.Errorf("%s %w", kind, ErrNotFound)
+}
+
+// conflict returns an ErrConflict for an update based on an outdated version
+// of the given entity kind
+func conflict(kind string) error {
+	return fmt.Errorf("%s was modified by someone else: %w", kind, ErrConflict)
+}
+
+// alreadyExists returns an ErrAlreadyExists for the given entity kind
+func alreadyExists(kind string) error {
+	return fmt.Errorf("%s %w", kind, ErrAlreadyExists)
+}
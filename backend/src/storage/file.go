@@ -0,0 +1,701 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+)
+
+const (
+	snapshotFileName = "snapshot.json"
+	journalFileName  = "journal.log"
+
+	// defaultSnapshotEvery is the number of journal records after which the
+	// journal is folded into a fresh snapshot.
+	defaultSnapshotEvery = 500
+)
+
+const (
+	journalOpPut    = "put"
+	journalOpDelete = "delete"
+)
+
+const (
+	kindDraft      = "draft"
+	kindResource   = "resource"
+	kindIdea       = "idea"
+	kindSession    = "session"
+	kindRevision   = "revision"
+	kindArchive    = "archive"
+	kindAnnotation = "annotation"
+)
+
+// journalRecord is a single line in the append-only journal
+type journalRecord struct {
+	Op   string          `json:"op"`
+	Kind string          `json:"kind"`
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// snapshotData is the on-disk representation of the full data set
+type snapshotData struct {
+	Drafts      []*models.BlogDraft         `json:"drafts"`
+	Resources   []*models.CollectedResource `json:"resources"`
+	Ideas       []*models.InterestIdea      `json:"ideas"`
+	Sessions    []*models.ChatSession       `json:"sessions"`
+	Revisions   []*models.DraftRevision     `json:"revisions"`
+	Archives    []*models.ResourceArchive   `json:"archives"`
+	Annotations []*models.Annotation        `json:"annotations"`
+}
+
+// FileStorage provides a durable storage implementation backed by a local
+// directory. Every write is appended to a journal and fsynced before the call
+// returns, and undone in memory when that fails; the journal is periodically
+// folded into a snapshot that is written atomically (temp file + fsync +
+// rename). Reads are served from memory.
+type FileStorage struct {
+	*MemoryStorage
+
+	dir           string
+	journal       *os.File
+	records       int
+	size          int64 // journal length up to its last complete record
+	snapshotEvery int
+	mu            sync.Mutex
+}
+
+// NewFileStorage opens (or creates) a file-backed storage in dir, restoring
+// the last snapshot and replaying any journal records written after it
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage directory: %w", err)
+	}
+
+	f := &FileStorage{
+		MemoryStorage: NewMemoryStorage(),
+		dir:           dir,
+		snapshotEvery: defaultSnapshotEvery,
+	}
+
+	if err := f.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	replayed, err := f.replayJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(f.path(journalFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	info, err := journal.Stat()
+	if err != nil {
+		journal.Close()
+		return nil, fmt.Errorf("stat journal: %w", err)
+	}
+	f.journal = journal
+	f.records = replayed
+	f.size = info.Size()
+
+	// Fold recovered records into a snapshot so the next start is fast
+	if replayed > 0 {
+		if err := f.compact(); err != nil {
+			journal.Close()
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Close writes a final snapshot and releases the journal file
+func (f *FileStorage) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.journal == nil {
+		return nil
+	}
+
+	err := f.compact()
+	if closeErr := f.journal.Close(); err == nil {
+		err = closeErr
+	}
+	f.journal = nil
+	return err
+}
+
+// Draft operations
+func (f *FileStorage) CreateDraft(draft *models.BlogDraft) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.CreateDraft(draft); err != nil {
+		return err
+	}
+	return f.appendPut(kindDraft, draft.ID, draft)
+}
+
+func (f *FileStorage) UpdateDraft(draft *models.BlogDraft) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.UpdateDraft(draft); err != nil {
+		return err
+	}
+	return f.appendPut(kindDraft, draft.ID, draft)
+}
+
+func (f *FileStorage) DeleteDraft(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.DeleteDraft(id); err != nil {
+		return err
+	}
+	return f.appendDelete(kindDraft, id)
+}
+
+// Resource operations
+func (f *FileStorage) CreateResource(resource *models.CollectedResource) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.CreateResource(resource); err != nil {
+		return err
+	}
+	return f.appendPut(kindResource, resource.ID, resource)
+}
+
+func (f *FileStorage) UpdateResource(resource *models.CollectedResource) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.UpdateResource(resource); err != nil {
+		return err
+	}
+	return f.appendPut(kindResource, resource.ID, resource)
+}
+
+func (f *FileStorage) SaveResourceMetadata(id string, metadata models.ResourceMetadata) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.SaveResourceMetadata(id, metadata); err != nil {
+		return err
+	}
+	return f.appendStoredResource(id)
+}
+
+func (f *FileStorage) SaveResourceHealth(id string, health models.ResourceHealth) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.SaveResourceHealth(id, health); err != nil {
+		return err
+	}
+	return f.appendStoredResource(id)
+}
+
+func (f *FileStorage) DeleteResource(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// The unlink time is journaled so replaying the delete stamps the
+	// drafts and ideas it changes exactly as they were stamped now
+	now := time.Now()
+	if err := f.MemoryStorage.deleteResource(id, now); err != nil {
+		return err
+	}
+	data, err := json.Marshal(now)
+	if err != nil {
+		return f.rollback(err)
+	}
+	return f.append(journalRecord{Op: journalOpDelete, Kind: kindResource, ID: id, Data: data})
+}
+
+// Idea operations
+func (f *FileStorage) CreateIdea(idea *models.InterestIdea) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.CreateIdea(idea); err != nil {
+		return err
+	}
+	return f.appendPut(kindIdea, idea.ID, idea)
+}
+
+func (f *FileStorage) UpdateIdea(idea *models.InterestIdea) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.UpdateIdea(idea); err != nil {
+		return err
+	}
+	return f.appendPut(kindIdea, idea.ID, idea)
+}
+
+func (f *FileStorage) DeleteIdea(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.DeleteIdea(id); err != nil {
+		return err
+	}
+	return f.appendDelete(kindIdea, id)
+}
+
+// Chat session operations
+func (f *FileStorage) CreateSession(session *models.ChatSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.CreateSession(session); err != nil {
+		return err
+	}
+	return f.appendPut(kindSession, session.ID, session)
+}
+
+func (f *FileStorage) UpdateSession(session *models.ChatSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.UpdateSession(session); err != nil {
+		return err
+	}
+	return f.appendPut(kindSession, session.ID, session)
+}
+
+// Draft revision operations
+func (f *FileStorage) CreateRevision(revision *models.DraftRevision) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.CreateRevision(revision); err != nil {
+		return err
+	}
+	return f.appendPut(kindRevision, revision.ID, revision)
+}
+
+// Resource archive operations
+func (f *FileStorage) SaveArchive(archive *models.ResourceArchive) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.SaveArchive(archive); err != nil {
+		return err
+	}
+	return f.appendPut(kindArchive, archive.ResourceID, archive)
+}
+
+// Annotation operations
+func (f *FileStorage) CreateAnnotation(annotation *models.Annotation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.CreateAnnotation(annotation); err != nil {
+		return err
+	}
+	return f.appendPut(kindAnnotation, annotation.ID, annotation)
+}
+
+func (f *FileStorage) UpdateAnnotation(annotation *models.Annotation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.UpdateAnnotation(annotation); err != nil {
+		return err
+	}
+	return f.appendPut(kindAnnotation, annotation.ID, annotation)
+}
+
+func (f *FileStorage) DeleteAnnotation(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemoryStorage.DeleteAnnotation(id); err != nil {
+		return err
+	}
+	return f.appendDelete(kindAnnotation, id)
+}
+
+// appendPut journals the full current value of an entity
+func (f *FileStorage) appendPut(kind, id string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", kind, err)
+	}
+	return f.append(journalRecord{Op: journalOpPut, Kind: kind, ID: id, Data: data})
+}
+
+// appendStoredResource journals a resource as it is now in memory
+func (f *FileStorage) appendStoredResource(id string) error {
+	resource, err := f.MemoryStorage.GetResource(id)
+	if err != nil {
+		return f.rollback(err)
+	}
+	return f.appendPut(kindResource, id, resource)
+}
+
+// appendDelete journals the removal of an entity
+func (f *FileStorage) appendDelete(kind, id string) error {
+	return f.append(journalRecord{Op: journalOpDelete, Kind: kind, ID: id})
+}
+
+// append writes a record to the journal, fsyncs it and compacts when the
+// journal has grown past the snapshot threshold. The change the record
+// describes is already applied in memory, so it is rolled back when the
+// record cannot be written. Callers must hold f.mu.
+func (f *FileStorage) append(record journalRecord) error {
+	if f.journal == nil {
+		return f.rollback(errors.New("storage is closed"))
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return f.rollback(fmt.Errorf("encode journal record: %w", err))
+	}
+	line = append(line, '\n')
+
+	if _, err := f.journal.Write(line); err != nil {
+		return f.rollback(fmt.Errorf("write journal: %w", err))
+	}
+	if err := f.journal.Sync(); err != nil {
+		return f.rollback(fmt.Errorf("sync journal: %w", err))
+	}
+
+	f.size += int64(len(line))
+	f.records++
+	if f.records >= f.snapshotEvery {
+		// The record is durable, so the write succeeded; a failed compaction
+		// is tried again on the next write
+		if err := f.compact(); err != nil {
+			log.Printf("Failed to compact the storage journal: %v", err)
+		}
+	}
+	return nil
+}
+
+// rollback undoes a change that reached memory but not the journal: it cuts
+// the journal back to its last complete record and reloads memory from the
+// snapshot and the journal. It returns cause. Callers must hold f.mu.
+func (f *FileStorage) rollback(cause error) error {
+	if f.journal != nil {
+		if err := f.journal.Truncate(f.size); err != nil {
+			return fmt.Errorf("%w (roll back journal: %v)", cause, err)
+		}
+	}
+
+	m := f.MemoryStorage
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.drafts = make(map[string]*models.BlogDraft)
+	m.resources = make(map[string]*models.CollectedResource)
+	m.ideas = make(map[string]*models.InterestIdea)
+	m.sessions = make(map[string]*models.ChatSession)
+	m.revisions = make(map[string]*models.DraftRevision)
+	m.archives = make(map[string]*models.ResourceArchive)
+	m.annotations = make(map[string]*models.Annotation)
+	if err := f.loadSnapshot(); err != nil {
+		return fmt.Errorf("%w (reload: %v)", cause, err)
+	}
+	if _, err := f.replayJournal(); err != nil {
+		return fmt.Errorf("%w (reload: %v)", cause, err)
+	}
+	return cause
+}
+
+// compact writes the current state to a new snapshot and truncates the
+// journal. A crash between the rename and the truncate is harmless because
+// replaying put/delete records on top of the new snapshot is idempotent.
+// Callers must hold f.mu.
+func (f *FileStorage) compact() error {
+	f.MemoryStorage.mu.RLock()
+	snap := snapshotData{
+		Drafts:      make([]*models.BlogDraft, 0, len(f.MemoryStorage.drafts)),
+		Resources:   make([]*models.CollectedResource, 0, len(f.MemoryStorage.resources)),
+		Ideas:       make([]*models.InterestIdea, 0, len(f.MemoryStorage.ideas)),
+		Sessions:    make([]*models.ChatSession, 0, len(f.MemoryStorage.sessions)),
+		Revisions:   make([]*models.DraftRevision, 0, len(f.MemoryStorage.revisions)),
+		Archives:    make([]*models.ResourceArchive, 0, len(f.MemoryStorage.archives)),
+		Annotations: make([]*models.Annotation, 0, len(f.MemoryStorage.annotations)),
+	}
+	for _, draft := range f.MemoryStorage.drafts {
+		snap.Drafts = append(snap.Drafts, draft)
+	}
+	for _, resource := range f.MemoryStorage.resources {
+		snap.Resources = append(snap.Resources, resource)
+	}
+	for _, idea := range f.MemoryStorage.ideas {
+		snap.Ideas = append(snap.Ideas, idea)
+	}
+	for _, session := range f.MemoryStorage.sessions {
+		snap.Sessions = append(snap.Sessions, session)
+	}
+	for _, revision := range f.MemoryStorage.revisions {
+		snap.Revisions = append(snap.Revisions, revision)
+	}
+	for _, archive := range f.MemoryStorage.archives {
+		snap.Archives = append(snap.Archives, archive)
+	}
+	for _, annotation := range f.MemoryStorage.annotations {
+		snap.Annotations = append(snap.Annotations, annotation)
+	}
+	data, err := json.Marshal(snap)
+	f.MemoryStorage.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if err := writeFileAtomic(f.path(snapshotFileName), data); err != nil {
+		return err
+	}
+
+	if err := f.journal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate journal: %w", err)
+	}
+	f.size = 0
+	if _, err := f.journal.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind journal: %w", err)
+	}
+	if err := f.journal.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+
+	f.records = 0
+	return nil
+}
+
+// loadSnapshot restores the in-memory maps from the last snapshot, if any
+func (f *FileStorage) loadSnapshot() error {
+	data, err := os.ReadFile(f.path(snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snap snapshotData
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	for _, draft := range snap.Drafts {
+		f.MemoryStorage.drafts[draft.ID] = draft
+	}
+	for _, resource := range snap.Resources {
+		f.MemoryStorage.resources[resource.ID] = resource
+	}
+	for _, idea := range snap.Ideas {
+		f.MemoryStorage.ideas[idea.ID] = idea
+	}
+	for _, session := range snap.Sessions {
+		f.MemoryStorage.sessions[session.ID] = session
+	}
+	for _, revision := range snap.Revisions {
+		f.MemoryStorage.revisions[revision.ID] = revision
+	}
+	for _, archive := range snap.Archives {
+		f.MemoryStorage.archives[archive.ResourceID] = archive
+	}
+	for _, annotation := range snap.Annotations {
+		f.MemoryStorage.annotations[annotation.ID] = annotation
+	}
+
+	return nil
+}
+
+// replayJournal applies every complete journal record on top of the loaded
+// snapshot. A torn trailing record left by a crash mid-write is discarded and
+// the journal is truncated back to the last complete record; a corrupt record
+// followed by others fails instead, as dropping them would lose writes.
+func (f *FileStorage) replayJournal() (int, error) {
+	file, err := os.OpenFile(f.path(journalFileName), os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open journal: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	count := 0
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// Nothing left, or a record cut off before its newline
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read journal: %w", err)
+		}
+
+		var record journalRecord
+		if err := json.Unmarshal(bytes.TrimSpace(line), &record); err != nil {
+			if _, err := reader.Peek(1); err == io.EOF {
+				break
+			}
+			return 0, fmt.Errorf("journal record at offset %d is corrupt: %w", offset, err)
+		}
+		if err := f.apply(record); err != nil {
+			return 0, err
+		}
+
+		offset += int64(len(line))
+		count++
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return 0, fmt.Errorf("truncate journal: %w", err)
+	}
+
+	return count, nil
+}
+
+// apply replays a single journal record against the in-memory maps
+func (f *FileStorage) apply(record journalRecord) error {
+	m := f.MemoryStorage
+
+	switch record.Kind {
+	case kindDraft:
+		if record.Op == journalOpDelete {
+			delete(m.drafts, record.ID)
+			m.deleteRevisions(record.ID)
+			return nil
+		}
+		var draft models.BlogDraft
+		if err := json.Unmarshal(record.Data, &draft); err != nil {
+			return fmt.Errorf("decode draft %s: %w", record.ID, err)
+		}
+		m.drafts[record.ID] = &draft
+	case kindResource:
+		if record.Op == journalOpDelete {
+			delete(m.resources, record.ID)
+			delete(m.archives, record.ID)
+			m.deleteAnnotations(record.ID)
+			// Older journals did not record when a resource was deleted
+			at := time.Now()
+			if len(record.Data) > 0 {
+				if err := json.Unmarshal(record.Data, &at); err != nil {
+					return fmt.Errorf("decode resource delete %s: %w", record.ID, err)
+				}
+			}
+			m.unlinkResource(record.ID, at)
+			return nil
+		}
+		var resource models.CollectedResource
+		if err := json.Unmarshal(record.Data, &resource); err != nil {
+			return fmt.Errorf("decode resource %s: %w", record.ID, err)
+		}
+		m.resources[record.ID] = &resource
+	case kindIdea:
+		if record.Op == journalOpDelete {
+			delete(m.ideas, record.ID)
+			return nil
+		}
+		var idea models.InterestIdea
+		if err := json.Unmarshal(record.Data, &idea); err != nil {
+			return fmt.Errorf("decode idea %s: %w", record.ID, err)
+		}
+		m.ideas[record.ID] = &idea
+	case kindSession:
+		if record.Op == journalOpDelete {
+			delete(m.sessions, record.ID)
+			return nil
+		}
+		var session models.ChatSession
+		if err := json.Unmarshal(record.Data, &session); err != nil {
+			return fmt.Errorf("decode session %s: %w", record.ID, err)
+		}
+		m.sessions[record.ID] = &session
+	case kindRevision:
+		var revision models.DraftRevision
+		if err := json.Unmarshal(record.Data, &revision); err != nil {
+			return fmt.Errorf("decode revision %s: %w", record.ID, err)
+		}
+		m.revisions[record.ID] = &revision
+	case kindArchive:
+		var archive models.ResourceArchive
+		if err := json.Unmarshal(record.Data, &archive); err != nil {
+			return fmt.Errorf("decode archive %s: %w", record.ID, err)
+		}
+		m.archives[record.ID] = &archive
+	case kindAnnotation:
+		if record.Op == journalOpDelete {
+			delete(m.annotations, record.ID)
+			return nil
+		}
+		var annotation models.Annotation
+		if err := json.Unmarshal(record.Data, &annotation); err != nil {
+			return fmt.Errorf("decode annotation %s: %w", record.ID, err)
+		}
+		m.annotations[record.ID] = &annotation
+	default:
+		return fmt.Errorf("unknown journal record kind %q", record.Kind)
+	}
+
+	return nil
+}
+
+func (f *FileStorage) path(name string) string {
+	return filepath.Join(f.dir, name)
+}
+
+// writeFileAtomic replaces path with data so that readers only ever observe
+// the old or the new contents, never a partial write
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("rename %s: %w", filepath.Base(path), err)
+	}
+
+	// Persist the directory entry so the rename survives a crash
+	dirFile, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open storage directory: %w", err)
+	}
+	defer dirFile.Close()
+	if err := dirFile.Sync(); err != nil {
+		return fmt.Errorf("sync storage directory: %w", err)
+	}
+
+	return nil
+}
@@ -2,12 +2,25 @@ package storage
 
 import "inspiration-blog-writer/backend/src/models"
 
-// Storage defines the interface for data storage operations
+// Storage defines the interface for data storage operations.
+//
+// UpdateDraft and UpdateResource only succeed when the given Version matches
+// the stored one, returning ErrConflict otherwise, and increment Version on
+// the stored and the given value.
+//
+// DeleteResource also removes the resource from the Resources of every draft
+// (incrementing their Version) and the Sources of every idea in the same
+// operation, so no dangling references remain.
+//
+// The Query methods return one page of items matching a ListQuery together
+// with the cursor of the next page, which is empty on the last page. They
+// return ErrInvalidQuery for unknown sort fields and malformed cursors.
 type Storage interface {
 	// Blog Draft operations
 	CreateDraft(draft *models.BlogDraft) error
 	GetDraft(id string) (*models.BlogDraft, error)
 	ListDrafts() ([]*models.BlogDraft, error)
+	QueryDrafts(query ListQuery) (drafts []*models.BlogDraft, nextCursor string, err error)
 	UpdateDraft(draft *models.BlogDraft) error
 	DeleteDraft(id string) error
 
@@ -15,16 +28,50 @@ type Storage interface {
 	CreateResource(resource *models.CollectedResource) error
 	GetResource(id string) (*models.CollectedResource, error)
 	ListResources() ([]*models.CollectedResource, error)
+	QueryResources(query ListQuery) (resources []*models.CollectedResource, nextCursor string, err error)
 	UpdateResource(resource *models.CollectedResource) error
 	DeleteResource(id string) error
+	// SaveResourceMetadata and SaveResourceHealth store what background
+	// workers found out about a resource's page and link. Users do not edit
+	// either, so unlike UpdateResource they neither check nor increment
+	// Version.
+	SaveResourceMetadata(id string, metadata models.ResourceMetadata) error
+	SaveResourceHealth(id string, health models.ResourceHealth) error
+	// ResourceReferences returns the IDs of the drafts and ideas that link to
+	// a resource, oldest first
+	ResourceReferences(id string) (draftIDs, ideaIDs []string, err error)
+
+	// Resource archive operations. SaveArchive creates or replaces the
+	// archive of an existing resource. Archives are removed together with
+	// their resource.
+	SaveArchive(archive *models.ResourceArchive) error
+	GetArchive(resourceID string) (*models.ResourceArchive, error)
+
+	// Annotation operations. Annotations belong to an existing resource and
+	// are removed together with it. ListAnnotations returns them oldest
+	// first.
+	CreateAnnotation(annotation *models.Annotation) error
+	GetAnnotation(id string) (*models.Annotation, error)
+	ListAnnotations(resourceID string) ([]*models.Annotation, error)
+	UpdateAnnotation(annotation *models.Annotation) error
+	DeleteAnnotation(id string) error
 
 	// Idea operations
 	CreateIdea(idea *models.InterestIdea) error
 	GetIdea(id string) (*models.InterestIdea, error)
 	ListIdeas() ([]*models.InterestIdea, error)
+	QueryIdeas(query ListQuery) (ideas []*models.InterestIdea, nextCursor string, err error)
+	UpdateIdea(idea *models.InterestIdea) error
+	DeleteIdea(id string) error
+
+	// Draft revision operations. Revisions are immutable and are removed
+	// together with their draft.
+	CreateRevision(revision *models.DraftRevision) error
+	ListRevisions(draftID string) ([]*models.DraftRevision, error)
 
 	// Chat Session operations
 	CreateSession(session *models.ChatSession) error
 	GetSession(id string) (*models.ChatSession, error)
+	ListSessions(draftID string) ([]*models.ChatSession, error)
 	UpdateSession(session *models.ChatSession) error
 }
@@ -1,28 +1,38 @@
 package storage
 
 import (
-	"errors"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"inspiration-blog-writer/backend/src/models"
 )
 
-// MemoryStorage provides an in-memory storage implementation
+// MemoryStorage provides an in-memory storage implementation. Values are
+// copied on the way in and out, so callers never share state with the store
+// or with each other.
 type MemoryStorage struct {
-	drafts    map[string]*models.BlogDraft
-	resources map[string]*models.CollectedResource
-	ideas     map[string]*models.InterestIdea
-	sessions  map[string]*models.ChatSession
-	mu        sync.RWMutex
+	drafts      map[string]*models.BlogDraft
+	resources   map[string]*models.CollectedResource
+	ideas       map[string]*models.InterestIdea
+	sessions    map[string]*models.ChatSession
+	revisions   map[string]*models.DraftRevision
+	archives    map[string]*models.ResourceArchive
+	annotations map[string]*models.Annotation
+	mu          sync.RWMutex
 }
 
 // NewMemoryStorage creates a new in-memory storage instance
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		drafts:    make(map[string]*models.BlogDraft),
-		resources: make(map[string]*models.CollectedResource),
-		ideas:     make(map[string]*models.InterestIdea),
-		sessions:  make(map[string]*models.ChatSession),
+		drafts:      make(map[string]*models.BlogDraft),
+		resources:   make(map[string]*models.CollectedResource),
+		ideas:       make(map[string]*models.InterestIdea),
+		sessions:    make(map[string]*models.ChatSession),
+		revisions:   make(map[string]*models.DraftRevision),
+		archives:    make(map[string]*models.ResourceArchive),
+		annotations: make(map[string]*models.Annotation),
 	}
 }
 
@@ -32,10 +42,10 @@ func (m *MemoryStorage) CreateDraft(draft *models.BlogDraft) error {
 	defer m.mu.Unlock()
 
 	if _, exists := m.drafts[draft.ID]; exists {
-		return errors.New("draft already exists")
+		return alreadyExists("draft")
 	}
 
-	m.drafts[draft.ID] = draft
+	m.drafts[draft.ID] = draft.Clone()
 	return nil
 }
 
@@ -45,10 +55,10 @@ func (m *MemoryStorage) GetDraft(id string) (*models.BlogDraft, error) {
 
 	draft, exists := m.drafts[id]
 	if !exists {
-		return nil, errors.New("draft not found")
+		return nil, notFound("draft")
 	}
 
-	return draft, nil
+	return draft.Clone(), nil
 }
 
 func (m *MemoryStorage) ListDrafts() ([]*models.BlogDraft, error) {
@@ -57,21 +67,52 @@ func (m *MemoryStorage) ListDrafts() ([]*models.BlogDraft, error) {
 
 	drafts := make([]*models.BlogDraft, 0, len(m.drafts))
 	for _, draft := range m.drafts {
-		drafts = append(drafts, draft)
+		drafts = append(drafts, draft.Clone())
 	}
 
 	return drafts, nil
 }
 
+func (m *MemoryStorage) QueryDrafts(query ListQuery) ([]*models.BlogDraft, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []*models.BlogDraft
+	var entries []listEntry
+	for _, draft := range m.drafts {
+		if !query.matchesTrash(draft.DeletedAt) || !query.matchesCommon(draft.Tags, draft.CreatedAt) {
+			continue
+		}
+		matches = append(matches, draft)
+		entries = append(entries, listEntry{draft.ID, draft.Title, draft.CreatedAt, draft.UpdatedAt})
+	}
+
+	page, next, err := pageEntries(query, entries)
+	if err != nil {
+		return nil, "", err
+	}
+
+	drafts := make([]*models.BlogDraft, len(page))
+	for i, index := range page {
+		drafts[i] = matches[index].Clone()
+	}
+	return drafts, next, nil
+}
+
 func (m *MemoryStorage) UpdateDraft(draft *models.BlogDraft) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.drafts[draft.ID]; !exists {
-		return errors.New("draft not found")
+	stored, exists := m.drafts[draft.ID]
+	if !exists {
+		return notFound("draft")
+	}
+	if stored.Version != draft.Version {
+		return conflict("draft")
 	}
 
-	m.drafts[draft.ID] = draft
+	draft.Version++
+	m.drafts[draft.ID] = draft.Clone()
 	return nil
 }
 
@@ -80,10 +121,11 @@ func (m *MemoryStorage) DeleteDraft(id string) error {
 	defer m.mu.Unlock()
 
 	if _, exists := m.drafts[id]; !exists {
-		return errors.New("draft not found")
+		return notFound("draft")
 	}
 
 	delete(m.drafts, id)
+	m.deleteRevisions(id)
 	return nil
 }
 
@@ -93,10 +135,10 @@ func (m *MemoryStorage) CreateResource(resource *models.CollectedResource) error
 	defer m.mu.Unlock()
 
 	if _, exists := m.resources[resource.ID]; exists {
-		return errors.New("resource already exists")
+		return alreadyExists("resource")
 	}
 
-	m.resources[resource.ID] = resource
+	m.resources[resource.ID] = resource.Clone()
 	return nil
 }
 
@@ -106,10 +148,10 @@ func (m *MemoryStorage) GetResource(id string) (*models.CollectedResource, error
 
 	resource, exists := m.resources[id]
 	if !exists {
-		return nil, errors.New("resource not found")
+		return nil, notFound("resource")
 	}
 
-	return resource, nil
+	return resource.Clone(), nil
 }
 
 func (m *MemoryStorage) ListResources() ([]*models.CollectedResource, error) {
@@ -118,46 +160,154 @@ func (m *MemoryStorage) ListResources() ([]*models.CollectedResource, error) {
 
 	resources := make([]*models.CollectedResource, 0, len(m.resources))
 	for _, resource := range m.resources {
-		resources = append(resources, resource)
+		resources = append(resources, resource.Clone())
 	}
 
 	return resources, nil
 }
 
+func (m *MemoryStorage) QueryResources(query ListQuery) ([]*models.CollectedResource, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []*models.CollectedResource
+	var entries []listEntry
+	for _, resource := range m.resources {
+		if !query.matchesTrash(resource.DeletedAt) || !query.matchesCommon(resource.Tags, resource.CreatedAt) {
+			continue
+		}
+		if !query.matchesResource(resource) {
+			continue
+		}
+		matches = append(matches, resource)
+		entries = append(entries, listEntry{resource.ID, resource.Title, resource.CreatedAt, resource.UpdatedAt})
+	}
+
+	page, next, err := pageEntries(query, entries)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resources := make([]*models.CollectedResource, len(page))
+	for i, index := range page {
+		resources[i] = matches[index].Clone()
+	}
+	return resources, next, nil
+}
+
 func (m *MemoryStorage) UpdateResource(resource *models.CollectedResource) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.resources[resource.ID]; !exists {
-		return errors.New("resource not found")
+	stored, exists := m.resources[resource.ID]
+	if !exists {
+		return notFound("resource")
+	}
+	if stored.Version != resource.Version {
+		return conflict("resource")
+	}
+
+	resource.Version++
+	m.resources[resource.ID] = resource.Clone()
+	return nil
+}
+
+func (m *MemoryStorage) SaveResourceMetadata(id string, metadata models.ResourceMetadata) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, exists := m.resources[id]
+	if !exists {
+		return notFound("resource")
 	}
 
-	m.resources[resource.ID] = resource
+	updated := stored.Clone()
+	updated.Metadata = metadata
+	m.resources[id] = updated.Clone()
+	return nil
+}
+
+func (m *MemoryStorage) SaveResourceHealth(id string, health models.ResourceHealth) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, exists := m.resources[id]
+	if !exists {
+		return notFound("resource")
+	}
+
+	updated := stored.Clone()
+	updated.Health = health
+	m.resources[id] = updated.Clone()
 	return nil
 }
 
 func (m *MemoryStorage) DeleteResource(id string) error {
+	return m.deleteResource(id, time.Now())
+}
+
+// deleteResource removes a resource and unlinks it, stamping the drafts and
+// ideas it is removed from with the given time
+func (m *MemoryStorage) deleteResource(id string, at time.Time) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if _, exists := m.resources[id]; !exists {
-		return errors.New("resource not found")
+		return notFound("resource")
 	}
 
 	delete(m.resources, id)
+	delete(m.archives, id)
+	m.deleteAnnotations(id)
+	m.unlinkResource(id, at)
 	return nil
 }
 
+func (m *MemoryStorage) ResourceReferences(id string) ([]string, []string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var drafts []*models.BlogDraft
+	for _, draft := range m.drafts {
+		if slices.Contains(draft.Resources, id) {
+			drafts = append(drafts, draft)
+		}
+	}
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].CreatedAt.Before(drafts[j].CreatedAt)
+	})
+
+	var ideas []*models.InterestIdea
+	for _, idea := range m.ideas {
+		if slices.Contains(idea.Sources, id) {
+			ideas = append(ideas, idea)
+		}
+	}
+	sort.Slice(ideas, func(i, j int) bool {
+		return ideas[i].CreatedAt.Before(ideas[j].CreatedAt)
+	})
+
+	draftIDs := make([]string, len(drafts))
+	for i, draft := range drafts {
+		draftIDs[i] = draft.ID
+	}
+	ideaIDs := make([]string, len(ideas))
+	for i, idea := range ideas {
+		ideaIDs[i] = idea.ID
+	}
+	return draftIDs, ideaIDs, nil
+}
+
 // Idea operations
 func (m *MemoryStorage) CreateIdea(idea *models.InterestIdea) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if _, exists := m.ideas[idea.ID]; exists {
-		return errors.New("idea already exists")
+		return alreadyExists("idea")
 	}
 
-	m.ideas[idea.ID] = idea
+	m.ideas[idea.ID] = idea.Clone()
 	return nil
 }
 
@@ -167,10 +317,10 @@ func (m *MemoryStorage) GetIdea(id string) (*models.InterestIdea, error) {
 
 	idea, exists := m.ideas[id]
 	if !exists {
-		return nil, errors.New("idea not found")
+		return nil, notFound("idea")
 	}
 
-	return idea, nil
+	return idea.Clone(), nil
 }
 
 func (m *MemoryStorage) ListIdeas() ([]*models.InterestIdea, error) {
@@ -179,22 +329,78 @@ func (m *MemoryStorage) ListIdeas() ([]*models.InterestIdea, error) {
 
 	ideas := make([]*models.InterestIdea, 0, len(m.ideas))
 	for _, idea := range m.ideas {
-		ideas = append(ideas, idea)
+		ideas = append(ideas, idea.Clone())
 	}
 
 	return ideas, nil
 }
 
+func (m *MemoryStorage) QueryIdeas(query ListQuery) ([]*models.InterestIdea, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []*models.InterestIdea
+	var entries []listEntry
+	for _, idea := range m.ideas {
+		if !query.matchesCommon(idea.Tags, idea.CreatedAt) {
+			continue
+		}
+		if query.DraftID != "" && idea.DraftID != query.DraftID {
+			continue
+		}
+		if query.SourceID != "" && !slices.Contains(idea.Sources, query.SourceID) {
+			continue
+		}
+		matches = append(matches, idea)
+		entries = append(entries, listEntry{idea.ID, idea.Title, idea.CreatedAt, idea.UpdatedAt})
+	}
+
+	page, next, err := pageEntries(query, entries)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ideas := make([]*models.InterestIdea, len(page))
+	for i, index := range page {
+		ideas[i] = matches[index].Clone()
+	}
+	return ideas, next, nil
+}
+
+func (m *MemoryStorage) UpdateIdea(idea *models.InterestIdea) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.ideas[idea.ID]; !exists {
+		return notFound("idea")
+	}
+
+	m.ideas[idea.ID] = idea.Clone()
+	return nil
+}
+
+func (m *MemoryStorage) DeleteIdea(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.ideas[id]; !exists {
+		return notFound("idea")
+	}
+
+	delete(m.ideas, id)
+	return nil
+}
+
 // Chat session operations
 func (m *MemoryStorage) CreateSession(session *models.ChatSession) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if _, exists := m.sessions[session.ID]; exists {
-		return errors.New("session already exists")
+		return alreadyExists("session")
 	}
 
-	m.sessions[session.ID] = session
+	m.sessions[session.ID] = session.Clone()
 	return nil
 }
 
@@ -204,10 +410,24 @@ func (m *MemoryStorage) GetSession(id string) (*models.ChatSession, error) {
 
 	session, exists := m.sessions[id]
 	if !exists {
-		return nil, errors.New("session not found")
+		return nil, notFound("session")
+	}
+
+	return session.Clone(), nil
+}
+
+func (m *MemoryStorage) ListSessions(draftID string) ([]*models.ChatSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*models.ChatSession, 0)
+	for _, session := range m.sessions {
+		if session.DraftID == draftID {
+			sessions = append(sessions, session.Clone())
+		}
 	}
 
-	return session, nil
+	return sessions, nil
 }
 
 func (m *MemoryStorage) UpdateSession(session *models.ChatSession) error {
@@ -215,9 +435,192 @@ func (m *MemoryStorage) UpdateSession(session *models.ChatSession) error {
 	defer m.mu.Unlock()
 
 	if _, exists := m.sessions[session.ID]; !exists {
-		return errors.New("session not found")
+		return notFound("session")
+	}
+
+	m.sessions[session.ID] = session.Clone()
+	return nil
+}
+
+// Resource archive operations
+func (m *MemoryStorage) SaveArchive(archive *models.ResourceArchive) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.resources[archive.ResourceID]; !exists {
+		return notFound("resource")
 	}
 
-	m.sessions[session.ID] = session
+	m.archives[archive.ResourceID] = archive.Clone()
 	return nil
 }
+
+func (m *MemoryStorage) GetArchive(resourceID string) (*models.ResourceArchive, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	archive, exists := m.archives[resourceID]
+	if !exists {
+		return nil, notFound("archive")
+	}
+	return archive.Clone(), nil
+}
+
+// Annotation operations
+func (m *MemoryStorage) CreateAnnotation(annotation *models.Annotation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.resources[annotation.ResourceID]; !exists {
+		return notFound("resource")
+	}
+	if _, exists := m.annotations[annotation.ID]; exists {
+		return alreadyExists("annotation")
+	}
+
+	m.annotations[annotation.ID] = annotation.Clone()
+	return nil
+}
+
+func (m *MemoryStorage) GetAnnotation(id string) (*models.Annotation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	annotation, exists := m.annotations[id]
+	if !exists {
+		return nil, notFound("annotation")
+	}
+	return annotation.Clone(), nil
+}
+
+func (m *MemoryStorage) ListAnnotations(resourceID string) ([]*models.Annotation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	annotations := make([]*models.Annotation, 0)
+	for _, annotation := range m.annotations {
+		if annotation.ResourceID == resourceID {
+			annotations = append(annotations, annotation.Clone())
+		}
+	}
+	sort.Slice(annotations, func(i, j int) bool {
+		if !annotations[i].CreatedAt.Equal(annotations[j].CreatedAt) {
+			return annotations[i].CreatedAt.Before(annotations[j].CreatedAt)
+		}
+		return annotations[i].ID < annotations[j].ID
+	})
+
+	return annotations, nil
+}
+
+func (m *MemoryStorage) UpdateAnnotation(annotation *models.Annotation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.annotations[annotation.ID]; !exists {
+		return notFound("annotation")
+	}
+
+	m.annotations[annotation.ID] = annotation.Clone()
+	return nil
+}
+
+func (m *MemoryStorage) DeleteAnnotation(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.annotations[id]; !exists {
+		return notFound("annotation")
+	}
+
+	delete(m.annotations, id)
+	return nil
+}
+
+// Draft revision operations
+func (m *MemoryStorage) CreateRevision(revision *models.DraftRevision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.drafts[revision.DraftID]; !exists {
+		return notFound("draft")
+	}
+	if _, exists := m.revisions[revision.ID]; exists {
+		return alreadyExists("revision")
+	}
+	for _, existing := range m.revisions {
+		if existing.DraftID == revision.DraftID && existing.Number == revision.Number {
+			return alreadyExists("revision")
+		}
+	}
+
+	m.revisions[revision.ID] = revision.Clone()
+	return nil
+}
+
+func (m *MemoryStorage) ListRevisions(draftID string) ([]*models.DraftRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := make([]*models.DraftRevision, 0)
+	for _, revision := range m.revisions {
+		if revision.DraftID == draftID {
+			revisions = append(revisions, revision.Clone())
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number < revisions[j].Number
+	})
+
+	return revisions, nil
+}
+
+// deleteRevisions drops the revision history of a deleted draft. Callers
+// must hold m.mu.
+func (m *MemoryStorage) deleteRevisions(draftID string) {
+	for id, revision := range m.revisions {
+		if revision.DraftID == draftID {
+			delete(m.revisions, id)
+		}
+	}
+}
+
+// deleteAnnotations drops the annotations of a deleted resource. Callers
+// must hold m.mu.
+func (m *MemoryStorage) deleteAnnotations(resourceID string) {
+	for id, annotation := range m.annotations {
+		if annotation.ResourceID == resourceID {
+			delete(m.annotations, id)
+		}
+	}
+}
+
+// unlinkResource removes a deleted resource from the drafts and ideas that
+// reference it. Changed drafts get a new version, and changed drafts and
+// ideas are marked updated at the given time. Callers must hold m.mu.
+func (m *MemoryStorage) unlinkResource(resourceID string, at time.Time) {
+	for _, draft := range m.drafts {
+		if slices.Contains(draft.Resources, resourceID) {
+			draft.Resources = withoutID(draft.Resources, resourceID)
+			draft.Version++
+			draft.UpdatedAt = at
+		}
+	}
+	for _, idea := range m.ideas {
+		if slices.Contains(idea.Sources, resourceID) {
+			idea.Sources = withoutID(idea.Sources, resourceID)
+			idea.UpdatedAt = at
+		}
+	}
+}
+
+// withoutID returns a copy of ids with every occurrence of id removed
+func withoutID(ids []string, id string) []string {
+	kept := make([]string, 0, len(ids))
+	for _, candidate := range ids {
+		if candidate != id {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+)
+
+// Fields a ListQuery can sort by
+const (
+	SortByCreatedAt = "createdAt"
+	SortByUpdatedAt = "updatedAt"
+	SortByTitle     = "title"
+)
+
+// TrashFilter selects drafts and resources by whether they are in the trash
+type TrashFilter int
+
+const (
+	// ExcludeTrashed leaves out trashed items. It is the zero value.
+	ExcludeTrashed TrashFilter = iota
+	// OnlyTrashed returns nothing but trashed items
+	OnlyTrashed
+	// IncludeTrashed ignores the trash
+	IncludeTrashed
+)
+
+// ListQuery selects one page of drafts, resources or ideas. Items are ordered
+// by SortBy and then by ID, so pages are stable while items are added, and
+// Cursor continues after the last item of the previous page. Filters that do
+// not apply to a kind of item are ignored.
+type ListQuery struct {
+	// Limit is the page size; zero returns every matching item
+	Limit  int
+	Cursor string
+	// SortBy is one of the SortBy constants, SortByCreatedAt when empty
+	SortBy     string
+	Descending bool
+
+	// Tags must all be present on an item
+	Tags []string
+	// CreatedAfter (inclusive) and CreatedBefore (exclusive) bound the
+	// creation time when set
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Trash applies to drafts and resources
+	Trash TrashFilter
+	// Category, Type, Domain, Text and Health apply to resources. Domain
+	// matches the URL's host and its subdomains; Text matches the title,
+	// description, body or URL regardless of case; Health matches the state of the
+	// link, see ResourceHealth.State.
+	Category string
+	Type     models.ResourceType
+	Domain   string
+	Text     string
+	Health   models.HealthStatus
+	// DraftID and SourceID apply to ideas
+	DraftID  string
+	SourceID string
+}
+
+// sortField returns the field the query sorts by
+func (q ListQuery) sortField() string {
+	if q.SortBy == "" {
+		return SortByCreatedAt
+	}
+	return q.SortBy
+}
+
+// validate checks the sort field
+func (q ListQuery) validate() error {
+	switch q.sortField() {
+	case SortByCreatedAt, SortByUpdatedAt, SortByTitle:
+		return nil
+	default:
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, q.SortBy)
+	}
+}
+
+// matchesCommon applies the filters shared by every kind of item
+func (q ListQuery) matchesCommon(tags []string, createdAt time.Time) bool {
+	for _, tag := range q.Tags {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	if !q.CreatedAfter.IsZero() && createdAt.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !createdAt.Before(q.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// matchesResource applies the filters specific to resources
+func (q ListQuery) matchesResource(resource *models.CollectedResource) bool {
+	if q.Category != "" && resource.Category != q.Category {
+		return false
+	}
+	if q.Type != "" && resource.Type != q.Type {
+		return false
+	}
+	if q.Domain != "" && !inDomain(resource.URL, q.Domain) {
+		return false
+	}
+	if q.Text != "" && !containsText(q.Text, resource.Title, resource.Description, resource.Body, resource.URL) {
+		return false
+	}
+	if q.Health != "" && resource.Health.State() != q.Health {
+		return false
+	}
+	return true
+}
+
+// inDomain reports whether the host of rawURL is domain or a subdomain of it
+func inDomain(rawURL, domain string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	domain = strings.ToLower(domain)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// containsText reports whether any of fields contains text, ignoring case
+func containsText(text string, fields ...string) bool {
+	text = strings.ToLower(text)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTrash applies the trash filter to an item's deletion time
+func (q ListQuery) matchesTrash(deletedAt *time.Time) bool {
+	switch q.Trash {
+	case OnlyTrashed:
+		return deletedAt != nil
+	case IncludeTrashed:
+		return true
+	default:
+		return deletedAt == nil
+	}
+}
+
+// cursor is the position after which the next page starts
+type cursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Key        string `json:"k"`
+	ID         string `json:"id"`
+}
+
+// listEntry holds what ordering and paging need to know about one item
+type listEntry struct {
+	id        string
+	title     string
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// key returns the entry's sort key as stored in a cursor
+func (e listEntry) key(sortBy string) string {
+	switch sortBy {
+	case SortByUpdatedAt:
+		return e.updatedAt.UTC().Format(time.RFC3339Nano)
+	case SortByTitle:
+		return e.title
+	default:
+		return e.createdAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// encodeCursor returns the cursor that continues after entry
+func encodeCursor(q ListQuery, entry listEntry) string {
+	data, _ := json.Marshal(cursor{
+		SortBy:     q.sortField(),
+		Descending: q.Descending,
+		Key:        entry.key(q.sortField()),
+		ID:         entry.id,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses the query's cursor into the entry it points after. A
+// cursor is only valid for the sort order that produced it.
+func decodeCursor(q ListQuery) (*listEntry, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if c.SortBy != q.sortField() || c.Descending != q.Descending {
+		return nil, fmt.Errorf("%w: cursor belongs to a different sort order", ErrInvalidQuery)
+	}
+
+	entry := &listEntry{id: c.ID}
+	if c.SortBy == SortByTitle {
+		entry.title = c.Key
+		return entry, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	entry.createdAt, entry.updatedAt = t, t
+	return entry, nil
+}
+
+// compareEntries orders a before b (negative) or after b (positive) in the
+// query's sort order
+func compareEntries(q ListQuery, a, b listEntry) int {
+	var result int
+	switch q.sortField() {
+	case SortByTitle:
+		result = strings.Compare(a.title, b.title)
+	case SortByUpdatedAt:
+		result = a.updatedAt.Compare(b.updatedAt)
+	default:
+		result = a.createdAt.Compare(b.createdAt)
+	}
+	if result == 0 {
+		result = strings.Compare(a.id, b.id)
+	}
+	if q.Descending {
+		result = -result
+	}
+	return result
+}
+
+// pageEntries sorts matching entries and cuts out the page the query asks
+// for. It returns the positions of the page's entries in entries and the
+// cursor of the next page, empty on the last one.
+func pageEntries(q ListQuery, entries []listEntry) ([]int, string, error) {
+	if err := q.validate(); err != nil {
+		return nil, "", err
+	}
+	after, err := decodeCursor(q)
+	if err != nil {
+		return nil, "", err
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return compareEntries(q, entries[order[i]], entries[order[j]]) < 0
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(order), func(i int) bool {
+			return compareEntries(q, entries[order[i]], *after) > 0
+		})
+	}
+	page := order[start:]
+
+	next := ""
+	if q.Limit > 0 && len(page) > q.Limit {
+		page = page[:q.Limit]
+		next = encodeCursor(q, entries[page[len(page)-1]])
+	}
+	return page, next, nil
+}
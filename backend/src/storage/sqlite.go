@@ -0,0 +1,806 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const draftColumns = `id, title, content, tags, resources, created_at, updated_at, version, deleted_at`
+
+const resourceColumns = `id, url, title, description, body, source_kind, source_name, source_author, source_locator, type, category, tags, created_at, updated_at, version, deleted_at,
+	metadata_status, canonical_url, site_name, image_url, favicon_url, published_at, fetched_at, fetch_error, archived_at,
+	health_status, health_status_code, final_url, checked_at, health_error, health_failures`
+
+const ideaColumns = `id, title, description, content, confidence, sources, tags, draft_id, created_at, updated_at`
+
+const annotationColumns = `id, resource_id, quote, selector_start, selector_end, selector_prefix, selector_suffix, note, tags, created_at, updated_at`
+
+const revisionColumns = `id, draft_id, number, title, content, tags, source, hash, restored_from, created_at`
+
+const messageColumns = `id, parent_id, type, content, model, prompt_tokens, completion_tokens, latency_ms, resource_ids, created_at`
+
+// sqliteDriver is the go-sqlite3 driver with the Go functions queries use
+// registered on every connection, so filters match exactly what the memory
+// storage matches
+const sqliteDriver = "sqlite3_blog_writer"
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("url_in_domain", inDomain, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("contains_text", containsText, true)
+		},
+	})
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// SQLiteStorage provides a storage implementation backed by a SQLite database
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage opens the SQLite database at path and migrates its schema
+// to the latest version
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open(sqliteDriver, path+"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+
+	// SQLite serializes writers anyway; a single connection avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// Draft operations
+func (s *SQLiteStorage) CreateDraft(draft *models.BlogDraft) error {
+	_, err := s.db.Exec(`INSERT INTO drafts (`+draftColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		draft.ID, draft.Title, draft.Content, encodeList(draft.Tags), encodeList(draft.Resources), draft.CreatedAt, draft.UpdatedAt,
+		draft.Version, draft.DeletedAt)
+	if isUniqueViolation(err) {
+		return alreadyExists("draft")
+	}
+	return err
+}
+
+func (s *SQLiteStorage) GetDraft(id string) (*models.BlogDraft, error) {
+	row := s.db.QueryRow(`SELECT `+draftColumns+` FROM drafts WHERE id = ?`, id)
+	draft, err := scanDraft(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound("draft")
+	}
+	return draft, err
+}
+
+func (s *SQLiteStorage) ListDrafts() ([]*models.BlogDraft, error) {
+	rows, err := s.db.Query(`SELECT ` + draftColumns + ` FROM drafts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := make([]*models.BlogDraft, 0)
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+
+	return drafts, rows.Err()
+}
+
+func (s *SQLiteStorage) UpdateDraft(draft *models.BlogDraft) error {
+	result, err := s.db.Exec(`UPDATE drafts SET title = ?, content = ?, tags = ?, resources = ?, created_at = ?, updated_at = ?, deleted_at = ?, version = version + 1 WHERE id = ? AND version = ?`,
+		draft.Title, draft.Content, encodeList(draft.Tags), encodeList(draft.Resources), draft.CreatedAt, draft.UpdatedAt, draft.DeletedAt,
+		draft.ID, draft.Version)
+	if err != nil {
+		return err
+	}
+	if err := s.requireVersion(result, "drafts", "draft", draft.ID); err != nil {
+		return err
+	}
+	draft.Version++
+	return nil
+}
+
+func (s *SQLiteStorage) DeleteDraft(id string) error {
+	result, err := s.db.Exec(`DELETE FROM drafts WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "draft")
+}
+
+// Resource operations
+func (s *SQLiteStorage) CreateResource(resource *models.CollectedResource) error {
+	metadata, health, source := &resource.Metadata, &resource.Health, sourceOf(resource)
+	_, err := s.db.Exec(`INSERT INTO resources (`+resourceColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		resource.ID, resource.URL, resource.Title, resource.Description,
+		resource.Body, string(source.Kind), source.Name, source.Author, source.Locator, string(resource.Type), resource.Category,
+		encodeList(resource.Tags), resource.CreatedAt, resource.UpdatedAt, resource.Version, resource.DeletedAt,
+		string(metadata.Status), metadata.CanonicalURL, metadata.SiteName, metadata.ImageURL, metadata.FaviconURL,
+		metadata.PublishedAt, metadata.FetchedAt, metadata.Error, metadata.ArchivedAt,
+		string(health.Status), health.StatusCode, health.FinalURL, health.CheckedAt, health.Error, health.Failures)
+	if isUniqueViolation(err) {
+		return alreadyExists("resource")
+	}
+	return err
+}
+
+func (s *SQLiteStorage) GetResource(id string) (*models.CollectedResource, error) {
+	row := s.db.QueryRow(`SELECT `+resourceColumns+` FROM resources WHERE id = ?`, id)
+	resource, err := scanResource(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound("resource")
+	}
+	return resource, err
+}
+
+func (s *SQLiteStorage) ListResources() ([]*models.CollectedResource, error) {
+	rows, err := s.db.Query(`SELECT ` + resourceColumns + ` FROM resources`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := make([]*models.CollectedResource, 0)
+	for rows.Next() {
+		resource, err := scanResource(rows)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, rows.Err()
+}
+
+func (s *SQLiteStorage) UpdateResource(resource *models.CollectedResource) error {
+	metadata, health, source := &resource.Metadata, &resource.Health, sourceOf(resource)
+	result, err := s.db.Exec(`UPDATE resources SET url = ?, title = ?, description = ?,
+		body = ?, source_kind = ?, source_name = ?, source_author = ?, source_locator = ?, type = ?, category = ?, tags = ?, created_at = ?, updated_at = ?, deleted_at = ?,
+		metadata_status = ?, canonical_url = ?, site_name = ?, image_url = ?, favicon_url = ?, published_at = ?, fetched_at = ?, fetch_error = ?, archived_at = ?,
+		health_status = ?, health_status_code = ?, final_url = ?, checked_at = ?, health_error = ?, health_failures = ?,
+		version = version + 1 WHERE id = ? AND version = ?`,
+		resource.URL, resource.Title, resource.Description,
+		resource.Body, string(source.Kind), source.Name, source.Author, source.Locator, string(resource.Type), resource.Category,
+		encodeList(resource.Tags), resource.CreatedAt, resource.UpdatedAt, resource.DeletedAt,
+		string(metadata.Status), metadata.CanonicalURL, metadata.SiteName, metadata.ImageURL, metadata.FaviconURL,
+		metadata.PublishedAt, metadata.FetchedAt, metadata.Error, metadata.ArchivedAt,
+		string(health.Status), health.StatusCode, health.FinalURL, health.CheckedAt, health.Error, health.Failures,
+		resource.ID, resource.Version)
+	if err != nil {
+		return err
+	}
+	if err := s.requireVersion(result, "resources", "resource", resource.ID); err != nil {
+		return err
+	}
+	resource.Version++
+	return nil
+}
+
+func (s *SQLiteStorage) SaveResourceMetadata(id string, metadata models.ResourceMetadata) error {
+	result, err := s.db.Exec(`UPDATE resources SET
+		metadata_status = ?, canonical_url = ?, site_name = ?, image_url = ?, favicon_url = ?, published_at = ?, fetched_at = ?, fetch_error = ?, archived_at = ?
+		WHERE id = ?`,
+		string(metadata.Status), metadata.CanonicalURL, metadata.SiteName, metadata.ImageURL, metadata.FaviconURL,
+		metadata.PublishedAt, metadata.FetchedAt, metadata.Error, metadata.ArchivedAt, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "resource")
+}
+
+func (s *SQLiteStorage) SaveResourceHealth(id string, health models.ResourceHealth) error {
+	result, err := s.db.Exec(`UPDATE resources SET
+		health_status = ?, health_status_code = ?, final_url = ?, checked_at = ?, health_error = ?, health_failures = ?
+		WHERE id = ?`,
+		string(health.Status), health.StatusCode, health.FinalURL, health.CheckedAt, health.Error, health.Failures, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "resource")
+}
+
+func (s *SQLiteStorage) DeleteResource(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM resources WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, "resource"); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := unlinkFromList(tx, "drafts", "resources", id, true, now); err != nil {
+		return err
+	}
+	if err := unlinkFromList(tx, "ideas", "sources", id, false, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) ResourceReferences(id string) ([]string, []string, error) {
+	draftIDs, _, err := listReferences(s.db, "drafts", "resources", id)
+	if err != nil {
+		return nil, nil, err
+	}
+	ideaIDs, _, err := listReferences(s.db, "ideas", "sources", id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return draftIDs, ideaIDs, nil
+}
+
+// Resource archive operations
+func (s *SQLiteStorage) SaveArchive(archive *models.ResourceArchive) error {
+	_, err := s.db.Exec(`INSERT INTO resource_archives (resource_id, url, title, text, html, archived_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (resource_id) DO UPDATE SET url = excluded.url, title = excluded.title, text = excluded.text,
+		html = excluded.html, archived_at = excluded.archived_at`,
+		archive.ResourceID, archive.URL, archive.Title, archive.Text, archive.HTML, archive.ArchivedAt)
+	if isForeignKeyViolation(err) {
+		return notFound("resource")
+	}
+	return err
+}
+
+func (s *SQLiteStorage) GetArchive(resourceID string) (*models.ResourceArchive, error) {
+	archive := &models.ResourceArchive{}
+	err := s.db.QueryRow(`SELECT resource_id, url, title, text, html, archived_at FROM resource_archives WHERE resource_id = ?`, resourceID).
+		Scan(&archive.ResourceID, &archive.URL, &archive.Title, &archive.Text, &archive.HTML, &archive.ArchivedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound("archive")
+	}
+	return archive, err
+}
+
+// Annotation operations
+func (s *SQLiteStorage) CreateAnnotation(annotation *models.Annotation) error {
+	start, end, prefix, suffix := selectorColumns(annotation.Selector)
+	_, err := s.db.Exec(`INSERT INTO annotations (`+annotationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		annotation.ID, annotation.ResourceID, annotation.Quote, start, end, prefix, suffix,
+		annotation.Note, encodeList(annotation.Tags), annotation.CreatedAt, annotation.UpdatedAt)
+	if isUniqueViolation(err) {
+		return alreadyExists("annotation")
+	}
+	if isForeignKeyViolation(err) {
+		return notFound("resource")
+	}
+	return err
+}
+
+func (s *SQLiteStorage) GetAnnotation(id string) (*models.Annotation, error) {
+	row := s.db.QueryRow(`SELECT `+annotationColumns+` FROM annotations WHERE id = ?`, id)
+	annotation, err := scanAnnotation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound("annotation")
+	}
+	return annotation, err
+}
+
+func (s *SQLiteStorage) ListAnnotations(resourceID string) ([]*models.Annotation, error) {
+	rows, err := s.db.Query(`SELECT `+annotationColumns+` FROM annotations WHERE resource_id = ? ORDER BY created_at, id`, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := make([]*models.Annotation, 0)
+	for rows.Next() {
+		annotation, err := scanAnnotation(rows)
+		if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, annotation)
+	}
+
+	return annotations, rows.Err()
+}
+
+func (s *SQLiteStorage) UpdateAnnotation(annotation *models.Annotation) error {
+	start, end, prefix, suffix := selectorColumns(annotation.Selector)
+	result, err := s.db.Exec(`UPDATE annotations SET quote = ?, selector_start = ?, selector_end = ?, selector_prefix = ?, selector_suffix = ?,
+		note = ?, tags = ?, created_at = ?, updated_at = ? WHERE id = ?`,
+		annotation.Quote, start, end, prefix, suffix, annotation.Note, encodeList(annotation.Tags),
+		annotation.CreatedAt, annotation.UpdatedAt, annotation.ID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "annotation")
+}
+
+func (s *SQLiteStorage) DeleteAnnotation(id string) error {
+	result, err := s.db.Exec(`DELETE FROM annotations WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "annotation")
+}
+
+// Idea operations
+func (s *SQLiteStorage) CreateIdea(idea *models.InterestIdea) error {
+	_, err := s.db.Exec(`INSERT INTO ideas (`+ideaColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		idea.ID, idea.Title, idea.Description, idea.Content, idea.Confidence,
+		encodeList(idea.Sources), encodeList(idea.Tags), idea.DraftID, idea.CreatedAt, idea.UpdatedAt)
+	if isUniqueViolation(err) {
+		return alreadyExists("idea")
+	}
+	return err
+}
+
+func (s *SQLiteStorage) GetIdea(id string) (*models.InterestIdea, error) {
+	row := s.db.QueryRow(`SELECT `+ideaColumns+` FROM ideas WHERE id = ?`, id)
+	idea, err := scanIdea(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound("idea")
+	}
+	return idea, err
+}
+
+func (s *SQLiteStorage) ListIdeas() ([]*models.InterestIdea, error) {
+	rows, err := s.db.Query(`SELECT ` + ideaColumns + ` FROM ideas`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ideas := make([]*models.InterestIdea, 0)
+	for rows.Next() {
+		idea, err := scanIdea(rows)
+		if err != nil {
+			return nil, err
+		}
+		ideas = append(ideas, idea)
+	}
+
+	return ideas, rows.Err()
+}
+
+func (s *SQLiteStorage) UpdateIdea(idea *models.InterestIdea) error {
+	result, err := s.db.Exec(`UPDATE ideas SET title = ?, description = ?, content = ?, confidence = ?, sources = ?, tags = ?, draft_id = ?, created_at = ?, updated_at = ? WHERE id = ?`,
+		idea.Title, idea.Description, idea.Content, idea.Confidence, encodeList(idea.Sources), encodeList(idea.Tags),
+		idea.DraftID, idea.CreatedAt, idea.UpdatedAt, idea.ID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "idea")
+}
+
+func (s *SQLiteStorage) DeleteIdea(id string) error {
+	result, err := s.db.Exec(`DELETE FROM ideas WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "idea")
+}
+
+// Chat session operations
+func (s *SQLiteStorage) CreateSession(session *models.ChatSession) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO chat_sessions (id, draft_id, active, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		session.ID, session.DraftID, session.Active, session.CreatedAt, session.UpdatedAt)
+	if isUniqueViolation(err) {
+		return alreadyExists("session")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertMessages(tx, session); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) GetSession(id string) (*models.ChatSession, error) {
+	session := &models.ChatSession{}
+	err := s.db.QueryRow(`SELECT id, draft_id, active, created_at, updated_at FROM chat_sessions WHERE id = ?`, id).
+		Scan(&session.ID, &session.DraftID, &session.Active, &session.CreatedAt, &session.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound("session")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadMessages(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *SQLiteStorage) ListSessions(draftID string) ([]*models.ChatSession, error) {
+	rows, err := s.db.Query(`SELECT id, draft_id, active, created_at, updated_at FROM chat_sessions WHERE draft_id = ? ORDER BY created_at`, draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*models.ChatSession, 0)
+	for rows.Next() {
+		session := &models.ChatSession{}
+		if err := rows.Scan(&session.ID, &session.DraftID, &session.Active, &session.CreatedAt, &session.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Messages are loaded after the session rows are closed because the
+	// pool holds a single connection
+	for _, session := range sessions {
+		if err := s.loadMessages(session); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// loadMessages fills session.Messages in their stored order
+func (s *SQLiteStorage) loadMessages(session *models.ChatSession) error {
+	rows, err := s.db.Query(`SELECT `+messageColumns+` FROM chat_messages WHERE session_id = ? ORDER BY position`, session.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	session.Messages = []models.ChatMessage{}
+	for rows.Next() {
+		var message models.ChatMessage
+		var messageType, resourceIDs string
+		err := rows.Scan(&message.ID, &message.ParentID, &messageType, &message.Content, &message.Model,
+			&message.PromptTokens, &message.CompletionTokens, &message.LatencyMs, &resourceIDs, &message.CreatedAt)
+		if err != nil {
+			return err
+		}
+		message.Type = models.MessageType(messageType)
+		if message.ResourceIDs, err = decodeList(resourceIDs); err != nil {
+			return err
+		}
+		session.Messages = append(session.Messages, message)
+	}
+
+	return rows.Err()
+}
+
+func (s *SQLiteStorage) UpdateSession(session *models.ChatSession) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE chat_sessions SET draft_id = ?, active = ?, created_at = ?, updated_at = ? WHERE id = ?`,
+		session.DraftID, session.Active, session.CreatedAt, session.UpdatedAt, session.ID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, "session"); err != nil {
+		return err
+	}
+
+	// Messages are rewritten as a whole so that the stored order always
+	// matches the in-memory session
+	if _, err := tx.Exec(`DELETE FROM chat_messages WHERE session_id = ?`, session.ID); err != nil {
+		return err
+	}
+	if err := insertMessages(tx, session); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Draft revision operations
+func (s *SQLiteStorage) CreateRevision(revision *models.DraftRevision) error {
+	_, err := s.db.Exec(`INSERT INTO draft_revisions (`+revisionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		revision.ID, revision.DraftID, revision.Number, revision.Title, revision.Content, encodeList(revision.Tags),
+		string(revision.Source), revision.Hash, revision.RestoredFrom, revision.CreatedAt)
+	if isUniqueViolation(err) {
+		return alreadyExists("revision")
+	}
+	if isForeignKeyViolation(err) {
+		return notFound("draft")
+	}
+	return err
+}
+
+func (s *SQLiteStorage) ListRevisions(draftID string) ([]*models.DraftRevision, error) {
+	rows, err := s.db.Query(`SELECT `+revisionColumns+` FROM draft_revisions WHERE draft_id = ? ORDER BY number`, draftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]*models.DraftRevision, 0)
+	for rows.Next() {
+		revision := &models.DraftRevision{}
+		var tags, source string
+		err := rows.Scan(&revision.ID, &revision.DraftID, &revision.Number, &revision.Title, &revision.Content,
+			&tags, &source, &revision.Hash, &revision.RestoredFrom, &revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revision.Source = models.RevisionSource(source)
+		if revision.Tags, err = decodeList(tags); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// listReferences returns the IDs of the rows of table whose JSON list column
+// contains id, oldest first, together with each row's decoded list
+func listReferences(q queryer, table, column, id string) ([]string, [][]string, error) {
+	rows, err := q.Query(`SELECT id, `+column+` FROM `+table+`
+		WHERE EXISTS (SELECT 1 FROM json_each(`+table+`.`+column+`) WHERE value = ?)
+		ORDER BY created_at, id`, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	var lists [][]string
+	for rows.Next() {
+		var rowID, data string
+		if err := rows.Scan(&rowID, &data); err != nil {
+			return nil, nil, err
+		}
+		list, err := decodeList(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, rowID)
+		lists = append(lists, list)
+	}
+	return ids, lists, rows.Err()
+}
+
+// unlinkFromList removes id from the JSON list column of every row of table
+// that contains it and marks those rows updated at the given time. Rows of
+// versioned tables get a new version.
+func unlinkFromList(tx *sql.Tx, table, column, id string, versioned bool, at time.Time) error {
+	ids, lists, err := listReferences(tx, table, column, id)
+	if err != nil {
+		return err
+	}
+
+	update := `UPDATE ` + table + ` SET ` + column + ` = ?, updated_at = ?`
+	if versioned {
+		update += `, version = version + 1`
+	}
+	for i, rowID := range ids {
+		if _, err := tx.Exec(update+` WHERE id = ?`, encodeList(withoutID(lists[i], id)), at, rowID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertMessages(tx *sql.Tx, session *models.ChatSession) error {
+	for i, message := range session.Messages {
+		_, err := tx.Exec(`INSERT INTO chat_messages (session_id, position, `+messageColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			session.ID, i, message.ID, message.ParentID, string(message.Type), message.Content, message.Model,
+			message.PromptTokens, message.CompletionTokens, message.LatencyMs, encodeList(message.ResourceIDs), message.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanDraft(row rowScanner) (*models.BlogDraft, error) {
+	draft := &models.BlogDraft{}
+	var tags, resources string
+	err := row.Scan(&draft.ID, &draft.Title, &draft.Content, &tags, &resources, &draft.CreatedAt, &draft.UpdatedAt,
+		&draft.Version, &draft.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Tags, err = decodeList(tags); err != nil {
+		return nil, err
+	}
+	if draft.Resources, err = decodeList(resources); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func scanResource(row rowScanner) (*models.CollectedResource, error) {
+	resource := &models.CollectedResource{}
+	var resourceType, tags, status, healthStatus, sourceKind string
+	var source models.ResourceSource
+	metadata, health := &resource.Metadata, &resource.Health
+	err := row.Scan(&resource.ID, &resource.URL, &resource.Title, &resource.Description,
+		&resource.Body, &sourceKind, &source.Name, &source.Author, &source.Locator, &resourceType,
+		&resource.Category, &tags, &resource.CreatedAt, &resource.UpdatedAt, &resource.Version, &resource.DeletedAt,
+		&status, &metadata.CanonicalURL, &metadata.SiteName, &metadata.ImageURL, &metadata.FaviconURL,
+		&metadata.PublishedAt, &metadata.FetchedAt, &metadata.Error, &metadata.ArchivedAt,
+		&healthStatus, &health.StatusCode, &health.FinalURL, &health.CheckedAt, &health.Error, &health.Failures)
+	if err != nil {
+		return nil, err
+	}
+	resource.Type = models.ResourceType(resourceType)
+	metadata.Status = models.MetadataStatus(status)
+	health.Status = models.HealthStatus(healthStatus)
+	if sourceKind != "" {
+		source.Kind = models.SourceKind(sourceKind)
+		resource.Source = &source
+	}
+	if resource.Tags, err = decodeList(tags); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// sourceOf returns the source of a resource, empty when it has none
+func sourceOf(resource *models.CollectedResource) models.ResourceSource {
+	if resource.Source == nil {
+		return models.ResourceSource{}
+	}
+	return *resource.Source
+}
+
+// selectorColumns returns the column values of an annotation's selector;
+// the positions are NULL without one
+func selectorColumns(selector *models.AnnotationSelector) (start, end sql.NullInt64, prefix, suffix string) {
+	if selector == nil {
+		return start, end, "", ""
+	}
+	start = sql.NullInt64{Int64: int64(selector.Start), Valid: true}
+	end = sql.NullInt64{Int64: int64(selector.End), Valid: true}
+	return start, end, selector.Prefix, selector.Suffix
+}
+
+func scanAnnotation(row rowScanner) (*models.Annotation, error) {
+	annotation := &models.Annotation{}
+	var start, end sql.NullInt64
+	var prefix, suffix, tags string
+	err := row.Scan(&annotation.ID, &annotation.ResourceID, &annotation.Quote, &start, &end, &prefix, &suffix,
+		&annotation.Note, &tags, &annotation.CreatedAt, &annotation.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if start.Valid {
+		annotation.Selector = &models.AnnotationSelector{Start: int(start.Int64), End: int(end.Int64), Prefix: prefix, Suffix: suffix}
+	}
+	if annotation.Tags, err = decodeList(tags); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+func scanIdea(row rowScanner) (*models.InterestIdea, error) {
+	idea := &models.InterestIdea{}
+	var sources, tags string
+	err := row.Scan(&idea.ID, &idea.Title, &idea.Description, &idea.Content, &idea.Confidence,
+		&sources, &tags, &idea.DraftID, &idea.CreatedAt, &idea.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if idea.Sources, err = decodeList(sources); err != nil {
+		return nil, err
+	}
+	if idea.Tags, err = decodeList(tags); err != nil {
+		return nil, err
+	}
+	return idea, nil
+}
+
+// encodeList stores a string slice as a JSON array so it stays readable and
+// queryable with SQLite's json functions
+func encodeList(values []string) string {
+	if values == nil {
+		return "[]"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+func decodeList(data string) ([]string, error) {
+	var values []string
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return nil, fmt.Errorf("decode list column: %w", err)
+	}
+	return values, nil
+}
+
+func requireAffected(result sql.Result, kind string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound(kind)
+	}
+	return nil
+}
+
+// requireVersion interprets the result of a versioned update: when no row
+// matched, the entity either does not exist or was changed since it was read
+func (s *SQLiteStorage) requireVersion(result sql.Result, table, kind, id string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM `+table+` WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return conflict(kind)
+	}
+	return notFound(kind)
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+	return false
+}
+
+func isForeignKeyViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is a single, append-only schema change. Versions must be
+// strictly increasing and a released migration must never be edited.
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+// sqliteMigrations lists every schema change in the order it is applied
+var sqliteMigrations = []migration{
+	{
+		version: 1,
+		name:    "create core tables",
+		statements: []string{
+			`CREATE TABLE drafts (
+				id         TEXT PRIMARY KEY,
+				title      TEXT NOT NULL,
+				content    TEXT NOT NULL DEFAULT '',
+				tags       TEXT NOT NULL DEFAULT '[]',
+				resources  TEXT NOT NULL DEFAULT '[]',
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE resources (
+				id          TEXT PRIMARY KEY,
+				url         TEXT NOT NULL,
+				title       TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				type        TEXT NOT NULL,
+				category    TEXT NOT NULL DEFAULT '',
+				tags        TEXT NOT NULL DEFAULT '[]',
+				created_at  TIMESTAMP NOT NULL,
+				updated_at  TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE ideas (
+				id          TEXT PRIMARY KEY,
+				title       TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				content     TEXT NOT NULL DEFAULT '',
+				confidence  REAL NOT NULL DEFAULT 0,
+				sources     TEXT NOT NULL DEFAULT '[]',
+				tags        TEXT NOT NULL DEFAULT '[]',
+				created_at  TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE chat_sessions (
+				id         TEXT PRIMARY KEY,
+				draft_id   TEXT NOT NULL,
+				active     INTEGER NOT NULL DEFAULT 1,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE chat_messages (
+				session_id TEXT NOT NULL REFERENCES chat_sessions(id) ON DELETE CASCADE,
+				position   INTEGER NOT NULL,
+				id         TEXT NOT NULL DEFAULT '',
+				type       TEXT NOT NULL,
+				content    TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (session_id, position)
+			)`,
+			`CREATE INDEX idx_chat_sessions_draft_id ON chat_sessions(draft_id)`,
+			`CREATE INDEX idx_resources_type ON resources(type)`,
+			`CREATE INDEX idx_resources_category ON resources(category)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "add idea draft and update time",
+		statements: []string{
+			`ALTER TABLE ideas ADD COLUMN draft_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE ideas ADD COLUMN updated_at TIMESTAMP`,
+			`UPDATE ideas SET updated_at = created_at`,
+			`CREATE INDEX idx_ideas_draft_id ON ideas(draft_id)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "add chat message metadata",
+		statements: []string{
+			`ALTER TABLE chat_messages ADD COLUMN parent_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE chat_messages ADD COLUMN model TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE chat_messages ADD COLUMN prompt_tokens INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE chat_messages ADD COLUMN completion_tokens INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE chat_messages ADD COLUMN latency_ms INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE chat_messages ADD COLUMN resource_ids TEXT NOT NULL DEFAULT '[]'`,
+			// Messages stored before IDs were assigned get a random one
+			`UPDATE chat_messages SET id = lower(hex(randomblob(16))) WHERE id = ''`,
+		},
+	},
+	{
+		version: 4,
+		name:    "add draft revisions",
+		statements: []string{
+			`CREATE TABLE draft_revisions (
+				id            TEXT PRIMARY KEY,
+				draft_id      TEXT NOT NULL REFERENCES drafts(id) ON DELETE CASCADE,
+				number        INTEGER NOT NULL,
+				title         TEXT NOT NULL,
+				content       TEXT NOT NULL DEFAULT '',
+				tags          TEXT NOT NULL DEFAULT '[]',
+				source        TEXT NOT NULL,
+				hash          TEXT NOT NULL,
+				restored_from INTEGER NOT NULL DEFAULT 0,
+				created_at    TIMESTAMP NOT NULL,
+				UNIQUE (draft_id, number)
+			)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "add draft and resource versions",
+		statements: []string{
+			`ALTER TABLE drafts ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+			`ALTER TABLE resources ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		version: 6,
+		name:    "add trash timestamps",
+		statements: []string{
+			`ALTER TABLE drafts ADD COLUMN deleted_at TIMESTAMP`,
+			`ALTER TABLE resources ADD COLUMN deleted_at TIMESTAMP`,
+		},
+	},
+	{
+		version: 7,
+		name:    "add resource page metadata",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN metadata_status TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN canonical_url TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN site_name TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN image_url TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN favicon_url TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN published_at TIMESTAMP`,
+			`ALTER TABLE resources ADD COLUMN fetched_at TIMESTAMP`,
+			`ALTER TABLE resources ADD COLUMN fetch_error TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 8,
+		name:    "add resource link health",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN health_status TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN health_status_code INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE resources ADD COLUMN final_url TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN checked_at TIMESTAMP`,
+			`ALTER TABLE resources ADD COLUMN health_error TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN health_failures INTEGER NOT NULL DEFAULT 0`,
+			`CREATE INDEX idx_resources_health_status ON resources(health_status)`,
+		},
+	},
+	{
+		version: 9,
+		name:    "add resource archives",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN archived_at TIMESTAMP`,
+			`CREATE TABLE resource_archives (
+				resource_id TEXT PRIMARY KEY REFERENCES resources(id) ON DELETE CASCADE,
+				url         TEXT NOT NULL,
+				title       TEXT NOT NULL,
+				text        TEXT NOT NULL,
+				html        TEXT NOT NULL,
+				archived_at TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 10,
+		name:    "add note bodies and sources",
+		statements: []string{
+			`ALTER TABLE resources ADD COLUMN body TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN source_kind TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN source_name TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN source_author TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE resources ADD COLUMN source_locator TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 11,
+		name:    "add resource annotations",
+		statements: []string{
+			`CREATE TABLE annotations (
+				id              TEXT PRIMARY KEY,
+				resource_id     TEXT NOT NULL REFERENCES resources(id) ON DELETE CASCADE,
+				quote           TEXT NOT NULL,
+				selector_start  INTEGER,
+				selector_end    INTEGER,
+				selector_prefix TEXT NOT NULL DEFAULT '',
+				selector_suffix TEXT NOT NULL DEFAULT '',
+				note            TEXT NOT NULL DEFAULT '',
+				tags            TEXT NOT NULL DEFAULT '[]',
+				created_at      TIMESTAMP NOT NULL,
+				updated_at      TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX idx_annotations_resource_id ON annotations(resource_id, created_at)`,
+		},
+	},
+}
+
+// migrateSQLite brings the database schema up to the latest version. Each
+// migration runs in its own transaction together with its version record, so
+// an interrupted upgrade resumes from the last completed step.
+func migrateSQLite(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	latest := sqliteMigrations[len(sqliteMigrations)-1].version
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", current, latest)
+	}
+
+	for _, m := range sqliteMigrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", m.version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %d: %w", m.version, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"strings"
+
+	"inspiration-blog-writer/backend/src/models"
+)
+
+// sqliteQuery translates a ListQuery into SQL. Timestamps are compared
+// through julianday() because the driver stores them as text with a zone
+// offset, which does not sort chronologically.
+type sqliteQuery struct {
+	query ListQuery
+	where []string
+	args  []interface{}
+}
+
+// newSQLiteQuery validates query and adds the filters every table shares:
+// tags, creation time and the cursor position
+func newSQLiteQuery(query ListQuery) (*sqliteQuery, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	after, err := decodeCursor(query)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &sqliteQuery{query: query}
+	for _, tag := range query.Tags {
+		b.add(`EXISTS (SELECT 1 FROM json_each(tags) WHERE value = ?)`, tag)
+	}
+	if !query.CreatedAfter.IsZero() {
+		b.add(`julianday(created_at) >= julianday(?)`, query.CreatedAfter)
+	}
+	if !query.CreatedBefore.IsZero() {
+		b.add(`julianday(created_at) < julianday(?)`, query.CreatedBefore)
+	}
+	if after != nil {
+		op := ">"
+		if query.Descending {
+			op = "<"
+		}
+		key, value := b.sortKey(), `julianday(?)`
+		if query.sortField() == SortByTitle {
+			value = `?`
+		}
+		b.add(`(`+key+` `+op+` `+value+` OR (`+key+` = `+value+` AND id `+op+` ?))`,
+			b.cursorArg(*after), b.cursorArg(*after), after.id)
+	}
+	return b, nil
+}
+
+func (b *sqliteQuery) add(clause string, args ...interface{}) {
+	b.where = append(b.where, clause)
+	b.args = append(b.args, args...)
+}
+
+// trash adds the trash filter for tables with a deleted_at column
+func (b *sqliteQuery) trash() {
+	switch b.query.Trash {
+	case OnlyTrashed:
+		b.add(`deleted_at IS NOT NULL`)
+	case IncludeTrashed:
+	default:
+		b.add(`deleted_at IS NULL`)
+	}
+}
+
+// sortKey returns the SQL expression rows are ordered by
+func (b *sqliteQuery) sortKey() string {
+	switch b.query.sortField() {
+	case SortByTitle:
+		return `title`
+	case SortByUpdatedAt:
+		return `julianday(updated_at)`
+	default:
+		return `julianday(created_at)`
+	}
+}
+
+// cursorArg returns the cursor's sort key as a query argument
+func (b *sqliteQuery) cursorArg(after listEntry) interface{} {
+	switch b.query.sortField() {
+	case SortByTitle:
+		return after.title
+	case SortByUpdatedAt:
+		return after.updatedAt
+	default:
+		return after.createdAt
+	}
+}
+
+// sql completes a SELECT with the filters, the order and one row more than
+// the limit, which tells whether another page follows
+func (b *sqliteQuery) sql(selectFrom string) (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(selectFrom)
+	if len(b.where) > 0 {
+		sb.WriteString(` WHERE `)
+		sb.WriteString(strings.Join(b.where, ` AND `))
+	}
+
+	direction := ` ASC`
+	if b.query.Descending {
+		direction = ` DESC`
+	}
+	sb.WriteString(` ORDER BY ` + b.sortKey() + direction + `, id` + direction)
+
+	args := b.args
+	if b.query.Limit > 0 {
+		sb.WriteString(` LIMIT ?`)
+		args = append(args, b.query.Limit+1)
+	}
+	return sb.String(), args
+}
+
+// nextCursor trims the extra row fetched by sql and returns the cursor of
+// the next page, or an empty cursor on the last page
+func (b *sqliteQuery) nextCursor(count int, entry func(i int) listEntry) (int, string) {
+	if b.query.Limit <= 0 || count <= b.query.Limit {
+		return count, ""
+	}
+	return b.query.Limit, encodeCursor(b.query, entry(b.query.Limit-1))
+}
+
+func (s *SQLiteStorage) QueryDrafts(query ListQuery) ([]*models.BlogDraft, string, error) {
+	b, err := newSQLiteQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+	b.trash()
+
+	statement, args := b.sql(`SELECT ` + draftColumns + ` FROM drafts`)
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	drafts := make([]*models.BlogDraft, 0)
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		drafts = append(drafts, draft)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	count, next := b.nextCursor(len(drafts), func(i int) listEntry {
+		return listEntry{drafts[i].ID, drafts[i].Title, drafts[i].CreatedAt, drafts[i].UpdatedAt}
+	})
+	return drafts[:count], next, nil
+}
+
+func (s *SQLiteStorage) QueryResources(query ListQuery) ([]*models.CollectedResource, string, error) {
+	b, err := newSQLiteQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+	b.trash()
+	if query.Category != "" {
+		b.add(`category = ?`, query.Category)
+	}
+	if query.Type != "" {
+		b.add(`type = ?`, string(query.Type))
+	}
+	if query.Domain != "" {
+		b.add(`url_in_domain(url, ?)`, query.Domain)
+	}
+	if query.Text != "" {
+		b.add(`contains_text(?, title, description, body, url)`, query.Text)
+	}
+	if query.Health == models.HealthUnchecked {
+		b.add(`health_status = ''`)
+	} else if query.Health != "" {
+		b.add(`health_status = ?`, string(query.Health))
+	}
+
+	statement, args := b.sql(`SELECT ` + resourceColumns + ` FROM resources`)
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	resources := make([]*models.CollectedResource, 0)
+	for rows.Next() {
+		resource, err := scanResource(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		resources = append(resources, resource)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	count, next := b.nextCursor(len(resources), func(i int) listEntry {
+		return listEntry{resources[i].ID, resources[i].Title, resources[i].CreatedAt, resources[i].UpdatedAt}
+	})
+	return resources[:count], next, nil
+}
+
+func (s *SQLiteStorage) QueryIdeas(query ListQuery) ([]*models.InterestIdea, string, error) {
+	b, err := newSQLiteQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+	if query.DraftID != "" {
+		b.add(`draft_id = ?`, query.DraftID)
+	}
+	if query.SourceID != "" {
+		b.add(`EXISTS (SELECT 1 FROM json_each(sources) WHERE value = ?)`, query.SourceID)
+	}
+
+	statement, args := b.sql(`SELECT ` + ideaColumns + ` FROM ideas`)
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ideas := make([]*models.InterestIdea, 0)
+	for rows.Next() {
+		idea, err := scanIdea(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		ideas = append(ideas, idea)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	count, next := b.nextCursor(len(ideas), func(i int) listEntry {
+		return listEntry{ideas[i].ID, ideas[i].Title, ideas[i].CreatedAt, ideas[i].UpdatedAt}
+	})
+	return ideas[:count], next, nil
+}
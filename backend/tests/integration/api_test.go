@@ -3,10 +3,16 @@ package integration
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"inspiration-blog-writer/backend/src/api"
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/search"
 	"inspiration-blog-writer/backend/src/services"
 	"inspiration-blog-writer/backend/src/storage"
 
@@ -18,13 +24,24 @@ func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 
 	// Initialize test storage and services
-	store := storage.NewMemoryStorage()
+	index := search.NewIndex()
+	store, _ := search.NewIndexedStorage(storage.NewMemoryStorage(), index)
 	draftService := services.NewDraftService(store)
 	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, nil)
+	chatService := services.NewChatService(store, llm.NewFakeProvider())
+	trashService := services.NewTrashService(store, 0)
+	searchService := services.NewSearchService(store, index)
+	resourceService.SetMetadataService(services.NewMetadataService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true})))
+	resourceService.SetHealthService(services.NewHealthService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}), 0))
 
 	// Initialize handlers
 	draftHandlers := api.NewDraftHandlers(draftService)
 	resourceHandlers := api.NewResourceHandlers(resourceService)
+	ideaHandlers := api.NewIdeaHandlers(ideaService)
+	chatHandlers := api.NewChatHandlers(chatService)
+	trashHandlers := api.NewTrashHandlers(trashService)
+	searchHandlers := api.NewSearchHandlers(searchService)
 
 	// Setup router
 	router := gin.New()
@@ -33,7 +50,8 @@ func setupTestRouter() *gin.Engine {
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match")
+		c.Header("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -62,17 +80,69 @@ func setupTestRouter() *gin.Engine {
 			drafts.GET("/:id", draftHandlers.GetDraft)
 			drafts.PUT("/:id", draftHandlers.UpdateDraft)
 			drafts.DELETE("/:id", draftHandlers.DeleteDraft)
+			drafts.POST("/:id/resources", draftHandlers.AddResourceToDraft)
+			drafts.DELETE("/:id/resources/:resourceId", draftHandlers.RemoveResourceFromDraft)
+			drafts.GET("/:id/revisions", draftHandlers.ListRevisions)
+			drafts.GET("/:id/revisions/:number", draftHandlers.GetRevision)
+			drafts.POST("/:id/revisions/:number/restore", draftHandlers.RestoreRevision)
+			drafts.GET("/:id/diff", draftHandlers.DiffRevisions)
 		}
 
 		// Resource routes
 		resources := api.Group("/resources")
 		{
 			resources.GET("", resourceHandlers.ListResources)
+			resources.GET("/facets", resourceHandlers.GetFacets)
 			resources.POST("", resourceHandlers.CreateResource)
+			resources.POST("/dedupe", resourceHandlers.Dedupe)
 			resources.GET("/:id", resourceHandlers.GetResource)
 			resources.PUT("/:id", resourceHandlers.UpdateResource)
 			resources.DELETE("/:id", resourceHandlers.DeleteResource)
+			resources.GET("/:id/references", resourceHandlers.GetReferences)
+			resources.POST("/:id/metadata", resourceHandlers.RefreshMetadata)
+			resources.POST("/:id/health", resourceHandlers.CheckHealth)
+			resources.GET("/:id/archive", resourceHandlers.GetArchive)
+			resources.POST("/:id/archive", resourceHandlers.ArchivePage)
+			resources.GET("/:id/annotations", resourceHandlers.ListAnnotations)
+			resources.POST("/:id/annotations", resourceHandlers.CreateAnnotation)
+			resources.GET("/:id/annotations/:annotationId", resourceHandlers.GetAnnotation)
+			resources.PUT("/:id/annotations/:annotationId", resourceHandlers.UpdateAnnotation)
+			resources.DELETE("/:id/annotations/:annotationId", resourceHandlers.DeleteAnnotation)
 		}
+
+		// Idea routes
+		ideas := api.Group("/ideas")
+		{
+			ideas.GET("", ideaHandlers.ListIdeas)
+			ideas.POST("", ideaHandlers.CreateIdea)
+			ideas.GET("/:id", ideaHandlers.GetIdea)
+			ideas.PUT("/:id", ideaHandlers.UpdateIdea)
+			ideas.DELETE("/:id", ideaHandlers.DeleteIdea)
+		}
+
+		// Chat routes
+		chat := api.Group("/chat")
+		{
+			chat.GET("/sessions", chatHandlers.ListSessions)
+			chat.POST("/sessions", chatHandlers.CreateSession)
+			chat.GET("/sessions/:id", chatHandlers.GetSession)
+			chat.POST("/sessions/:id/messages", chatHandlers.SendMessage)
+			chat.GET("/sessions/:id/messages/:messageId", chatHandlers.GetMessage)
+			chat.POST("/sessions/:id/deactivate", chatHandlers.DeactivateSession)
+		}
+
+		// Trash routes
+		trash := api.Group("/trash")
+		{
+			trash.GET("", trashHandlers.ListTrash)
+			trash.DELETE("", trashHandlers.EmptyTrash)
+			trash.POST("/drafts/:id/restore", trashHandlers.RestoreDraft)
+			trash.DELETE("/drafts/:id", trashHandlers.PurgeDraft)
+			trash.POST("/resources/:id/restore", trashHandlers.RestoreResource)
+			trash.DELETE("/resources/:id", trashHandlers.PurgeResource)
+		}
+
+		api.GET("/search", searchHandlers.Search)
 	}
 
 	return router
@@ -285,3 +355,1055 @@ func TestCORSPreflight(t *testing.T) {
 		t.Error("Expected CORS headers to be set")
 	}
 }
+
+func TestUpdateMissingResourceReturnsErrorEnvelope(t *testing.T) {
+	router := setupTestRouter()
+
+	payload := map[string]interface{}{
+		"title": "Updated Resource",
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req := httptest.NewRequest("PUT", "/api/resources/"+uuid.New().String(), bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 404 {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	var response api.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Failed to parse response: %v", err)
+	}
+
+	if response.Error.Code != api.CodeNotFound {
+		t.Errorf("Expected code '%s', got %s", api.CodeNotFound, response.Error.Code)
+	}
+
+	if response.Error.Message != "resource not found" {
+		t.Errorf("Expected message 'resource not found', got %s", response.Error.Message)
+	}
+}
+
+func TestCreateDraftWithMissingTitleReturnsInvalidRequest(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest("POST", "/api/drafts", bytes.NewBufferString(`{"content": "no title"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response api.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if w.Code != 400 || response.Error.Code != api.CodeInvalidRequest {
+		t.Errorf("Expected 400 %s, got %d %s", api.CodeInvalidRequest, w.Code, response.Error.Code)
+	}
+}
+
+func TestCreateAndFilterIdeas(t *testing.T) {
+	router := setupTestRouter()
+
+	payloads := []map[string]interface{}{
+		{"title": "Idea about AI", "confidence": 0.8, "tags": []string{"ai"}},
+		{"title": "Idea about writing", "confidence": 0.4, "tags": []string{"writing"}},
+	}
+
+	for _, payload := range payloads {
+		jsonData, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/api/ideas", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != 201 {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/ideas?tag=ai", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Failed to parse response: %v", err)
+	}
+
+	ideas := response["ideas"].([]interface{})
+	if len(ideas) != 1 {
+		t.Fatalf("Expected 1 idea tagged 'ai', got %d", len(ideas))
+	}
+
+	idea := ideas[0].(map[string]interface{})
+	if idea["title"] != "Idea about AI" {
+		t.Errorf("Expected title 'Idea about AI', got %v", idea["title"])
+	}
+}
+
+func TestCreateIdeaWithInvalidConfidence(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest("POST", "/api/ideas", bytes.NewBufferString(`{"title": "Overconfident", "confidence": 1.5}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response api.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if w.Code != 400 || response.Error.Code != api.CodeValidation {
+		t.Errorf("Expected 400 %s, got %d %s", api.CodeValidation, w.Code, response.Error.Code)
+	}
+}
+
+func TestChatSessionConversation(t *testing.T) {
+	router := setupTestRouter()
+
+	// Create a draft to chat about
+	req := httptest.NewRequest("POST", "/api/drafts", bytes.NewBufferString(`{"title": "Chat Draft", "content": "Draft body"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var draftResponse struct {
+		Draft struct {
+			ID string `json:"id"`
+		} `json:"draft"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &draftResponse)
+
+	// Start a session
+	req = httptest.NewRequest("POST", "/api/chat/sessions", bytes.NewBufferString(`{"draftId": "`+draftResponse.Draft.ID+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 201 {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var sessionResponse struct {
+		Session struct {
+			ID       string `json:"id"`
+			Active   bool   `json:"active"`
+			Messages []struct {
+				Type    string `json:"type"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		} `json:"session"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &sessionResponse)
+	sessionID := sessionResponse.Session.ID
+
+	// Send a message and receive the reply
+	req = httptest.NewRequest("POST", "/api/chat/sessions/"+sessionID+"/messages", bytes.NewBufferString(`{"content": "Hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 201 {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	json.Unmarshal(w.Body.Bytes(), &sessionResponse)
+	messages := sessionResponse.Session.Messages
+	if len(messages) != 2 || messages[0].Type != "user" || messages[1].Type != "assistant" {
+		t.Fatalf("Expected user message and assistant reply, got %+v", messages)
+	}
+	if messages[1].Content != "Echo: Hello" {
+		t.Errorf("Expected echoed reply, got %q", messages[1].Content)
+	}
+
+	// Deactivated sessions reject new messages
+	req = httptest.NewRequest("POST", "/api/chat/sessions/"+sessionID+"/deactivate", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/api/chat/sessions/"+sessionID+"/messages", bytes.NewBufferString(`{"content": "Still there?"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var errorResponse api.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	if w.Code != 409 || errorResponse.Error.Code != api.CodeConflict {
+		t.Errorf("Expected 409 %s, got %d %s", api.CodeConflict, w.Code, errorResponse.Error.Code)
+	}
+}
+
+func TestCreateChatSessionForMissingDraft(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest("POST", "/api/chat/sessions", bytes.NewBufferString(`{"draftId": "`+uuid.New().String()+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 404 {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestStreamChatMessage(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest("POST", "/api/drafts", bytes.NewBufferString(`{"title": "Stream Draft"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var draftResponse struct {
+		Draft struct {
+			ID string `json:"id"`
+		} `json:"draft"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &draftResponse)
+
+	req = httptest.NewRequest("POST", "/api/chat/sessions", bytes.NewBufferString(`{"draftId": "`+draftResponse.Draft.ID+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var sessionResponse struct {
+		Session struct {
+			ID string `json:"id"`
+		} `json:"session"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &sessionResponse)
+
+	req = httptest.NewRequest("POST", "/api/chat/sessions/"+sessionResponse.Session.ID+"/messages", bytes.NewBufferString(`{"content": "Hello there"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Errorf("Expected an event stream, got %q", contentType)
+	}
+
+	body := w.Body.String()
+	if strings.Count(body, "event:delta") != 3 {
+		t.Errorf("Expected 3 delta events, got %q", body)
+	}
+	if !strings.Contains(body, "event:done") || !strings.Contains(body, "Echo: Hello there") {
+		t.Errorf("Expected a done event with the stored reply, got %q", body)
+	}
+
+	// Errors before the stream starts use the regular envelope
+	req = httptest.NewRequest("POST", "/api/chat/sessions/missing/messages", bytes.NewBufferString(`{"content": "Hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 404 {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestDraftRevisionHistory(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest("POST", "/api/drafts", bytes.NewBufferString(`{"title": "History", "content": "Keep this"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var draftResponse struct {
+		Draft struct {
+			ID      string `json:"id"`
+			Content string `json:"content"`
+		} `json:"draft"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &draftResponse)
+	draftID := draftResponse.Draft.ID
+
+	req = httptest.NewRequest("PUT", "/api/drafts/"+draftID, bytes.NewBufferString(`{"title": "History", "content": "Overwritten", "source": "assistant"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Diff the two revisions word by word
+	req = httptest.NewRequest("GET", "/api/drafts/"+draftID+"/diff?from=1&to=2&mode=word", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var diffResponse struct {
+		Diff struct {
+			Content struct {
+				Insertions int `json:"insertions"`
+				Deletions  int `json:"deletions"`
+			} `json:"content"`
+		} `json:"diff"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &diffResponse)
+	if w.Code != 200 || diffResponse.Diff.Content.Insertions != 1 || diffResponse.Diff.Content.Deletions != 2 {
+		t.Errorf("Expected +1 -2 words, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Restore the first revision
+	req = httptest.NewRequest("POST", "/api/drafts/"+draftID+"/revisions/1/restore", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	json.Unmarshal(w.Body.Bytes(), &draftResponse)
+	if w.Code != 200 || draftResponse.Draft.Content != "Keep this" {
+		t.Errorf("Expected restored content, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/drafts/"+draftID+"/revisions", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var revisionsResponse struct {
+		Revisions []struct {
+			Number       int    `json:"number"`
+			Source       string `json:"source"`
+			RestoredFrom int    `json:"restoredFrom"`
+		} `json:"revisions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &revisionsResponse)
+	revisions := revisionsResponse.Revisions
+	if len(revisions) != 3 || revisions[1].Source != "assistant" || revisions[2].RestoredFrom != 1 {
+		t.Errorf("Expected 3 revisions ending with the restore, got %+v", revisions)
+	}
+
+	req = httptest.NewRequest("GET", "/api/drafts/"+draftID+"/revisions/abc", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 400 {
+		t.Errorf("Expected status 400 for a malformed revision number, got %d", w.Code)
+	}
+}
+
+func TestUpdateDraftRequiresCurrentETag(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest("POST", "/api/drafts", bytes.NewBufferString(`{"title": "Shared", "content": "Original"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var draftResponse struct {
+		Draft struct {
+			ID      string `json:"id"`
+			Version int    `json:"version"`
+		} `json:"draft"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &draftResponse)
+	draftID := draftResponse.Draft.ID
+	etag := w.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("Expected ETag \"1\" for a new draft, got %q", etag)
+	}
+
+	update := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/drafts/"+draftID, bytes.NewBufferString(`{"title": "Shared", "content": "Edited"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := update(""); w.Code != 428 {
+		t.Errorf("Expected status 428 without If-Match, got %d", w.Code)
+	}
+	// If-Match compares strongly, so a weak form of the current ETag fails
+	if w := update("W/" + etag); w.Code != 412 {
+		t.Errorf("Expected status 412 for a weak ETag, got %d", w.Code)
+	}
+
+	w = update(etag)
+	if w.Code != 200 || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected status 200 with ETag \"2\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	// Saving again with the old ETag is rejected with the current copy
+	w = update(etag)
+	if w.Code != 412 {
+		t.Fatalf("Expected status 412 for a stale ETag, got %d", w.Code)
+	}
+
+	var errorResponse struct {
+		Error   api.ErrorBody `json:"error"`
+		Current struct {
+			Content string `json:"content"`
+			Version int    `json:"version"`
+		} `json:"current"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	if errorResponse.Error.Code != api.CodePreconditionFailed {
+		t.Errorf("Expected code '%s', got %s", api.CodePreconditionFailed, errorResponse.Error.Code)
+	}
+	if errorResponse.Current.Content != "Edited" || errorResponse.Current.Version != 2 {
+		t.Errorf("Expected the current draft at version 2, got %+v", errorResponse.Current)
+	}
+}
+
+func TestDeleteReferencedResource(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var draftResponse struct {
+		Draft struct {
+			ID        string   `json:"id"`
+			Resources []string `json:"resources"`
+		} `json:"draft"`
+	}
+	json.Unmarshal(send("POST", "/api/drafts", `{"title": "Linked", "content": "Content"}`).Body.Bytes(), &draftResponse)
+	draftID := draftResponse.Draft.ID
+
+	var resourceResponse struct {
+		Resource struct {
+			ID string `json:"id"`
+		} `json:"resource"`
+	}
+	json.Unmarshal(send("POST", "/api/resources", `{"url": "https://example.com", "title": "Source", "type": "link"}`).Body.Bytes(), &resourceResponse)
+	resourceID := resourceResponse.Resource.ID
+
+	send("POST", "/api/drafts/"+draftID+"/resources", `{"resourceId": "`+resourceID+`"}`)
+
+	// Refusing lists what still links to the resource
+	w := send("DELETE", "/api/resources/"+resourceID+"?references=refuse", "")
+	if w.Code != 409 {
+		t.Fatalf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var errorResponse struct {
+		Error      api.ErrorBody `json:"error"`
+		References struct {
+			Drafts []struct {
+				ID string `json:"id"`
+			} `json:"drafts"`
+		} `json:"references"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	if errorResponse.Error.Code != api.CodeConflict || len(errorResponse.References.Drafts) != 1 || errorResponse.References.Drafts[0].ID != draftID {
+		t.Errorf("Expected a conflict listing the draft, got %s", w.Body.String())
+	}
+
+	// The default unlinks the resource from the draft
+	if w := send("DELETE", "/api/resources/"+resourceID, ""); w.Code != 204 {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+
+	json.Unmarshal(send("GET", "/api/drafts/"+draftID, "").Body.Bytes(), &draftResponse)
+	if len(draftResponse.Draft.Resources) != 0 {
+		t.Errorf("Expected the draft to have no resources, got %v", draftResponse.Draft.Resources)
+	}
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var draftResponse struct {
+		Draft struct {
+			ID string `json:"id"`
+		} `json:"draft"`
+	}
+	json.Unmarshal(send("POST", "/api/drafts", `{"title": "Disposable", "content": "Content"}`).Body.Bytes(), &draftResponse)
+	draftID := draftResponse.Draft.ID
+
+	if w := send("DELETE", "/api/drafts/"+draftID, ""); w.Code != 204 {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := send("GET", "/api/drafts/"+draftID, ""); w.Code != 404 {
+		t.Errorf("Expected status 404 for a trashed draft, got %d", w.Code)
+	}
+
+	var trashResponse struct {
+		Trash struct {
+			Drafts []struct {
+				ID        string `json:"id"`
+				DeletedAt string `json:"deletedAt"`
+			} `json:"drafts"`
+		} `json:"trash"`
+	}
+	json.Unmarshal(send("GET", "/api/trash", "").Body.Bytes(), &trashResponse)
+	if len(trashResponse.Trash.Drafts) != 1 || trashResponse.Trash.Drafts[0].DeletedAt == "" {
+		t.Fatalf("Expected the draft in the trash, got %+v", trashResponse.Trash.Drafts)
+	}
+
+	if w := send("POST", "/api/trash/drafts/"+draftID+"/restore", ""); w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("GET", "/api/drafts/"+draftID, ""); w.Code != 200 {
+		t.Errorf("Expected status 200 for a restored draft, got %d", w.Code)
+	}
+
+	// Purging only applies to trashed drafts and is permanent
+	if w := send("DELETE", "/api/trash/drafts/"+draftID, ""); w.Code != 404 {
+		t.Errorf("Expected status 404 purging a draft outside the trash, got %d", w.Code)
+	}
+	send("DELETE", "/api/drafts/"+draftID, "")
+	if w := send("DELETE", "/api/trash/drafts/"+draftID, ""); w.Code != 204 {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("POST", "/api/trash/drafts/"+draftID+"/restore", ""); w.Code != 404 {
+		t.Errorf("Expected status 404 restoring a purged draft, got %d", w.Code)
+	}
+}
+
+func TestListResourcesPaging(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, title := range []string{"Charlie", "Alpha", "Echo", "Bravo", "Delta"} {
+		body := `{"url": "https://example.com/` + title + `", "title": "` + title + `", "type": "link", "tags": ["paging"]}`
+		if w := send("POST", "/api/resources", body); w.Code != 201 {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	var titles []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		w := send("GET", "/api/resources?limit=2&sort=title&tag=paging&cursor="+cursor, "")
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Resources []struct {
+				Title string `json:"title"`
+			} `json:"resources"`
+			NextCursor string `json:"nextCursor"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		for _, resource := range response.Resources {
+			titles = append(titles, resource.Title)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		cursor = response.NextCursor
+	}
+
+	if strings.Join(titles, ",") != "Alpha,Bravo,Charlie,Delta,Echo" {
+		t.Errorf("Expected every resource A to Z across pages, got %v", titles)
+	}
+
+	if w := send("GET", "/api/resources?limit=500", ""); w.Code != 400 {
+		t.Errorf("Expected status 400 for an oversized page, got %d", w.Code)
+	}
+	if w := send("GET", "/api/resources?cursor=bogus", ""); w.Code != 400 {
+		t.Errorf("Expected status 400 for a malformed cursor, got %d", w.Code)
+	}
+	if w := send("GET", "/api/drafts?createdAfter=yesterday", ""); w.Code != 400 {
+		t.Errorf("Expected status 400 for a malformed date, got %d", w.Code)
+	}
+}
+
+func TestFilterResourcesAndFacets(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	send("POST", "/api/resources", `{"url": "https://go.dev/blog", "title": "Go Blog", "type": "blog", "category": "go", "tags": ["go"]}`)
+	send("POST", "/api/resources", `{"url": "https://example.com/go", "title": "Go Link", "type": "link", "category": "go", "tags": ["go", "links"]}`)
+	send("POST", "/api/resources", `{"url": "https://example.com/rust", "title": "Rust Link", "type": "link", "category": "rust"}`)
+
+	var listResponse struct {
+		Resources []struct {
+			Title string `json:"title"`
+		} `json:"resources"`
+	}
+	w := send("GET", "/api/resources?type=link&category=go", "")
+	json.Unmarshal(w.Body.Bytes(), &listResponse)
+	if len(listResponse.Resources) != 1 || listResponse.Resources[0].Title != "Go Link" {
+		t.Errorf("Expected only the Go link, got %+v", listResponse.Resources)
+	}
+
+	w = send("GET", "/api/resources?domain=example.com&q=rust", "")
+	json.Unmarshal(w.Body.Bytes(), &listResponse)
+	if len(listResponse.Resources) != 1 || listResponse.Resources[0].Title != "Rust Link" {
+		t.Errorf("Expected only the Rust link, got %+v", listResponse.Resources)
+	}
+
+	w = send("GET", "/api/resources/facets?type=link", "")
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var facetsResponse struct {
+		Facets struct {
+			Total      int `json:"total"`
+			Categories []struct {
+				Value string `json:"value"`
+				Count int    `json:"count"`
+			} `json:"categories"`
+		} `json:"facets"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &facetsResponse)
+	if facetsResponse.Facets.Total != 2 || len(facetsResponse.Facets.Categories) != 2 {
+		t.Errorf("Expected facets of the 2 links in 2 categories, got %+v", facetsResponse.Facets)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	send("POST", "/api/drafts", `{"title": "Picking a vector database", "content": "Notes on embeddings"}`)
+	send("POST", "/api/resources", `{"url": "https://example.com/vectors", "title": "Vector databases compared", "type": "link"}`)
+	send("POST", "/api/resources", `{"url": "https://example.com/sql", "title": "Relational databases", "type": "link"}`)
+
+	w := send("GET", "/api/search?q=vector+databases", "")
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Results struct {
+			Drafts    []json.RawMessage `json:"drafts"`
+			Resources []struct {
+				Title      string `json:"title"`
+				Highlights []struct {
+					Field     string `json:"field"`
+					Fragments []struct {
+						Text  string `json:"text"`
+						Match bool   `json:"match"`
+					} `json:"fragments"`
+				} `json:"highlights"`
+			} `json:"resources"`
+			Totals map[string]int `json:"totals"`
+		} `json:"results"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if len(response.Results.Resources) != 1 || response.Results.Resources[0].Title != "Vector databases compared" {
+		t.Fatalf("Expected only the resource with both words, got %+v", response.Results.Resources)
+	}
+	highlights := response.Results.Resources[0].Highlights
+	if len(highlights) == 0 || highlights[0].Field != "title" || !highlights[0].Fragments[0].Match {
+		t.Errorf("Expected the title highlighted, got %+v", highlights)
+	}
+	if len(response.Results.Drafts) != 1 || response.Results.Totals["resource"] != 1 {
+		t.Errorf("Expected the draft (\"database\" stems like \"databases\") and 1 resource, got %s", w.Body.String())
+	}
+
+	if w := send("GET", "/api/search", ""); w.Code != 400 {
+		t.Errorf("Expected status 400 without a query, got %d", w.Code)
+	}
+}
+
+func TestResourceMetadataFromPage(t *testing.T) {
+	router := setupTestRouter()
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Vector databases compared | Example</title>
+			<meta property="og:title" content="Vector databases compared">
+			<meta name="description" content="Milvus, Qdrant and pgvector side by side">
+			<link rel="canonical" href="/vectors"></head><body></body></html>`))
+	}))
+	defer page.Close()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var response struct {
+		Resource models.CollectedResource `json:"resource"`
+	}
+	w := send("POST", "/api/resources", `{"url": "`+page.URL+`/vectors?utm_source=feed", "type": "link"}`)
+	if w.Code != 201 {
+		t.Fatalf("Expected status 201 without a title, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Resource.Metadata.Status != models.MetadataPending {
+		t.Errorf("Expected the metadata to be pending, got %+v", response.Resource.Metadata)
+	}
+
+	w = send("POST", "/api/resources/"+response.Resource.ID+"/metadata", "")
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	resource := response.Resource
+	if resource.Title != "Vector databases compared" || resource.Description != "Milvus, Qdrant and pgvector side by side" {
+		t.Errorf("Expected the title and description from the page, got %q and %q", resource.Title, resource.Description)
+	}
+	if resource.Metadata.Status != models.MetadataFetched || resource.Metadata.CanonicalURL != page.URL+"/vectors" {
+		t.Errorf("Expected the canonical URL, got %+v", resource.Metadata)
+	}
+
+	if w := send("POST", "/api/resources/missing/metadata", ""); w.Code != 404 {
+		t.Errorf("Expected status 404 for an unknown resource, got %d", w.Code)
+	}
+}
+
+func TestDuplicateResources(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var response struct {
+		Resource models.CollectedResource `json:"resource"`
+	}
+	w := send("POST", "/api/resources", `{"url": "https://example.com/post/?utm_source=feed", "title": "Post", "tags": ["go"]}`)
+	if w.Code != 201 {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	original := response.Resource
+	if original.URL != "https://example.com/post/?utm_source=feed" {
+		t.Errorf("Expected the URL as given, got %q", original.URL)
+	}
+
+	// The same link again is rejected with the existing resource
+	w = send("POST", "/api/resources", `{"url": "https://m.example.com/post#top", "title": "Post"}`)
+	if w.Code != 409 {
+		t.Fatalf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var errorResponse struct {
+		Error    api.ErrorBody             `json:"error"`
+		Existing *models.CollectedResource `json:"existing"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	if errorResponse.Error.Code != api.CodeDuplicate || errorResponse.Existing == nil || errorResponse.Existing.ID != original.ID {
+		t.Errorf("Expected a duplicate error with the existing resource, got %s", w.Body.String())
+	}
+
+	// or merged into it
+	w = send("POST", "/api/resources?duplicates=merge", `{"url": "http://example.com/post", "title": "Post", "tags": ["testing"]}`)
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200 for a merge, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Resource.ID != original.ID || len(response.Resource.Tags) != 2 {
+		t.Errorf("Expected the tags merged into the existing resource, got %+v", response.Resource)
+	}
+
+	// or collected anyway, and merged later
+	if w := send("POST", "/api/resources?duplicates=allow", `{"url": "https://example.com/post", "title": "Post", "category": "Go"}`); w.Code != 201 {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w = send("POST", "/api/resources/dedupe", "")
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var dedupeResponse struct {
+		Dedupe services.DedupeResult `json:"dedupe"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &dedupeResponse)
+	if dedupeResponse.Dedupe.Merged != 1 || dedupeResponse.Dedupe.Groups[0].Kept.Category != "Go" {
+		t.Errorf("Expected one duplicate merged into the original, got %s", w.Body.String())
+	}
+
+	if w := send("POST", "/api/resources?duplicates=skip", `{"url": "https://example.com/new", "title": "New"}`); w.Code != 400 {
+		t.Errorf("Expected status 400 for an unknown policy, got %d", w.Code)
+	}
+}
+
+func TestResourceLinkHealth(t *testing.T) {
+	router := setupTestRouter()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var response struct {
+		Resource models.CollectedResource `json:"resource"`
+	}
+	var etag string
+	for _, path := range []string{"/missing", "/fine"} {
+		created := send("POST", "/api/resources", `{"url": "`+site.URL+path+`", "title": "Page `+path+`"}`)
+		json.Unmarshal(created.Body.Bytes(), &response)
+		etag = created.Header().Get("ETag")
+		if response.Resource.Health.Status != "" {
+			t.Errorf("Expected a new resource to be unchecked, got %+v", response.Resource.Health)
+		}
+
+		w := send("POST", "/api/resources/"+response.Resource.ID+"/health", "")
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	json.Unmarshal(send("GET", "/api/resources/"+response.Resource.ID, "").Body.Bytes(), &response)
+	if response.Resource.Health.Status != models.HealthOK || response.Resource.Health.StatusCode != 200 {
+		t.Errorf("Expected the working link recorded, got %+v", response.Resource.Health)
+	}
+
+	// Checks are not edits, so the ETag from before the check still applies
+	req := httptest.NewRequest("PUT", "/api/resources/"+response.Resource.ID, bytes.NewBufferString(`{"title": "Fine page", "type": "link"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("Expected an edit based on the ETag from before the check to apply, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var listResponse struct {
+		Resources []models.CollectedResource `json:"resources"`
+	}
+	w = send("GET", "/api/resources?health=broken", "")
+	json.Unmarshal(w.Body.Bytes(), &listResponse)
+	if len(listResponse.Resources) != 1 || listResponse.Resources[0].Health.StatusCode != 404 {
+		t.Errorf("Expected only the missing page listed as broken, got %s", w.Body.String())
+	}
+
+	if w := send("GET", "/api/resources?health=dead", ""); w.Code != 400 {
+		t.Errorf("Expected status 400 for an unknown health, got %d", w.Code)
+	}
+}
+
+func TestResourceArchive(t *testing.T) {
+	router := setupTestRouter()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Archived post</title></head><body><nav>Menu</nav>` +
+			`<article><p>Archived pages keep their text, even after the original site goes away.</p></article>` +
+			`<script>alert(1)</script></body></html>`))
+	}))
+	defer site.Close()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var response struct {
+		Resource models.CollectedResource `json:"resource"`
+	}
+	json.Unmarshal(send("POST", "/api/resources", `{"url": "`+site.URL+`/post", "title": "Post"}`).Body.Bytes(), &response)
+	id := response.Resource.ID
+
+	if w := send("GET", "/api/resources/"+id+"/archive", ""); w.Code != 404 {
+		t.Errorf("Expected status 404 before the page is archived, got %d", w.Code)
+	}
+
+	w := send("POST", "/api/resources/"+id+"/archive", "")
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var archiveResponse struct {
+		Archive models.ResourceArchive `json:"archive"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &archiveResponse)
+	if archiveResponse.Archive.Text != "Archived pages keep their text, even after the original site goes away." || archiveResponse.Archive.HTML != "" {
+		t.Errorf("Expected the readable text without the raw page, got %+v", archiveResponse.Archive)
+	}
+
+	w = send("GET", "/api/resources/"+id+"/archive?format=html", "")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<script>") || w.Header().Get("Content-Security-Policy") != "sandbox" {
+		t.Errorf("Expected the sandboxed raw page, got %d with %v", w.Code, w.Header())
+	}
+	w = send("GET", "/api/resources/"+id+"/archive?format=text", "")
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") || !strings.HasPrefix(w.Body.String(), "Archived pages") {
+		t.Errorf("Expected the plain text, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("GET", "/api/resources/"+id+"/archive?format=pdf", ""); w.Code != 400 {
+		t.Errorf("Expected status 400 for an unknown format, got %d", w.Code)
+	}
+
+	json.Unmarshal(send("POST", "/api/resources", `{"url": "`+site.URL+`/missing", "title": "Missing"}`).Body.Bytes(), &response)
+	w = send("POST", "/api/resources/"+response.Resource.ID+"/archive", "")
+	if w.Code != 502 || !strings.Contains(w.Body.String(), `"fetch_failed"`) {
+		t.Errorf("Expected status 502 for a page that cannot be fetched, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateNote(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/api/resources", `{"type": "note", "body": "# Ship small\nSmall changes are easier to review.",
+		"source": {"kind": "person", "name": "A colleague"}, "tags": ["process"]}`)
+	if w.Code != 201 {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Resource models.CollectedResource `json:"resource"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	note := response.Resource
+	if note.Title != "Ship small" || note.URL != "" || note.Source == nil || note.Source.Kind != models.SourcePerson {
+		t.Errorf("Expected a note titled from its body, got %+v", note)
+	}
+
+	req := httptest.NewRequest("PUT", "/api/resources/"+note.ID, bytes.NewBufferString(`{"type": "note", "title": "Ship small changes", "body": "Reviews go faster."}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	response.Resource = models.CollectedResource{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != 200 || response.Resource.Body != "Reviews go faster." || response.Resource.Source != nil {
+		t.Errorf("Expected the note updated, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"type": "note", "title": "Empty"}`,
+		`{"type": "note", "body": "Text", "source": {"kind": "tweet", "name": "x"}}`,
+		`{"type": "link", "title": "No URL"}`,
+		`{"type": "link", "url": "https://example.com", "title": "Link", "body": "Only notes have bodies"}`,
+	} {
+		if w := send("POST", "/api/resources", body); w.Code != 400 {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestResourceAnnotations(t *testing.T) {
+	router := setupTestRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/api/resources", `{"url": "https://example.com/post", "title": "Post", "type": "blog"}`)
+	var created struct {
+		Resource models.CollectedResource `json:"resource"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/api/resources/" + created.Resource.ID + "/annotations"
+
+	w = send("POST", path, `{"quote": "Small changes are easier to review.", "selector": {"start": 10, "end": 45, "prefix": "# Ship small\n"},
+		"note": "True for our team", "tags": ["process"]}`)
+	if w.Code != 201 {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Annotation models.Annotation `json:"annotation"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	annotation := response.Annotation
+	if annotation.ResourceID != created.Resource.ID || annotation.Selector == nil || annotation.Selector.End != 45 || annotation.CreatedAt.IsZero() {
+		t.Errorf("Expected the annotation created on the resource, got %+v", annotation)
+	}
+
+	w = send("PUT", path+"/"+annotation.ID, `{"quote": "Small changes are easier to review.", "note": "And to revert"}`)
+	response.Annotation = models.Annotation{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != 200 || response.Annotation.Note != "And to revert" || response.Annotation.Selector != nil {
+		t.Errorf("Expected the annotation updated, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = send("GET", path, "")
+	var list struct {
+		Annotations []models.Annotation `json:"annotations"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != 200 || len(list.Annotations) != 1 || list.Annotations[0].ID != annotation.ID {
+		t.Errorf("Expected the annotation listed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{`{"note": "No quote"}`, `{"quote": "Text", "selector": {"start": 5, "end": 2}}`} {
+		if w := send("POST", path, body); w.Code != 400 {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+	if w := send("GET", "/api/resources/missing/annotations/"+annotation.ID, ""); w.Code != 404 {
+		t.Errorf("Expected status 404 under another resource, got %d", w.Code)
+	}
+
+	if w := send("DELETE", path+"/"+annotation.ID, ""); w.Code != 204 {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if w := send("GET", path+"/"+annotation.ID, ""); w.Code != 404 {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
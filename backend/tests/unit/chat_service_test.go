@@ -0,0 +1,283 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func setupChat(t *testing.T, provider llm.Provider) (*services.ChatService, *models.BlogDraft, *models.CollectedResource) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+
+	draft, _ := draftService.CreateDraft("Vector Databases", "Embeddings everywhere.", []string{"ai"})
+	resource, _ := resourceService.CreateResource("https://example.com/ann", "Approximate nearest neighbours", "HNSW explained", models.ResourceTypeBlog, "research", nil)
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+
+	return services.NewChatService(store, provider), draft, resource
+}
+
+func TestChatService_SendMessage(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider("Try comparing HNSW with IVF.")
+	chatService, draft, resource := setupChat(t, provider)
+
+	session, err := chatService.CreateSession(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	session, err = chatService.SendMessage(context.Background(), session.ID, "What should I add?")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(session.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(session.Messages))
+	}
+
+	reply := session.Messages[1]
+	if reply.Type != models.MessageTypeAssistant || reply.Content != "Try comparing HNSW with IVF." {
+		t.Errorf("Expected assistant reply, got %+v", reply)
+	}
+
+	// The model sees the draft and its resources
+	system := provider.Requests()[0].Messages[0].Content
+	for _, want := range []string{"Vector Databases", "Embeddings everywhere.", resource.ID, "HNSW explained"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("Expected context to contain %q", want)
+		}
+	}
+
+	// Earlier turns are sent along with the next question
+	chatService.SendMessage(context.Background(), session.ID, "Anything else?")
+	second := provider.Requests()[1].Messages
+	if len(second) != 4 || second[1].Content != "What should I add?" || second[2].Role != llm.RoleAssistant {
+		t.Errorf("Expected history in second request, got %+v", second)
+	}
+
+	stored, _ := chatService.GetSession(session.ID)
+	if len(stored.Messages) != 4 {
+		t.Errorf("Expected 4 stored messages, got %d", len(stored.Messages))
+	}
+}
+
+func TestChatService_SendMessageStoresErrorOnModelFailure(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider()
+	provider.SetError(errors.New("connection refused"))
+	chatService, draft, _ := setupChat(t, provider)
+
+	session, _ := chatService.CreateSession(draft.ID)
+
+	_, err := chatService.SendMessage(context.Background(), session.ID, "Hello?")
+	if !errors.Is(err, services.ErrModelUnavailable) {
+		t.Fatalf("Expected ErrModelUnavailable, got %v", err)
+	}
+
+	stored, _ := chatService.GetSession(session.ID)
+	if len(stored.Messages) != 2 || stored.Messages[1].Type != models.MessageTypeError {
+		t.Fatalf("Expected user message followed by an error message, got %+v", stored.Messages)
+	}
+
+	// Error messages are not replayed to the model
+	provider.SetError(nil)
+	chatService.SendMessage(context.Background(), session.ID, "Again")
+	requests := provider.Requests()
+	for _, message := range requests[len(requests)-1].Messages {
+		if message.Role == llm.RoleAssistant {
+			t.Errorf("Expected no assistant history, got %q", message.Content)
+		}
+	}
+}
+
+func TestChatService_WithoutProvider(t *testing.T) {
+	// Setup
+	chatService, draft, _ := setupChat(t, nil)
+
+	session, err := chatService.CreateSession(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected sessions to work without a model, got %v", err)
+	}
+
+	_, err = chatService.SendMessage(context.Background(), session.ID, "Hello")
+	if !errors.Is(err, services.ErrNotConfigured) {
+		t.Errorf("Expected ErrNotConfigured, got %v", err)
+	}
+}
+
+func TestChatService_ListAndDeactivateSessions(t *testing.T) {
+	// Setup
+	chatService, draft, _ := setupChat(t, llm.NewFakeProvider())
+
+	first, _ := chatService.CreateSession(draft.ID)
+	second, _ := chatService.CreateSession(draft.ID)
+
+	if _, err := chatService.DeactivateSession(first.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	all, _ := chatService.ListSessions(draft.ID, false)
+	if len(all) != 2 {
+		t.Errorf("Expected 2 sessions, got %d", len(all))
+	}
+
+	active, _ := chatService.ListSessions(draft.ID, true)
+	if len(active) != 1 || active[0].ID != second.ID {
+		t.Errorf("Expected only the second session to be active, got %v", active)
+	}
+
+	_, err := chatService.SendMessage(context.Background(), first.ID, "Hello")
+	if !errors.Is(err, services.ErrConflict) {
+		t.Errorf("Expected ErrConflict for an inactive session, got %v", err)
+	}
+
+	if _, err := chatService.CreateSession("missing"); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing draft, got %v", err)
+	}
+}
+
+func TestChatService_StreamMessage(t *testing.T) {
+	// Setup
+	chatService, draft, _ := setupChat(t, llm.NewFakeProvider("Start with a benchmark."))
+	session, _ := chatService.CreateSession(draft.ID)
+
+	var deltas []string
+	session, err := chatService.StreamMessage(context.Background(), session.ID, "Ideas?", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(deltas) != 4 || strings.Join(deltas, "") != "Start with a benchmark." {
+		t.Errorf("Expected the reply word by word, got %q", deltas)
+	}
+
+	stored, _ := chatService.GetSession(session.ID)
+	if len(stored.Messages) != 2 || stored.Messages[1].Content != "Start with a benchmark." {
+		t.Errorf("Expected the full reply to be stored, got %+v", stored.Messages)
+	}
+}
+
+func TestChatService_StreamMessageKeepsPartialReplyOnDisconnect(t *testing.T) {
+	// Setup
+	chatService, draft, _ := setupChat(t, llm.NewFakeProvider("One two three four."))
+	session, _ := chatService.CreateSession(draft.ID)
+
+	disconnected := errors.New("client disconnected")
+	received := 0
+	_, err := chatService.StreamMessage(context.Background(), session.ID, "Count", func(delta string) error {
+		received++
+		if received == 2 {
+			return disconnected
+		}
+		return nil
+	})
+	if !errors.Is(err, disconnected) {
+		t.Fatalf("Expected the disconnect error, got %v", err)
+	}
+
+	stored, _ := chatService.GetSession(session.ID)
+	if len(stored.Messages) != 2 {
+		t.Fatalf("Expected user message and partial reply, got %+v", stored.Messages)
+	}
+	if reply := stored.Messages[1]; reply.Type != models.MessageTypeAssistant || reply.Content != "One two " {
+		t.Errorf("Expected the partial reply, got %+v", reply)
+	}
+}
+
+func TestChatService_StreamMessageKeepsConcurrentChanges(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider("First reply in words.", "Second reply.", "Late reply.")
+	chatService, draft, _ := setupChat(t, provider)
+	session, _ := chatService.CreateSession(draft.ID)
+
+	// A second message is sent while the first reply streams
+	sentSecond := false
+	_, err := chatService.StreamMessage(context.Background(), session.ID, "First?", func(delta string) error {
+		if !sentSecond {
+			sentSecond = true
+			if _, err := chatService.SendMessage(context.Background(), session.ID, "Second?"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stored, _ := chatService.GetSession(session.ID)
+	if len(stored.Messages) != 4 {
+		t.Fatalf("Expected both questions and both replies, got %+v", stored.Messages)
+	}
+	first, second := stored.Messages[0], stored.Messages[1]
+	if first.Content != "First?" || second.Content != "Second?" || stored.Messages[2].Content != "Second reply." {
+		t.Errorf("Expected the messages in the order they were stored, got %+v", stored.Messages)
+	}
+	if reply := stored.Messages[3]; reply.Content != "First reply in words." || reply.ParentID != first.ID {
+		t.Errorf("Expected the first reply to answer the first question, got %+v", reply)
+	}
+
+	// A session closed while the reply streams is not reopened
+	_, err = chatService.StreamMessage(context.Background(), session.ID, "Last?", func(delta string) error {
+		_, err := chatService.DeactivateSession(session.ID)
+		return err
+	})
+	if !errors.Is(err, services.ErrConflict) {
+		t.Errorf("Expected the late reply to be refused, got %v", err)
+	}
+	stored, _ = chatService.GetSession(session.ID)
+	if stored.IsActive() || len(stored.Messages) != 5 {
+		t.Errorf("Expected the session to stay closed without the late reply, got %+v", stored)
+	}
+}
+
+func TestChatService_RecordsMessageMetadata(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider("Compare HNSW with IVF.")
+	chatService, draft, resource := setupChat(t, provider)
+	session, _ := chatService.CreateSession(draft.ID)
+
+	session, err := chatService.SendMessage(context.Background(), session.ID, "What next?")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	question, reply := session.Messages[0], session.Messages[1]
+	if question.ID == "" || reply.ID == "" || question.ID == reply.ID {
+		t.Fatalf("Expected distinct message IDs, got %q and %q", question.ID, reply.ID)
+	}
+
+	if reply.ParentID != question.ID {
+		t.Errorf("Expected reply to point at the question, got parent %q", reply.ParentID)
+	}
+
+	if reply.Model != provider.Model() || reply.CompletionTokens != 4 || reply.PromptTokens == 0 {
+		t.Errorf("Expected model and token usage, got %+v", reply)
+	}
+
+	if len(reply.ResourceIDs) != 1 || reply.ResourceIDs[0] != resource.ID {
+		t.Errorf("Expected the draft's resource to be referenced, got %v", reply.ResourceIDs)
+	}
+
+	found, err := chatService.GetMessage(session.ID, reply.ID)
+	if err != nil || found.Content != reply.Content {
+		t.Errorf("Expected to find the reply by ID, got %v %v", found, err)
+	}
+
+	if _, err := chatService.GetMessage(session.ID, "missing"); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
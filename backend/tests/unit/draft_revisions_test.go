@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/diff"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func TestDraftService_RecordsRevisions(t *testing.T) {
+	// Setup
+	service := services.NewDraftService(storage.NewMemoryStorage())
+
+	draft, _ := service.CreateDraft("Title", "First version", []string{"go"})
+	service.UpdateDraft(draft.ID, "Title", "Second version", []string{"go"}, models.RevisionSourceAssistant, services.AnyVersion)
+	// Saving identical text does not add a revision
+	service.UpdateDraft(draft.ID, "Title", "Second version", []string{"go"}, "", services.AnyVersion)
+
+	revisions, err := service.ListRevisions(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+
+	first, second := revisions[0], revisions[1]
+	if first.Number != 1 || first.Content != "First version" || first.Source != models.RevisionSourceUser {
+		t.Errorf("Unexpected first revision %+v", first)
+	}
+	if second.Number != 2 || second.Source != models.RevisionSourceAssistant {
+		t.Errorf("Unexpected second revision %+v", second)
+	}
+	if first.Hash == "" || first.Hash == second.Hash {
+		t.Errorf("Expected distinct content hashes, got %q and %q", first.Hash, second.Hash)
+	}
+
+	if _, err := service.UpdateDraft(draft.ID, "Title", "x", nil, models.RevisionSourceRestore, services.AnyVersion); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for a reserved source, got %v", err)
+	}
+}
+
+func TestDraftService_NumbersConcurrentRevisions(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			service := services.NewDraftService(store)
+			draft, _ := service.CreateDraft("Title", "Version 0", nil)
+
+			var wg sync.WaitGroup
+			for i := 1; i <= 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if _, err := service.UpdateDraft(draft.ID, "Title", fmt.Sprintf("Version %d", i), nil, "", services.AnyVersion); err != nil {
+						t.Errorf("Expected no error, got %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			revisions, _ := service.ListRevisions(draft.ID)
+			if len(revisions) != 9 {
+				t.Fatalf("Expected 9 revisions, got %d", len(revisions))
+			}
+			for i, revision := range revisions {
+				if revision.Number != i+1 {
+					t.Errorf("Expected revision %d to be numbered %d, got %d", i, i+1, revision.Number)
+				}
+			}
+
+			// The latest revision is the text the draft was left with
+			current, _ := service.GetDraft(draft.ID)
+			if latest := revisions[len(revisions)-1]; latest.Content != current.Content {
+				t.Errorf("Expected the latest revision to hold %q, got %q", current.Content, latest.Content)
+			}
+		})
+	}
+}
+
+func TestDraftService_RestoreRevision(t *testing.T) {
+	// Setup
+	service := services.NewDraftService(storage.NewMemoryStorage())
+
+	draft, _ := service.CreateDraft("Title", "Good paragraph", nil)
+	service.UpdateDraft(draft.ID, "Title", "Bad AI insertion", nil, models.RevisionSourceAssistant, services.AnyVersion)
+
+	restoredDraft, revision, err := service.RestoreRevision(draft.ID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if restoredDraft.Content != "Good paragraph" {
+		t.Errorf("Expected restored content, got %q", restoredDraft.Content)
+	}
+
+	if revision.Number != 3 || revision.RestoredFrom != 1 || revision.Source != models.RevisionSourceRestore {
+		t.Errorf("Expected restore recorded as revision 3, got %+v", revision)
+	}
+
+	if _, _, err := service.RestoreRevision(draft.ID, 42); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing revision, got %v", err)
+	}
+}
+
+func TestDraftService_RecordsBaselineForOlderDrafts(t *testing.T) {
+	// Setup: a draft stored before revisions existed
+	store := storage.NewMemoryStorage()
+	draft := models.NewBlogDraft("Old", "Original text", nil)
+	draft.ID = "old-draft"
+	store.CreateDraft(draft)
+	service := services.NewDraftService(store)
+
+	service.UpdateDraft(draft.ID, "Old", "Edited text", nil, "", services.AnyVersion)
+
+	revisions, _ := service.ListRevisions(draft.ID)
+	if len(revisions) != 2 || revisions[0].Source != models.RevisionSourceOriginal || revisions[0].Content != "Original text" {
+		t.Errorf("Expected the original text as baseline, got %+v", revisions)
+	}
+}
+
+func TestDraftService_DiffRevisions(t *testing.T) {
+	// Setup
+	service := services.NewDraftService(storage.NewMemoryStorage())
+
+	draft, _ := service.CreateDraft("Title", "one\ntwo\nthree\n", nil)
+	service.UpdateDraft(draft.ID, "New Title", "one\n2\nthree\nfour\n", nil, "", services.AnyVersion)
+
+	result, err := service.DiffRevisions(draft.ID, 1, 2, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []diff.Edit{
+		{Op: diff.Equal, Text: "one\n"},
+		{Op: diff.Delete, Text: "two\n"},
+		{Op: diff.Insert, Text: "2\n"},
+		{Op: diff.Equal, Text: "three\n"},
+		{Op: diff.Insert, Text: "four\n"},
+	}
+	if len(result.Content.Edits) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, result.Content.Edits)
+	}
+	for i := range expected {
+		if result.Content.Edits[i] != expected[i] {
+			t.Errorf("Edit %d: expected %v, got %v", i, expected[i], result.Content.Edits[i])
+		}
+	}
+
+	if result.Content.Insertions != 2 || result.Content.Deletions != 1 {
+		t.Errorf("Expected 2 insertions and 1 deletion, got %d and %d", result.Content.Insertions, result.Content.Deletions)
+	}
+
+	if result.Title.Insertions != 1 || result.Title.Deletions != 0 {
+		t.Errorf("Expected one inserted title word, got %+v", result.Title)
+	}
+
+	if _, err := service.DiffRevisions(draft.ID, 1, 2, "char"); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an unknown mode, got %v", err)
+	}
+}
+
+func TestDiff_WordsReproducesBothTexts(t *testing.T) {
+	before := "The quick brown fox jumps"
+	after := "The quick  red fox leaps"
+
+	result := diff.Words(before, after)
+
+	var old, updated string
+	for _, edit := range result.Edits {
+		if edit.Op != diff.Insert {
+			old += edit.Text
+		}
+		if edit.Op != diff.Delete {
+			updated += edit.Text
+		}
+	}
+
+	if old != before || updated != after {
+		t.Errorf("Expected edits to rebuild both texts, got %q and %q", old, updated)
+	}
+
+	if result.Insertions != 2 || result.Deletions != 2 {
+		t.Errorf("Expected 2 words replaced, got +%d -%d", result.Insertions, result.Deletions)
+	}
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"errors"
 	"testing"
 
 	"inspiration-blog-writer/backend/src/models"
@@ -175,7 +176,7 @@ func TestDraftService_UpdateDraft(t *testing.T) {
 	draft, _ := service.CreateDraft("Original Title", "Original Content", []string{"original"})
 
 	// Update the draft
-	updatedDraft, err := service.UpdateDraft(draft.ID, "Updated Title", "Updated Content", []string{"updated"})
+	updatedDraft, err := service.UpdateDraft(draft.ID, "Updated Title", "Updated Content", []string{"updated"}, models.RevisionSourceUser, services.AnyVersion)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -204,7 +205,7 @@ func TestDraftService_UpdateDraftNotFound(t *testing.T) {
 	service := services.NewDraftService(store)
 
 	// Test updating a non-existent draft
-	_, err := service.UpdateDraft(uuid.New().String(), "Updated Title", "Updated Content", []string{"updated"})
+	_, err := service.UpdateDraft(uuid.New().String(), "Updated Title", "Updated Content", []string{"updated"}, models.RevisionSourceUser, services.AnyVersion)
 	if err == nil {
 		t.Error("Expected error for non-existent draft")
 	}
@@ -216,7 +217,7 @@ func TestDraftService_UpdateDraftWithEmptyID(t *testing.T) {
 	service := services.NewDraftService(store)
 
 	// Test updating with empty ID
-	_, err := service.UpdateDraft("", "Updated Title", "Updated Content", []string{"updated"})
+	_, err := service.UpdateDraft("", "Updated Title", "Updated Content", []string{"updated"}, models.RevisionSourceUser, services.AnyVersion)
 	if err == nil {
 		t.Error("Expected error for empty ID")
 	}
@@ -235,7 +236,7 @@ func TestDraftService_UpdateDraftWithEmptyTitle(t *testing.T) {
 	draft, _ := service.CreateDraft("Original Title", "Original Content", []string{"original"})
 
 	// Test updating with empty title
-	_, err := service.UpdateDraft(draft.ID, "", "Updated Content", []string{"updated"})
+	_, err := service.UpdateDraft(draft.ID, "", "Updated Content", []string{"updated"}, models.RevisionSourceUser, services.AnyVersion)
 	if err == nil {
 		t.Error("Expected error for empty title")
 	}
@@ -245,6 +246,40 @@ func TestDraftService_UpdateDraftWithEmptyTitle(t *testing.T) {
 	}
 }
 
+func TestDraftService_UpdateDraftStaleVersion(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	service := services.NewDraftService(store)
+
+	draft, _ := service.CreateDraft("Original Title", "Original Content", nil)
+	if draft.Version != 1 {
+		t.Fatalf("Expected a new draft to have version 1, got %d", draft.Version)
+	}
+
+	updated, err := service.UpdateDraft(draft.ID, "First Edit", "Content", nil, "", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Expected version 2 after an update, got %d", updated.Version)
+	}
+
+	// A second editor still holding version 1 must not overwrite the edit
+	_, err = service.UpdateDraft(draft.ID, "Second Edit", "Content", nil, "", 1)
+	if !errors.Is(err, services.ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed, got %v", err)
+	}
+
+	var conflict *services.VersionConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected a VersionConflictError, got %T", err)
+	}
+	current, ok := conflict.Current.(*models.BlogDraft)
+	if !ok || current.Title != "First Edit" || current.Version != 2 {
+		t.Errorf("Expected the current draft at version 2, got %+v", conflict.Current)
+	}
+}
+
 func TestDraftService_DeleteDraft(t *testing.T) {
 	// Setup
 	store := storage.NewMemoryStorage()
@@ -434,3 +469,32 @@ func TestDraftService_AddDuplicateResourceToDraft(t *testing.T) {
 		t.Errorf("Expected 1 resource, got %d", len(updatedDraft.Resources))
 	}
 }
+
+func TestDraftService_ErrorsAreTyped(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	service := services.NewDraftService(store)
+
+	// Validation failures match ErrValidation
+	_, err := service.CreateDraft("", "Test Content", nil)
+	if !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+
+	// Missing drafts match ErrNotFound from both packages
+	_, err = service.GetDraft(uuid.New().String())
+	if !errors.Is(err, services.ErrNotFound) || !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err.Error() != "draft not found" {
+		t.Errorf("Expected 'draft not found' error, got %s", err.Error())
+	}
+
+	// Duplicate IDs match ErrAlreadyExists
+	draft, _ := service.CreateDraft("Test Title", "Test Content", nil)
+	err = store.CreateDraft(draft)
+	if !errors.Is(err, services.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists, got %v", err)
+	}
+}
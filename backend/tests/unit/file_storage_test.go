@@ -0,0 +1,300 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func TestFileStorage_SurvivesRestart(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	store, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	trashService := services.NewTrashService(store, 0)
+
+	draft, _ := draftService.CreateDraft("Persistent Draft", "Content", []string{"test"})
+	resource, _ := resourceService.CreateResource("https://example.com", "Test Resource", "Test Description", models.ResourceTypeLink, "test", []string{"test"})
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+	store.SaveArchive(&models.ResourceArchive{ResourceID: resource.ID, URL: resource.URL, Text: "Archived text", ArchivedAt: time.Now()})
+	annotation, _ := resourceService.CreateAnnotation(resource.ID, "A highlighted passage", &models.AnnotationSelector{Start: 4, End: 25}, "Why it matters", nil)
+	dropped, _ := resourceService.CreateAnnotation(resource.ID, "A dropped highlight", nil, "", nil)
+	resourceService.DeleteAnnotation(resource.ID, dropped.ID)
+	removed, _ := draftService.CreateDraft("Removed Draft", "Content", nil)
+	draftService.DeleteDraft(removed.ID)
+	trashService.PurgeDraft(removed.ID)
+
+	// Reopen without closing to simulate a crash
+	reopened, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+
+	restored, err := reopened.GetDraft(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected draft to survive restart, got %v", err)
+	}
+
+	if restored.Title != "Persistent Draft" {
+		t.Errorf("Expected title 'Persistent Draft', got %s", restored.Title)
+	}
+
+	if len(restored.Resources) != 1 || restored.Resources[0] != resource.ID {
+		t.Errorf("Expected resources [%s], got %v", resource.ID, restored.Resources)
+	}
+
+	if _, err := reopened.GetDraft(removed.ID); err == nil {
+		t.Error("Expected deleted draft to stay deleted after restart")
+	}
+
+	if _, err := reopened.GetResource(resource.ID); err != nil {
+		t.Errorf("Expected resource to survive restart, got %v", err)
+	}
+
+	if archive, err := reopened.GetArchive(resource.ID); err != nil || archive.Text != "Archived text" {
+		t.Errorf("Expected the resource's archive to survive restart, got %+v (err %v)", archive, err)
+	}
+
+	annotations, err := reopened.ListAnnotations(resource.ID)
+	if err != nil || len(annotations) != 1 || annotations[0].ID != annotation.ID || annotations[0].Selector == nil || annotations[0].Selector.End != 25 {
+		t.Errorf("Expected only the kept annotation to survive restart, got %+v (err %v)", annotations, err)
+	}
+
+	if revisions, _ := reopened.ListRevisions(draft.ID); len(revisions) != 1 {
+		t.Errorf("Expected the draft's revision to survive restart, got %d", len(revisions))
+	}
+	if revisions, _ := reopened.ListRevisions(removed.ID); len(revisions) != 0 {
+		t.Errorf("Expected revisions of the deleted draft to stay deleted, got %d", len(revisions))
+	}
+}
+
+func TestFileStorage_CloseWritesSnapshot(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	store, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	session := models.NewChatSession("draft-1")
+	session.ID = "session-1"
+	session.AddMessage(models.MessageTypeUser, "hello")
+	if err := store.CreateSession(session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close storage: %v", err)
+	}
+
+	// Journal should be folded into the snapshot
+	info, err := os.Stat(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatalf("Expected journal file to exist, got %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("Expected empty journal after close, got %d bytes", info.Size())
+	}
+
+	reopened, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+
+	restored, err := reopened.GetSession("session-1")
+	if err != nil {
+		t.Fatalf("Expected session to survive restart, got %v", err)
+	}
+
+	if len(restored.Messages) != 1 || restored.Messages[0].Content != "hello" {
+		t.Errorf("Expected one message 'hello', got %v", restored.Messages)
+	}
+}
+
+func TestFileStorage_IgnoresTornJournalRecord(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	store, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	idea := models.NewInterestIdea("Idea", "Description", "Content", 0.5, nil, nil)
+	idea.ID = "idea-1"
+	if err := store.CreateIdea(idea); err != nil {
+		t.Fatalf("Failed to create idea: %v", err)
+	}
+
+	// Simulate a crash in the middle of writing the next record
+	journal, err := os.OpenFile(filepath.Join(dir, "journal.log"), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	journal.WriteString(`{"op":"put","kind":"idea","id":"idea-2","data":{"id":"id`)
+	journal.Close()
+
+	reopened, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Expected recovery to succeed, got %v", err)
+	}
+	defer reopened.Close()
+
+	if _, err := reopened.GetIdea("idea-1"); err != nil {
+		t.Errorf("Expected complete record to be replayed, got %v", err)
+	}
+
+	if _, err := reopened.GetIdea("idea-2"); err == nil {
+		t.Error("Expected torn record to be discarded")
+	}
+}
+
+func TestFileStorage_RefusesCorruptJournalRecord(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	complete := `{"op":"put","kind":"idea","id":"idea-1","data":{"id":"idea-1","title":"One"}}` + "\n"
+	corrupt := `{"op":"put","kind":"idea","id":"idea-2","data":{"id":"id` + "\n"
+	later := `{"op":"put","kind":"idea","id":"idea-3","data":{"id":"idea-3","title":"Three"}}` + "\n"
+	os.WriteFile(filepath.Join(dir, "journal.log"), []byte(complete+corrupt+later), 0o644)
+
+	// Dropping the corrupt record would also drop the one after it
+	_, err := storage.NewFileStorage(dir)
+	if want := fmt.Sprintf("offset %d", len(complete)); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected startup to fail at %s, got %v", want, err)
+	}
+
+	// The same record at the end was torn by a crash and is discarded
+	os.WriteFile(filepath.Join(dir, "journal.log"), []byte(complete+corrupt), 0o644)
+	reopened, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Expected recovery to succeed, got %v", err)
+	}
+	defer reopened.Close()
+
+	if ideas, _ := reopened.ListIdeas(); len(ideas) != 1 || ideas[0].ID != "idea-1" {
+		t.Errorf("Expected only the complete record replayed, got %v", ideas)
+	}
+}
+
+func TestFileStorage_RollsBackUnjournaledWrites(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	store, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	draft := models.NewBlogDraft("Kept", "Content", nil)
+	draft.ID = "draft-1"
+	store.CreateDraft(draft)
+	store.Close()
+
+	// Writes after closing cannot reach the journal, so they must not show
+	// in memory either
+	lost := models.NewBlogDraft("Lost", "Content", nil)
+	lost.ID = "draft-2"
+	if err := store.CreateDraft(lost); err == nil {
+		t.Fatal("Expected writing to a closed storage to fail")
+	}
+	if _, err := store.GetDraft("draft-2"); err == nil {
+		t.Error("Expected the failed create to be rolled back")
+	}
+
+	if err := store.DeleteDraft("draft-1"); err == nil {
+		t.Fatal("Expected deleting from a closed storage to fail")
+	}
+	if kept, err := store.GetDraft("draft-1"); err != nil || kept.Title != "Kept" {
+		t.Errorf("Expected the failed delete to be rolled back, got %v (err %v)", kept, err)
+	}
+}
+
+func TestFileStorage_WritesSurviveFailedCompaction(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	store, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	// A directory in the way makes writing the snapshot fail
+	blocker := filepath.Join(dir, "snapshot.json")
+	os.MkdirAll(filepath.Join(blocker, "in-the-way"), 0o755)
+
+	// Enough writes to trigger compaction
+	for i := 0; i < 600; i++ {
+		idea := models.NewInterestIdea(fmt.Sprintf("Idea %d", i), "", "", 0.5, nil, nil)
+		idea.ID = fmt.Sprintf("idea-%d", i)
+		if err := store.CreateIdea(idea); err != nil {
+			t.Fatalf("Expected the journaled write %d to succeed, got %v", i, err)
+		}
+	}
+
+	os.RemoveAll(blocker)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected compaction to succeed once possible, got %v", err)
+	}
+	reopened, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+
+	if ideas, _ := reopened.ListIdeas(); len(ideas) != 600 {
+		t.Errorf("Expected 600 ideas, got %d", len(ideas))
+	}
+}
+
+func TestFileStorage_ReplaysResourceUnlink(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	store, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	trashService := services.NewTrashService(store, 0)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Test Resource", "", models.ResourceTypeLink, "", nil)
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+	resourceService.DeleteResource(resource.ID, "")
+	linked, _ := store.GetDraft(draft.ID)
+	trashService.PurgeResource(resource.ID)
+	unlinked, _ := store.GetDraft(draft.ID)
+	if !unlinked.UpdatedAt.After(linked.UpdatedAt) {
+		t.Errorf("Expected UpdatedAt to move past %v after unlinking, got %v", linked.UpdatedAt, unlinked.UpdatedAt)
+	}
+
+	// Reopen without closing so the delete is replayed from the journal
+	reopened, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+
+	restored, err := reopened.GetDraft(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected draft to survive restart, got %v", err)
+	}
+	if len(restored.Resources) != 0 {
+		t.Errorf("Expected the deleted resource to stay unlinked, got %v", restored.Resources)
+	}
+	if !restored.UpdatedAt.Equal(unlinked.UpdatedAt) {
+		t.Errorf("Expected the replayed unlink to keep UpdatedAt %v, got %v", unlinked.UpdatedAt, restored.UpdatedAt)
+	}
+}
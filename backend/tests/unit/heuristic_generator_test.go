@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/nlp"
+	"inspiration-blog-writer/backend/src/services"
+)
+
+func newTestResource(id, title, description string, tags ...string) *models.CollectedResource {
+	resource := models.NewCollectedResource("https://example.com/"+id, title, description, models.ResourceTypeBlog, "", tags)
+	resource.ID = id
+	return resource
+}
+
+func TestTokenize(t *testing.T) {
+	terms := nlp.Tokenize("The Vector-Databases of 2024: why HNSW & IVF matter!")
+
+	expected := []string{"vector-databases", "hnsw", "ivf", "matter"}
+	if len(terms) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, terms)
+	}
+	for i := range expected {
+		if terms[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, terms)
+			break
+		}
+	}
+}
+
+func TestVocabulary_GroupsWordForms(t *testing.T) {
+	vocabulary := nlp.NewVocabulary()
+	first := vocabulary.Stems("Comparing databases", nlp.LanguageEnglish)
+	second := vocabulary.Stems("Choosing a database for your databases", nlp.LanguageEnglish)
+
+	if first[1] != second[1] || second[1] != second[2] {
+		t.Fatalf("Expected every form to share a stem, got %v and %v", first, second)
+	}
+	if word := vocabulary.Word(first[1]); word != "databases" {
+		t.Errorf("Expected the most common form, got %q", word)
+	}
+
+	words := vocabulary.Stems("Tests laufen schnell", nlp.LanguageOther)
+	if len(words) != 3 || words[0] != "tests" {
+		t.Errorf("Expected words of other languages unchanged, got %v", words)
+	}
+}
+
+func TestTFIDF_WeighsDistinctiveTerms(t *testing.T) {
+	docs := []nlp.Document{
+		{ID: "a", Terms: []string{"search", "embeddings", "embeddings"}},
+		{ID: "b", Terms: []string{"search", "ranking"}},
+	}
+
+	scores := nlp.TFIDF(docs)
+
+	if scores[0]["embeddings"] <= scores[0]["search"] {
+		t.Errorf("Expected distinctive term to outweigh shared term, got %v", scores[0])
+	}
+
+	top := nlp.TopTerms(scores[0], 1)
+	if len(top) != 1 || top[0].Term != "embeddings" {
+		t.Errorf("Expected top term 'embeddings', got %v", top)
+	}
+}
+
+func TestHeuristicIdeaGenerator_FindsUncoveredTopics(t *testing.T) {
+	draft := models.NewBlogDraft("Search at scale", "Ranking documents quickly with inverted indexes.", nil)
+	draft.ID = "draft-1"
+
+	resources := []*models.CollectedResource{
+		newTestResource("r1", "Embeddings for semantic search", "Dense embeddings capture meaning", "embeddings"),
+		newTestResource("r2", "Approximate nearest neighbours", "HNSW graphs over embeddings", "embeddings", "hnsw"),
+		newTestResource("r3", "Cooking pasta", "Boil water and salt generously"),
+	}
+
+	generator := services.NewHeuristicIdeaGenerator()
+	ideas, err := generator.Generate(context.Background(), services.IdeaGenerationInput{
+		Draft:     draft,
+		Resources: resources,
+		Count:     3,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(ideas) != 3 {
+		t.Fatalf("Expected 3 ideas, got %d", len(ideas))
+	}
+
+	best := ideas[0]
+	if best.Title != "What your draft misses about embeddings" {
+		t.Errorf("Expected the best idea to cover embeddings, got %q", best.Title)
+	}
+
+	if !slices.Contains(best.Sources, "r1") || !slices.Contains(best.Sources, "r2") || slices.Contains(best.Sources, "r3") {
+		t.Errorf("Expected sources r1 and r2, got %v", best.Sources)
+	}
+
+	for i, idea := range ideas {
+		if !idea.IsValid() {
+			t.Errorf("Expected valid confidence, got %v", idea.Confidence)
+		}
+		if i > 0 && idea.Confidence > ideas[i-1].Confidence {
+			t.Error("Expected ideas ordered by confidence")
+		}
+	}
+}
+
+func TestHeuristicIdeaGenerator_WorksWithoutResources(t *testing.T) {
+	draft := models.NewBlogDraft("Gardening", "Composting turns kitchen scraps into soil. Composting needs air.", nil)
+	draft.ID = "draft-1"
+
+	generator := services.NewHeuristicIdeaGenerator()
+	ideas, err := generator.Generate(context.Background(), services.IdeaGenerationInput{Draft: draft, Count: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(ideas) != 2 {
+		t.Fatalf("Expected 2 ideas, got %d", len(ideas))
+	}
+
+	if ideas[0].Title != "Go deeper on composting" {
+		t.Errorf("Expected to deepen the draft's main topic, got %q", ideas[0].Title)
+	}
+}
+
+func TestHeuristicIdeaGenerator_IsDeterministic(t *testing.T) {
+	draft := models.NewBlogDraft("Notes", "Thinking about writing habits", nil)
+	draft.ID = "draft-1"
+	input := services.IdeaGenerationInput{
+		Draft: draft,
+		Resources: []*models.CollectedResource{
+			newTestResource("r1", "Morning pages", "Journaling every morning", "journaling"),
+			newTestResource("r2", "Deep work", "Focus and journaling routines", "focus"),
+		},
+		Count: 5,
+	}
+
+	generator := services.NewHeuristicIdeaGenerator()
+	first, _ := generator.Generate(context.Background(), input)
+	second, _ := generator.Generate(context.Background(), input)
+
+	if len(first) != len(second) {
+		t.Fatalf("Expected same number of ideas, got %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].Title != second[i].Title || first[i].Confidence != second[i].Confidence {
+			t.Errorf("Expected identical ideas at %d, got %q and %q", i, first[i].Title, second[i].Title)
+		}
+	}
+}
+
+func TestHeuristicIdeaGenerator_HandlesChineseAndWordForms(t *testing.T) {
+	draft := models.NewBlogDraft("向量数据库选型", "我们需要一个支持检索的数据库。", nil)
+	draft.ID = "draft-1"
+
+	resources := []*models.CollectedResource{
+		newTestResource("r1", "推荐系统中的嵌入", "嵌入可以表示语义", "嵌入"),
+		newTestResource("r2", "近似最近邻检索", "基于图的嵌入索引", "嵌入"),
+	}
+
+	generator := services.NewHeuristicIdeaGenerator()
+	ideas, err := generator.Generate(context.Background(), services.IdeaGenerationInput{Draft: draft, Resources: resources, Count: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ideas) != 1 || ideas[0].Title != "What your draft misses about 嵌入" {
+		t.Fatalf("Expected a Chinese keyword, got %+v", ideas)
+	}
+
+	// "Embedding" in the draft covers "embeddings" in the resources
+	draft = models.NewBlogDraft("Search", "Embedding documents for search.", nil)
+	draft.ID = "draft-2"
+	resources = []*models.CollectedResource{
+		newTestResource("r1", "Embeddings explained", "Dense embeddings capture meaning", "embeddings"),
+		newTestResource("r2", "Rerankers", "Rerankers reorder results", "rerankers"),
+	}
+	ideas, _ = generator.Generate(context.Background(), services.IdeaGenerationInput{Draft: draft, Resources: resources, Count: 3})
+	for _, idea := range ideas {
+		if idea.Title == "What your draft misses about embeddings" {
+			t.Errorf("Expected the draft to cover embeddings, got %q", idea.Title)
+		}
+	}
+	if len(ideas) == 0 || ideas[0].Title != "What your draft misses about rerankers" {
+		t.Errorf("Expected rerankers as the missing topic, got %+v", ideas)
+	}
+}
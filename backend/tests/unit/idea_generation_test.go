@@ -0,0 +1,234 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func setupIdeaGeneration(t *testing.T, provider llm.Provider) (*services.IdeaService, *models.BlogDraft, *models.CollectedResource) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+
+	draft, _ := draftService.CreateDraft("Vector Databases", "# Why vectors\nEmbeddings everywhere.", []string{"ai"})
+	resource, _ := resourceService.CreateResource("https://example.com/ann", "Approximate nearest neighbours", "HNSW explained", models.ResourceTypeBlog, "research", []string{"search"})
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+
+	return services.NewIdeaService(store, provider), draft, resource
+}
+
+func TestIdeaService_GenerateIdeas(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider()
+	ideaService, draft, resource := setupIdeaGeneration(t, provider)
+
+	provider.Enqueue("```json\n" + `{"ideas":[
+		{"title":"Benchmark HNSW","description":"Compare indexes","content":"- setup","confidence":0.8,"sources":["` + resource.ID + `","made-up"],"tags":["benchmark"]},
+		{"title":"Vectors for writers","confidence":0.5,"tags":["writing"]}
+	]}` + "\n```")
+
+	ideas, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "focus on practice", 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(ideas) != 2 {
+		t.Fatalf("Expected 2 ideas, got %d", len(ideas))
+	}
+
+	first := ideas[0]
+	if first.Title != "Benchmark HNSW" || first.Confidence != 0.8 {
+		t.Errorf("Expected parsed title and confidence, got %s %v", first.Title, first.Confidence)
+	}
+
+	// Unknown source IDs are dropped
+	if len(first.Sources) != 1 || first.Sources[0] != resource.ID {
+		t.Errorf("Expected sources [%s], got %v", resource.ID, first.Sources)
+	}
+
+	if first.DraftID != draft.ID || first.ID == "" {
+		t.Errorf("Expected idea to be linked to draft and have an ID, got %+v", first)
+	}
+
+	// Ideas are persisted
+	stored, _, _ := ideaService.QueryIdeas(services.ListQuery{DraftID: draft.ID})
+	if len(stored) != 2 {
+		t.Errorf("Expected 2 stored ideas, got %d", len(stored))
+	}
+
+	// Prompt includes the draft, the attached resource and the user's context
+	prompt := provider.Requests()[0].Messages[1].Content
+	for _, want := range []string{"Vector Databases", resource.ID, "HNSW explained", "focus on practice"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected prompt to contain %q", want)
+		}
+	}
+}
+
+func TestIdeaService_GenerateIdeasKeepsLimits(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	provider := llm.NewFakeProvider()
+	draftService := services.NewDraftService(store)
+	ideaService := services.NewIdeaService(store, provider)
+
+	// Three-byte runes, so a byte cut at the prompt limit lands mid-character
+	draft, _ := draftService.CreateDraft("向量数据库", "x"+strings.Repeat("向量检索", 2000), nil)
+
+	provider.Enqueue(`{"ideas":[
+		{"title":"First","confidence":0.8},
+		{"title":"Second","confidence":0.6},
+		{"title":"Third","confidence":0.4}
+	]}`)
+
+	ideas, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Extra ideas from the model are dropped
+	if len(ideas) != 2 || ideas[0].Title != "First" || ideas[1].Title != "Second" {
+		t.Errorf("Expected the first 2 ideas, got %v", ideas)
+	}
+	if stored, _, _ := ideaService.QueryIdeas(services.ListQuery{DraftID: draft.ID}); len(stored) != 2 {
+		t.Errorf("Expected 2 stored ideas, got %d", len(stored))
+	}
+
+	prompt := provider.Requests()[0].Messages[1].Content
+	if !utf8.ValidString(prompt) || !strings.Contains(prompt, "[truncated]") {
+		t.Errorf("Expected a truncated prompt of valid UTF-8")
+	}
+}
+
+// failingIdeaStorage fails to create ideas once it has created failAfter
+type failingIdeaStorage struct {
+	storage.Storage
+	failAfter int
+}
+
+func (s *failingIdeaStorage) CreateIdea(idea *models.InterestIdea) error {
+	if s.failAfter == 0 {
+		return errors.New("disk full")
+	}
+	s.failAfter--
+	return s.Storage.CreateIdea(idea)
+}
+
+func TestIdeaService_GenerateIdeasStoresAllOrNone(t *testing.T) {
+	// Setup
+	store := &failingIdeaStorage{Storage: storage.NewMemoryStorage(), failAfter: 2}
+	provider := llm.NewFakeProvider(`{"ideas":[
+		{"title":"First","confidence":0.8},
+		{"title":"Second","confidence":0.6},
+		{"title":"Third","confidence":0.4}
+	]}`)
+	draftService := services.NewDraftService(store)
+	ideaService := services.NewIdeaService(store, provider)
+	draft, _ := draftService.CreateDraft("Vector Databases", "Embeddings everywhere.", nil)
+
+	if _, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 3); err == nil {
+		t.Fatal("Expected the failed save to be reported")
+	}
+
+	if stored, _, _ := ideaService.QueryIdeas(services.ListQuery{}); len(stored) != 0 {
+		t.Errorf("Expected the ideas saved before the failure to be removed, got %d", len(stored))
+	}
+}
+
+func TestIdeaService_GenerateIdeasRepairsInvalidOutput(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider(
+		"Sure! Here are some ideas: 1. Write about vectors",
+		`{"ideas":[{"title":"Repaired idea","confidence":0.6}]}`,
+	)
+	ideaService, draft, _ := setupIdeaGeneration(t, provider)
+
+	ideas, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 0)
+	if err != nil {
+		t.Fatalf("Expected repaired output to succeed, got %v", err)
+	}
+
+	if len(ideas) != 1 || ideas[0].Title != "Repaired idea" {
+		t.Errorf("Expected the repaired idea, got %v", ideas)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 model calls, got %d", len(requests))
+	}
+
+	repair := requests[1].Messages[len(requests[1].Messages)-1].Content
+	if !strings.Contains(repair, "could not be used") {
+		t.Errorf("Expected repair prompt, got %q", repair)
+	}
+}
+
+func TestIdeaService_GenerateIdeasGivesUpOnGarbage(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider(
+		`{"ideas":[{"title":"Too sure","confidence":3}]}`,
+		`not json`,
+		`{"ideas":[]}`,
+	)
+	ideaService, draft, _ := setupIdeaGeneration(t, provider)
+
+	_, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 0)
+	if !errors.Is(err, services.ErrGenerationFailed) {
+		t.Errorf("Expected ErrGenerationFailed, got %v", err)
+	}
+
+	if len(provider.Requests()) != 3 {
+		t.Errorf("Expected 3 model calls, got %d", len(provider.Requests()))
+	}
+
+	stored, _, _ := ideaService.QueryIdeas(services.ListQuery{})
+	if len(stored) != 0 {
+		t.Errorf("Expected no ideas to be stored, got %d", len(stored))
+	}
+}
+
+func TestIdeaService_GenerateIdeasWithoutProvider(t *testing.T) {
+	// Setup
+	ideaService, draft, resource := setupIdeaGeneration(t, nil)
+
+	// Without a model the offline generator is used
+	ideas, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(ideas) == 0 {
+		t.Fatal("Expected offline ideas")
+	}
+
+	if !slices.Contains(ideas[0].Sources, resource.ID) {
+		t.Errorf("Expected best idea to cite %s, got %v", resource.ID, ideas[0].Sources)
+	}
+}
+
+func TestIdeaService_GenerateIdeasFallsBackWhenModelUnreachable(t *testing.T) {
+	// Setup
+	provider := llm.NewFakeProvider()
+	provider.SetError(errors.New("dial tcp: network is unreachable"))
+	ideaService, draft, _ := setupIdeaGeneration(t, provider)
+
+	ideas, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 0)
+	if err != nil {
+		t.Fatalf("Expected fallback to succeed, got %v", err)
+	}
+
+	if len(ideas) == 0 {
+		t.Error("Expected offline ideas after model failure")
+	}
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestIdeaService_CreateIdea(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, nil)
+
+	draft, _ := draftService.CreateDraft("Test Draft", "Test Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Test Resource", "Test Description", models.ResourceTypeLink, "test", nil)
+
+	idea, err := ideaService.CreateIdea(draft.ID, "Test Idea", "Description", "Content", 0.7, []string{resource.ID}, []string{"ai"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if idea.ID == "" {
+		t.Error("Expected idea ID to be set")
+	}
+
+	if idea.DraftID != draft.ID {
+		t.Errorf("Expected draft ID %s, got %s", draft.ID, idea.DraftID)
+	}
+
+	retrieved, err := ideaService.GetIdea(idea.ID)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if retrieved.Title != "Test Idea" {
+		t.Errorf("Expected title 'Test Idea', got %s", retrieved.Title)
+	}
+}
+
+func TestIdeaService_CreateIdeaValidation(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	ideaService := services.NewIdeaService(store, nil)
+
+	_, err := ideaService.CreateIdea("", "", "Description", "Content", 0.5, nil, nil)
+	if !errors.Is(err, services.ErrValidation) || err.Error() != "title is required" {
+		t.Errorf("Expected 'title is required' validation error, got %v", err)
+	}
+
+	_, err = ideaService.CreateIdea("", "Test Idea", "", "", 1.2, nil, nil)
+	if !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected validation error for confidence, got %v", err)
+	}
+
+	_, err = ideaService.CreateIdea("", "Test Idea", "", "", 0.5, []string{uuid.New().String()}, nil)
+	if !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected not found error for unknown source, got %v", err)
+	}
+
+	_, err = ideaService.CreateIdea(uuid.New().String(), "Test Idea", "", "", 0.5, nil, nil)
+	if !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected not found error for unknown draft, got %v", err)
+	}
+}
+
+func TestIdeaService_QueryIdeasWithFilter(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, nil)
+
+	resource, _ := resourceService.CreateResource("https://example.com", "Test Resource", "", models.ResourceTypeLink, "", nil)
+	ideaService.CreateIdea("", "Tagged", "", "", 0.5, nil, []string{"ai"})
+	ideaService.CreateIdea("", "Sourced", "", "", 0.5, []string{resource.ID}, nil)
+
+	byTag, _, _ := ideaService.QueryIdeas(services.ListQuery{Tags: []string{"ai"}})
+	if len(byTag) != 1 || byTag[0].Title != "Tagged" {
+		t.Errorf("Expected only 'Tagged', got %v", byTag)
+	}
+
+	bySource, _, _ := ideaService.QueryIdeas(services.ListQuery{SourceID: resource.ID})
+	if len(bySource) != 1 || bySource[0].Title != "Sourced" {
+		t.Errorf("Expected only 'Sourced', got %v", bySource)
+	}
+
+	all, _, _ := ideaService.QueryIdeas(services.ListQuery{})
+	if len(all) != 2 {
+		t.Errorf("Expected 2 ideas, got %d", len(all))
+	}
+}
+
+func TestIdeaService_UpdateAndDeleteIdea(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	ideaService := services.NewIdeaService(store, nil)
+
+	idea, _ := ideaService.CreateIdea("", "Original", "", "", 0.5, nil, nil)
+
+	updated, err := ideaService.UpdateIdea(idea.ID, "Updated", "New description", "", 0.9, nil, []string{"new"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if updated.Title != "Updated" || updated.Confidence != 0.9 {
+		t.Errorf("Expected updated title and confidence, got %s %v", updated.Title, updated.Confidence)
+	}
+
+	if err := ideaService.DeleteIdea(idea.ID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if _, err := ideaService.GetIdea(idea.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected not found after delete, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/nlp"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]nlp.Language{
+		"Vector databases":                               nlp.LanguageEnglish,
+		"向量数据库的选择与比较":                                    nlp.LanguageChinese,
+		"ベクトルデータベースの選び方":                                 nlp.LanguageJapanese,
+		"벡터 데이터베이스 비교":                                   nlp.LanguageKorean,
+		"Notes on pgvector: 如何选择向量数据库":                   nlp.LanguageChinese,
+		"It is one of the ways to store embeddings well": nlp.LanguageEnglish,
+		"Vektordatenbanken speichern Einbettungen effizient und erlauben daher schnelle Ähnlichkeitssuche über sehr große Datenmengen": nlp.LanguageOther,
+	}
+
+	for text, expected := range cases {
+		if language := nlp.DetectLanguage(text); language != expected {
+			t.Errorf("Expected %q to be %s, got %s", text, expected, language)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"databases":   "databas",
+		"database":    "databas",
+		"embeddings":  "embed",
+		"embedded":    "embed",
+		"running":     "run",
+		"ponies":      "poni",
+		"relational":  "relat",
+		"generalize":  "gener",
+		"controlling": "control",
+		"go":          "go",
+		"hnsw2":       "hnsw2",
+	}
+
+	for word, expected := range cases {
+		if stem := nlp.Stem(word); stem != expected {
+			t.Errorf("Expected %q to stem to %q, got %q", word, expected, stem)
+		}
+	}
+}
+
+func TestTokenize_SegmentsChinese(t *testing.T) {
+	terms := nlp.Tokenize("我们如何选择向量数据库")
+
+	// "我们" and "如何" are stop words; the rest are dictionary words
+	if fmt.Sprint(terms) != "[选择 向量数据库]" {
+		t.Errorf("Expected dictionary words, got %v", terms)
+	}
+
+	// Unknown words fall back to character pairs
+	if terms := nlp.Tokenize("量子纠缠"); fmt.Sprint(terms) != "[量子 子纠 纠缠]" {
+		t.Errorf("Expected character pairs, got %v", terms)
+	}
+}
+
+func TestSearchTerms_MatchDocumentTokens(t *testing.T) {
+	document := "本文比较了几种向量数据库的检索性能"
+	indexed := make(map[string]bool)
+	for _, token := range nlp.SearchTokens(document, nlp.DetectLanguage(document)) {
+		indexed[token.Term] = true
+		if document[token.Start:token.End] != token.Term {
+			t.Errorf("Expected token %q to point at its text, got %q", token.Term, document[token.Start:token.End])
+		}
+	}
+
+	for _, query := range []string{"向量数据库", "数据库", "数据", "检索性能", "几种", "较了"} {
+		terms := nlp.SearchTerms(query)
+		if len(terms) == 0 {
+			t.Errorf("Expected terms for %q", query)
+		}
+		for _, term := range terms {
+			if !indexed[term] {
+				t.Errorf("Expected term %q of query %q to be indexed", term, query)
+			}
+		}
+	}
+
+	if terms := nlp.SearchTerms("Vector DATABASES"); fmt.Sprint(terms) != "[vector databas]" {
+		t.Errorf("Expected lowercase stems, got %v", terms)
+	}
+}
@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// queryBackends returns every storage implementation the query tests run on
+func queryBackends(t *testing.T) map[string]storage.Storage {
+	t.Helper()
+
+	sqliteStore, _ := openTestSQLite(t)
+	return map[string]storage.Storage{
+		"memory": storage.NewMemoryStorage(),
+		"sqlite": sqliteStore,
+	}
+}
+
+// seedResources stores count resources created an hour apart, alternating
+// between two types and titled in reverse creation order
+func seedResources(t *testing.T, store storage.Storage, count int) []*models.CollectedResource {
+	t.Helper()
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	resources := make([]*models.CollectedResource, count)
+	for i := range resources {
+		resourceType, tags := models.ResourceTypeLink, []string{"go"}
+		if i%2 == 1 {
+			resourceType, tags = models.ResourceTypeBlog, []string{"go", "testing"}
+		}
+		resource := models.NewCollectedResource("https://example.com", fmt.Sprintf("Resource %c", 'Z'-i), "", resourceType, "research", tags)
+		resource.ID = fmt.Sprintf("resource-%02d", i)
+		resource.CreatedAt = start.Add(time.Duration(i) * time.Hour)
+		resource.UpdatedAt = resource.CreatedAt
+		if err := store.CreateResource(resource); err != nil {
+			t.Fatalf("Failed to create resource: %v", err)
+		}
+		resources[i] = resource
+	}
+	return resources
+}
+
+// collectPages follows nextCursor until the last page and returns the IDs
+// seen, failing if the pages do not stop
+func collectPages(t *testing.T, store storage.Storage, query storage.ListQuery) []string {
+	t.Helper()
+
+	var ids []string
+	for pages := 0; pages < 20; pages++ {
+		resources, next, err := store.QueryResources(query)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if query.Limit > 0 && len(resources) > query.Limit {
+			t.Fatalf("Expected at most %d resources, got %d", query.Limit, len(resources))
+		}
+		for _, resource := range resources {
+			ids = append(ids, resource.ID)
+		}
+		if next == "" {
+			return ids
+		}
+		query.Cursor = next
+	}
+	t.Fatal("Expected the last page to have no cursor")
+	return nil
+}
+
+func TestStorage_QueryPagesInOrder(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			seedResources(t, store, 7)
+
+			newestFirst := collectPages(t, store, storage.ListQuery{Limit: 3, Descending: true})
+			expected := []string{"resource-06", "resource-05", "resource-04", "resource-03", "resource-02", "resource-01", "resource-00"}
+			if fmt.Sprint(newestFirst) != fmt.Sprint(expected) {
+				t.Errorf("Expected %v, got %v", expected, newestFirst)
+			}
+
+			byTitle := collectPages(t, store, storage.ListQuery{Limit: 2, SortBy: storage.SortByTitle})
+			if len(byTitle) != 7 || byTitle[0] != "resource-06" || byTitle[6] != "resource-00" {
+				t.Errorf("Expected resources A to Z by title, got %v", byTitle)
+			}
+
+			all, next, err := store.QueryResources(storage.ListQuery{})
+			if err != nil || len(all) != 7 || next != "" {
+				t.Errorf("Expected every resource on one page without a limit, got %d (cursor %q, err %v)", len(all), next, err)
+			}
+		})
+	}
+}
+
+func TestStorage_QueryFilters(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			resources := seedResources(t, store, 6)
+			resources[0].Trash()
+			if err := store.UpdateResource(resources[0]); err != nil {
+				t.Fatalf("Failed to trash resource: %v", err)
+			}
+
+			tagged := collectPages(t, store, storage.ListQuery{Tags: []string{"go", "testing"}})
+			if fmt.Sprint(tagged) != "[resource-01 resource-03 resource-05]" {
+				t.Errorf("Expected the resources with both tags, got %v", tagged)
+			}
+
+			links := collectPages(t, store, storage.ListQuery{Type: models.ResourceTypeLink})
+			if fmt.Sprint(links) != "[resource-02 resource-04]" {
+				t.Errorf("Expected the links outside the trash, got %v", links)
+			}
+
+			between := collectPages(t, store, storage.ListQuery{
+				CreatedAfter:  resources[2].CreatedAt,
+				CreatedBefore: resources[4].CreatedAt,
+			})
+			if fmt.Sprint(between) != "[resource-02 resource-03]" {
+				t.Errorf("Expected the resources in the date range, got %v", between)
+			}
+
+			trashed := collectPages(t, store, storage.ListQuery{Trash: storage.OnlyTrashed})
+			if fmt.Sprint(trashed) != "[resource-00]" {
+				t.Errorf("Expected only the trashed resource, got %v", trashed)
+			}
+		})
+	}
+}
+
+func TestStorage_QueryRejectsForeignCursor(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			seedResources(t, store, 3)
+
+			_, next, err := store.QueryResources(storage.ListQuery{Limit: 1})
+			if err != nil || next == "" {
+				t.Fatalf("Expected a next cursor, got %q (err %v)", next, err)
+			}
+
+			_, _, err = store.QueryResources(storage.ListQuery{Limit: 1, Cursor: next, SortBy: storage.SortByTitle})
+			if !errors.Is(err, storage.ErrInvalidQuery) {
+				t.Errorf("Expected ErrInvalidQuery for a cursor of another order, got %v", err)
+			}
+
+			_, _, err = store.QueryResources(storage.ListQuery{Cursor: "not-a-cursor"})
+			if !errors.Is(err, storage.ErrInvalidQuery) {
+				t.Errorf("Expected ErrInvalidQuery for a malformed cursor, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDraftService_QueryDraftsValidatesAndHidesTrash(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+
+	for i := 0; i < 3; i++ {
+		if _, err := draftService.CreateDraft(fmt.Sprintf("Draft %d", i), "Content", nil); err != nil {
+			t.Fatalf("Failed to create draft: %v", err)
+		}
+	}
+	trashed, _ := draftService.CreateDraft("Trashed", "Content", nil)
+	if err := draftService.DeleteDraft(trashed.ID); err != nil {
+		t.Fatalf("Failed to delete draft: %v", err)
+	}
+
+	drafts, next, err := draftService.QueryDrafts(services.ListQuery{Trash: storage.IncludeTrashed})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(drafts) != 3 || next != "" {
+		t.Errorf("Expected the 3 drafts outside the trash on one page, got %d (cursor %q)", len(drafts), next)
+	}
+
+	if _, _, err := draftService.QueryDrafts(services.ListQuery{Limit: services.MaxPageSize + 1}); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an oversized page, got %v", err)
+	}
+	if _, _, err := draftService.QueryDrafts(services.ListQuery{SortBy: "content"}); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an unknown sort field, got %v", err)
+	}
+	if _, _, err := draftService.QueryDrafts(services.ListQuery{Cursor: "bogus"}); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for a malformed cursor, got %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"inspiration-blog-writer/backend/src/llm"
+)
+
+var testCompletionRequest = llm.CompletionRequest{
+	Messages: []llm.Message{
+		{Role: llm.RoleSystem, Content: "You are helpful"},
+		{Role: llm.RoleUser, Content: "Say hello"},
+	},
+}
+
+func TestOpenAIProvider_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Expected path /chat/completions, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] != "gpt-test" {
+			t.Errorf("Expected model gpt-test, got %v", body["model"])
+		}
+
+		fmt.Fprint(w, `{"model":"gpt-test","choices":[{"message":{"content":"Hello!"}}],"usage":{"prompt_tokens":5,"completion_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "secret", "gpt-test", "embed-test", server.Client())
+
+	resp, err := provider.Complete(context.Background(), testCompletionRequest)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.Content != "Hello!" {
+		t.Errorf("Expected content 'Hello!', got %q", resp.Content)
+	}
+
+	if resp.PromptTokens != 5 || resp.CompletionTokens != 2 {
+		t.Errorf("Expected usage 5/2, got %d/%d", resp.PromptTokens, resp.CompletionTokens)
+	}
+}
+
+func TestOpenAIProvider_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":1}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "", "gpt-test", "", server.Client())
+
+	var deltas []string
+	resp, err := provider.Stream(context.Background(), testCompletionRequest, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("Expected deltas Hel|lo, got %v", deltas)
+	}
+
+	if resp.Content != "Hello" || resp.CompletionTokens != 1 {
+		t.Errorf("Expected content 'Hello' with 1 token, got %q with %d", resp.Content, resp.CompletionTokens)
+	}
+}
+
+func TestOpenAIProvider_StreamEndingEarly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "", "gpt-test", "", server.Client())
+
+	_, err := provider.Stream(context.Background(), testCompletionRequest, func(string) error { return nil })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected a stream without [DONE] to fail, got %v", err)
+	}
+}
+
+func TestNewProvider_TimeoutOnlyBoundsHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/slow/") {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, word := range []string{"Slow", " and", " steady"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+			w.(http.Flusher).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	// The stream takes longer than the timeout, but its headers do not
+	provider, _ := llm.NewProvider(llm.Config{Provider: "openai", BaseURL: server.URL, Timeout: 80 * time.Millisecond})
+	resp, err := provider.Stream(context.Background(), testCompletionRequest, func(string) error { return nil })
+	if err != nil || resp.Content != "Slow and steady" {
+		t.Fatalf("Expected the whole stream, got %v (err %v)", resp, err)
+	}
+
+	slow, _ := llm.NewProvider(llm.Config{Provider: "openai", BaseURL: server.URL + "/slow", Timeout: 80 * time.Millisecond})
+	if _, err := slow.Stream(context.Background(), testCompletionRequest, func(string) error { return nil }); err == nil {
+		t.Error("Expected waiting too long for headers to fail")
+	}
+}
+
+func TestOpenAIProvider_SendsTemperatureZero(t *testing.T) {
+	var temperatures []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		temperature, set := body["temperature"]
+		if !set {
+			temperature = "unset"
+		}
+		temperatures = append(temperatures, temperature)
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "", "gpt-test", "", server.Client())
+	for _, temperature := range []*float64{llm.Temperature(0), nil} {
+		req := testCompletionRequest
+		req.Temperature = temperature
+		if _, err := provider.Complete(context.Background(), req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if len(temperatures) != 2 || temperatures[0] != 0.0 || temperatures[1] != "unset" {
+		t.Errorf("Expected temperature 0 sent and none left out, got %v", temperatures)
+	}
+}
+
+func TestOpenAIProvider_StreamWithoutStreamOptions(t *testing.T) {
+	var withOptions []bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		_, set := body["stream_options"]
+		withOptions = append(withOptions, set)
+		if set {
+			http.Error(w, `{"error":"unknown field stream_options"}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "", "gpt-test", "", server.Client())
+	for i := 0; i < 2; i++ {
+		resp, err := provider.Stream(context.Background(), testCompletionRequest, func(string) error { return nil })
+		if err != nil || resp.Content != "Hi" {
+			t.Fatalf("Expected the stream to succeed without stream_options, got %v (err %v)", resp, err)
+		}
+	}
+
+	// Only the first request tries stream_options
+	if len(withOptions) != 3 || !withOptions[0] || withOptions[1] || withOptions[2] {
+		t.Errorf("Expected stream_options dropped after the first rejection, got %v", withOptions)
+	}
+}
+
+func TestOpenAIProvider_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"rate limited"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "", "gpt-test", "", server.Client())
+
+	_, err := provider.Complete(context.Background(), testCompletionRequest)
+
+	var apiErr *llm.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected APIError with status 429, got %v", err)
+	}
+}
+
+func TestOpenAIProvider_Embed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("Expected path /embeddings, got %s", r.URL.Path)
+		}
+		// Out-of-order indexes must be placed correctly
+		fmt.Fprint(w, `{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`)
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "", "gpt-test", "embed-test", server.Client())
+
+	embeddings, err := provider.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if embeddings[0][0] != 1 || embeddings[1][1] != 1 {
+		t.Errorf("Expected embeddings in input order, got %v", embeddings)
+	}
+}
+
+func TestOllamaProvider_CompleteAndStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected path /api/chat, got %s", r.URL.Path)
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if body["stream"] == true {
+			fmt.Fprintln(w, `{"model":"llama","message":{"content":"Hi "},"done":false}`)
+			fmt.Fprintln(w, `{"model":"llama","message":{"content":"there"},"done":false}`)
+			fmt.Fprintln(w, `{"model":"llama","message":{"content":""},"done":true,"prompt_eval_count":4,"eval_count":2}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"llama","message":{"content":"Hi there"},"done":true,"prompt_eval_count":4,"eval_count":2}`)
+	}))
+	defer server.Close()
+
+	provider := llm.NewOllamaProvider(server.URL, "llama", "", server.Client())
+
+	resp, err := provider.Complete(context.Background(), testCompletionRequest)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.Content != "Hi there" || resp.PromptTokens != 4 {
+		t.Errorf("Expected 'Hi there' with 4 prompt tokens, got %q with %d", resp.Content, resp.PromptTokens)
+	}
+
+	var deltas []string
+	streamed, err := provider.Stream(context.Background(), testCompletionRequest, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(deltas) != 2 || streamed.Content != "Hi there" || streamed.CompletionTokens != 2 {
+		t.Errorf("Expected 2 deltas assembling 'Hi there', got %v -> %q", deltas, streamed.Content)
+	}
+}
+
+func TestFakeProvider_IsDeterministic(t *testing.T) {
+	provider := llm.NewFakeProvider("first")
+
+	resp, _ := provider.Complete(context.Background(), testCompletionRequest)
+	if resp.Content != "first" {
+		t.Errorf("Expected queued response 'first', got %q", resp.Content)
+	}
+
+	resp, _ = provider.Complete(context.Background(), testCompletionRequest)
+	if resp.Content != "Echo: Say hello" {
+		t.Errorf("Expected echo response, got %q", resp.Content)
+	}
+
+	if len(provider.Requests()) != 2 {
+		t.Errorf("Expected 2 recorded requests, got %d", len(provider.Requests()))
+	}
+
+	a, _ := provider.Embed(context.Background(), []string{"vector databases"})
+	b, _ := provider.Embed(context.Background(), []string{"vector databases"})
+	for i := range a[0] {
+		if a[0][i] != b[0][i] {
+			t.Fatal("Expected identical embeddings for identical text")
+		}
+	}
+
+	provider.SetError(errors.New("offline"))
+	if _, err := provider.Complete(context.Background(), testCompletionRequest); err == nil {
+		t.Error("Expected configured error")
+	}
+}
+
+func TestNewProvider_Selection(t *testing.T) {
+	provider, err := llm.NewProvider(llm.Config{})
+	if err != nil || provider != nil {
+		t.Errorf("Expected no provider for empty config, got %v, %v", provider, err)
+	}
+
+	provider, err = llm.NewProvider(llm.Config{Provider: "ollama", Model: "llama"})
+	if err != nil || provider.Name() != "ollama" {
+		t.Errorf("Expected ollama provider, got %v, %v", provider, err)
+	}
+
+	if _, err := llm.NewProvider(llm.Config{Provider: "unknown"}); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+}
@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// These tests are meant to be run with the race detector:
+//
+//	go test -race -run Concurrent ./tests/unit
+
+func TestMemoryStorage_ReturnsCopies(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+
+	draft := models.NewBlogDraft("Title", "Content", []string{"go"})
+	draft.ID = "draft-1"
+	draft.Resources = []string{"r1"}
+	if err := store.CreateDraft(draft); err != nil {
+		t.Fatalf("Failed to create draft: %v", err)
+	}
+
+	// Changing the value passed in does not reach the store
+	draft.Tags[0] = "changed"
+	draft.Resources = append(draft.Resources, "r2")
+
+	retrieved, _ := store.GetDraft(draft.ID)
+	if retrieved.Tags[0] != "go" || len(retrieved.Resources) != 1 {
+		t.Errorf("Expected the stored draft to be unchanged, got tags %v resources %v", retrieved.Tags, retrieved.Resources)
+	}
+
+	// Neither does changing a value read back
+	retrieved.Title = "Changed"
+	retrieved.Resources[0] = "changed"
+	again, _ := store.GetDraft(draft.ID)
+	if again.Title != "Title" || again.Resources[0] != "r1" {
+		t.Errorf("Expected the stored draft to be unchanged, got %q %v", again.Title, again.Resources)
+	}
+
+	session := models.NewChatSession(draft.ID)
+	session.ID = "session-1"
+	store.CreateSession(session)
+	readSession, _ := store.GetSession(session.ID)
+	readSession.AddMessage(models.MessageTypeUser, "Hello")
+	if stored, _ := store.GetSession(session.ID); len(stored.Messages) != 0 {
+		t.Errorf("Expected no stored messages before UpdateSession, got %d", len(stored.Messages))
+	}
+}
+
+func TestMemoryStorage_ConcurrentResourceLinks(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+
+	draft, _ := draftService.CreateDraft("Shared Draft", "Content", nil)
+
+	const writers = 20
+	resourceIDs := make([]string, writers)
+	for i := range resourceIDs {
+		resource, _ := resourceService.CreateResource(fmt.Sprintf("https://example.com/%d", i), "Resource", "", models.ResourceTypeLink, "", nil)
+		resourceIDs[i] = resource.ID
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	stop := make(chan struct{})
+
+	// Readers walk the draft's slices while writers replace them
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if d, err := draftService.GetDraft(draft.ID); err == nil {
+					for range d.Resources {
+					}
+				}
+				if drafts, err := draftService.ListDrafts(); err == nil {
+					for _, d := range drafts {
+						_ = len(d.Resources)
+					}
+				}
+			}
+		}()
+	}
+
+	for _, resourceID := range resourceIDs {
+		wg.Add(1)
+		go func(resourceID string) {
+			defer wg.Done()
+			// Give up only on errors other than losing the race
+			for {
+				err := draftService.AddResourceToDraft(draft.ID, resourceID)
+				if !errors.Is(err, services.ErrConflict) {
+					errs <- err
+					return
+				}
+			}
+		}(resourceID)
+	}
+
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+
+	final, _ := draftService.GetDraft(draft.ID)
+	if len(final.Resources) != writers {
+		t.Errorf("Expected %d linked resources, got %d: an update was lost", writers, len(final.Resources))
+	}
+	if final.Version != 1+writers {
+		t.Errorf("Expected version %d after %d links, got %d", 1+writers, writers, final.Version)
+	}
+}
+
+func TestMemoryStorage_ConcurrentVersionedUpdates(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+
+	draft, _ := draftService.CreateDraft("Title", "Original", nil)
+
+	const editors = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+
+	// Every editor saves against version 1; exactly one may win
+	for i := 0; i < editors; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := draftService.UpdateDraft(draft.ID, "Title", fmt.Sprintf("Edit %d", i), nil, "", draft.Version)
+			switch {
+			case err == nil:
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			case !errors.Is(err, services.ErrPreconditionFailed) && !errors.Is(err, services.ErrConflict):
+				t.Errorf("Expected a version error, got %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one update based on version 1 to succeed, got %d", succeeded)
+	}
+
+	final, _ := draftService.GetDraft(draft.ID)
+	if final.Version != 2 {
+		t.Errorf("Expected version 2, got %d", final.Version)
+	}
+}
@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func TestResourceService_Annotations(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			resourceService := services.NewResourceService(store)
+			trashService := services.NewTrashService(store, 0)
+			resource, _ := resourceService.CreateResource("https://example.com/vector-databases", "Vector databases", "", models.ResourceTypeBlog, "", nil)
+			other, _ := resourceService.CreateResource("https://example.com/other", "Other", "", models.ResourceTypeLink, "", nil)
+
+			selector := &models.AnnotationSelector{Start: 120, End: 171, Prefix: "In short, ", Suffix: " That is"}
+			first, err := resourceService.CreateAnnotation(resource.ID, "pgvector is enough until you pass ten million rows.", selector,
+				"Matches what we saw at work", []string{"postgres"})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			second, _ := resourceService.CreateAnnotation(resource.ID, "Recall drops sharply with aggressive quantization.", nil, "", nil)
+
+			annotations, err := resourceService.ListAnnotations(resource.ID)
+			if err != nil || len(annotations) != 2 || annotations[0].ID != first.ID || annotations[1].ID != second.ID {
+				t.Fatalf("Expected both annotations oldest first, got %+v (err %v)", annotations, err)
+			}
+			if got := annotations[0]; *got.Selector != *selector || got.Note != "Matches what we saw at work" || len(got.Tags) != 1 {
+				t.Errorf("Expected the annotation stored as given, got %+v", got)
+			}
+			if annotations[1].Selector != nil {
+				t.Errorf("Expected no selector, got %+v", annotations[1].Selector)
+			}
+
+			updated, err := resourceService.UpdateAnnotation(resource.ID, second.ID, second.Quote, nil, "Worth a benchmark", []string{"recall"})
+			if err != nil || updated.Note != "Worth a benchmark" || !updated.UpdatedAt.After(second.CreatedAt) {
+				t.Errorf("Expected the note updated, got %+v (err %v)", updated, err)
+			}
+			if stored, _ := resourceService.GetAnnotation(resource.ID, second.ID); stored == nil || stored.Note != "Worth a benchmark" {
+				t.Errorf("Expected the update stored, got %+v", stored)
+			}
+
+			// Annotations are only reachable through their own resource
+			if _, err := resourceService.GetAnnotation(other.ID, first.ID); !errors.Is(err, services.ErrNotFound) {
+				t.Errorf("Expected the annotation not found under another resource, got %v", err)
+			}
+			if err := resourceService.DeleteAnnotation(other.ID, first.ID); !errors.Is(err, services.ErrNotFound) {
+				t.Errorf("Expected deleting through another resource to fail, got %v", err)
+			}
+
+			if err := resourceService.DeleteAnnotation(resource.ID, second.ID); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if annotations, _ := resourceService.ListAnnotations(resource.ID); len(annotations) != 1 {
+				t.Errorf("Expected one annotation left, got %d", len(annotations))
+			}
+
+			resourceService.DeleteResource(resource.ID, services.ReferencesRefuse)
+			if _, err := resourceService.ListAnnotations(resource.ID); !errors.Is(err, services.ErrNotFound) {
+				t.Errorf("Expected no annotations for a trashed resource, got %v", err)
+			}
+			trashService.PurgeResource(resource.ID)
+			if _, err := store.GetAnnotation(first.ID); !errors.Is(err, services.ErrNotFound) {
+				t.Errorf("Expected the annotations purged with their resource, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResourceService_ValidatesAnnotations(t *testing.T) {
+	// Setup
+	resourceService := services.NewResourceService(storage.NewMemoryStorage())
+	resource, _ := resourceService.CreateResource("https://example.com", "Example", "", models.ResourceTypeLink, "", nil)
+
+	cases := map[string]struct {
+		quote    string
+		selector *models.AnnotationSelector
+	}{
+		"empty quote":        {quote: " \n"},
+		"negative start":     {quote: "Text", selector: &models.AnnotationSelector{Start: -1, End: 3}},
+		"end before start":   {quote: "Text", selector: &models.AnnotationSelector{Start: 10, End: 4}},
+		"empty text segment": {quote: "Text", selector: &models.AnnotationSelector{Start: 4, End: 4}},
+	}
+	for name, c := range cases {
+		if _, err := resourceService.CreateAnnotation(resource.ID, c.quote, c.selector, "", nil); !errors.Is(err, services.ErrValidation) {
+			t.Errorf("Expected %s to be rejected, got %v", name, err)
+		}
+	}
+
+	if _, err := resourceService.CreateAnnotation("missing", "Text", nil, "", nil); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected an annotation on a missing resource to be rejected, got %v", err)
+	}
+}
+
+func TestAnnotations_LeadPrompts(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	provider := llm.NewFakeProvider()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, provider)
+	chatService := services.NewChatService(store, provider)
+
+	draft, _ := draftService.CreateDraft("Databases", "Notes on storing embeddings", nil)
+	resource, _ := resourceService.CreateResource("https://example.com/vector-databases", "Vector stores", "A comparison", models.ResourceTypeBlog, "", []string{"databases"})
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+	store.SaveArchive(&models.ResourceArchive{ResourceID: resource.ID, Text: "Milvus, Qdrant and pgvector each make different trade-offs."})
+	resource.Metadata.ArchivedAt = &draft.CreatedAt
+	store.UpdateResource(resource)
+	resourceService.CreateAnnotation(resource.ID, "Recall drops sharply\nwith aggressive quantization.", nil, "Benchmark this", []string{"quantization"})
+
+	provider.Enqueue(`{"ideas":[{"title":"Measure quantization","confidence":0.8}]}`)
+	if _, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	provider.Enqueue("Start with recall.")
+	session, _ := chatService.CreateSession(draft.ID)
+	if _, err := chatService.SendMessage(context.Background(), session.ID, "Where do I start?"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	requests := provider.Requests()
+	highlights := "  highlights:\n    - \"Recall drops sharply with aggressive quantization.\"\n      note: Benchmark this\n      tags: quantization\n"
+	for i, prompt := range []string{requests[0].Messages[1].Content, requests[1].Messages[0].Content} {
+		at := strings.Index(prompt, highlights)
+		if at < 0 || !strings.Contains(prompt, "Highlights are passages the author marked") {
+			t.Errorf("Expected prompt %d to list the highlights, got %q", i, prompt)
+			continue
+		}
+		if archived := strings.Index(prompt, "archived text:"); archived < at {
+			t.Errorf("Expected prompt %d to put the highlights before the archived text, got %q", i, prompt)
+		}
+	}
+}
+
+func TestHeuristicIdeaGenerator_WeighsHighlights(t *testing.T) {
+	draft := models.NewBlogDraft("Search at scale", "Ranking documents quickly with inverted indexes.", nil)
+	draft.ID = "draft-1"
+
+	resources := []*models.CollectedResource{
+		newTestResource("r1", "Vector search", "Graph indexes trade memory for recall and latency"),
+	}
+	highlight := models.NewAnnotation("r1", "Latency is what users notice", nil, "", nil)
+
+	generator := services.NewHeuristicIdeaGenerator()
+	generate := func(annotations map[string][]*models.Annotation) string {
+		ideas, err := generator.Generate(context.Background(), services.IdeaGenerationInput{
+			Draft:       draft,
+			Resources:   resources,
+			Annotations: annotations,
+			Count:       1,
+		})
+		if err != nil || len(ideas) != 1 {
+			t.Fatalf("Expected one idea, got %d (err %v)", len(ideas), err)
+		}
+		return ideas[0].Title
+	}
+
+	if title := generate(nil); title == "What your draft misses about latency" {
+		t.Errorf("Expected latency not to stand out without highlights, got %q", title)
+	}
+	if title := generate(map[string][]*models.Annotation{"r1": {highlight}}); title != "What your draft misses about latency" {
+		t.Errorf("Expected the highlighted topic to lead, got %q", title)
+	}
+}
@@ -0,0 +1,203 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/search"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// blogPostPage is an article surrounded by the usual boilerplate
+const blogPostPage = `<!DOCTYPE html>
+<html><head><title>Picking a vector database</title><script>track("view")</script></head><body>
+<header><nav><a href="/">Home</a> <a href="/blog">Blog</a></nav></header>
+<div class="layout has-sidebar">
+  <div class="post-content">
+    <h1>Picking a vector database</h1>
+    <p>Vector databases store embeddings, and choosing one depends on scale, latency, and cost.</p>
+    <p>Milvus, Qdrant and pgvector each make different trade-offs, which this post compares.</p>
+    <ul><li>Milvus scales out</li><li>pgvector lives in Postgres</li></ul>
+    <pre>SELECT * FROM items
+  ORDER BY embedding &lt;-&gt; '[1,2]';</pre>
+    <div class="share-buttons"><a href="#">Share on Twitter</a></div>
+  </div>
+  <div class="sidebar"><p>Subscribe to our newsletter for more posts like this one, every week.</p></div>
+  <div id="comments"><p>Great post, thanks a lot for writing this up, it was very helpful!</p></div>
+</div>
+<footer>Copyright 2024</footer>
+<div style="display: none">Hidden promotion</div>
+</body></html>`
+
+func TestReadableText_ExtractsMainArticle(t *testing.T) {
+	text := fetch.ReadableText([]byte(blogPostPage))
+
+	expected := "Picking a vector database\n\n" +
+		"Vector databases store embeddings, and choosing one depends on scale, latency, and cost.\n\n" +
+		"Milvus, Qdrant and pgvector each make different trade-offs, which this post compares.\n\n" +
+		"- Milvus scales out\n- pgvector lives in Postgres\n\n" +
+		"SELECT * FROM items\n  ORDER BY embedding <-> '[1,2]';"
+	if text != expected {
+		t.Errorf("Expected the article alone, got %q", text)
+	}
+
+	// Line breaks in the source do not split Chinese text
+	chinese := fetch.ReadableText([]byte(`<html><body><div class="nav">菜单</div><article><p>向量数据库
+的选择需要考虑规模、延迟和成本，而 Milvus 和 pgvector 各有取舍。</p></article></body></html>`))
+	if chinese != "向量数据库的选择需要考虑规模、延迟和成本，而 Milvus 和 pgvector 各有取舍。" {
+		t.Errorf("Expected the Chinese paragraph joined, got %q", chinese)
+	}
+}
+
+func TestMetadataService_ArchivesPages(t *testing.T) {
+	var mu sync.Mutex
+	page := blogPostPage
+	server := servePages(t, map[string]http.HandlerFunc{
+		"/post": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if page == "" {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			htmlPage(page)(w, r)
+		},
+	})
+	setPage := func(html string) {
+		mu.Lock()
+		page = html
+		mu.Unlock()
+	}
+
+	stores := queryBackends(t)
+	fileStore, err := storage.NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	t.Cleanup(func() { fileStore.Close() })
+	stores["file"] = fileStore
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			setPage(blogPostPage)
+			resourceService := services.NewResourceService(store)
+			metadataService := services.NewMetadataService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}))
+			resourceService.SetMetadataService(metadataService)
+			trashService := services.NewTrashService(store, 0)
+
+			resource, err := resourceService.CreateResource(server.URL+"/post", "", "", models.ResourceTypeBlog, "", nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, err := resourceService.GetArchive(resource.ID); !errors.Is(err, services.ErrNotFound) {
+				t.Errorf("Expected no archive before fetching, got %v", err)
+			}
+			if _, err := metadataService.FetchPending(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			fetched, _ := resourceService.GetResource(resource.ID)
+			if fetched.Metadata.ArchivedAt == nil {
+				t.Fatal("Expected the resource to record its archive")
+			}
+			archive, err := resourceService.GetArchive(resource.ID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if archive.Title != "Picking a vector database" || archive.URL != server.URL+"/post" ||
+				!strings.HasPrefix(archive.Text, "Picking a vector database\n\nVector databases store embeddings") ||
+				strings.Contains(archive.Text, "newsletter") || !strings.Contains(archive.HTML, "Subscribe to our newsletter") {
+				t.Errorf("Expected the readable text and raw page archived, got %+v", archive)
+			}
+
+			// A page that went away keeps its archive
+			setPage("")
+			if _, err := resourceService.ArchivePage(context.Background(), resource.ID); !errors.Is(err, services.ErrFetchFailed) {
+				t.Errorf("Expected the fetch to fail, got %v", err)
+			}
+			if kept, _ := resourceService.GetArchive(resource.ID); kept == nil || kept.Text != archive.Text {
+				t.Errorf("Expected the old archive kept, got %+v", kept)
+			}
+
+			setPage(`<html><body><article><p>The post was rewritten to compare only two databases, Qdrant and pgvector, in depth.</p></article></body></html>`)
+			updated, err := resourceService.ArchivePage(context.Background(), resource.ID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !strings.HasPrefix(updated.Text, "The post was rewritten") || !updated.ArchivedAt.After(archive.ArchivedAt) {
+				t.Errorf("Expected the archive replaced, got %+v", updated)
+			}
+
+			resourceService.DeleteResource(resource.ID, services.ReferencesRefuse)
+			if _, err := resourceService.GetArchive(resource.ID); !errors.Is(err, services.ErrNotFound) {
+				t.Errorf("Expected no archive for a trashed resource, got %v", err)
+			}
+			trashService.PurgeResource(resource.ID)
+			if _, err := store.GetArchive(resource.ID); !errors.Is(err, services.ErrNotFound) {
+				t.Errorf("Expected the archive purged with its resource, got %v", err)
+			}
+
+			metadataService.SetArchiving(false)
+			if _, err := resourceService.ArchivePage(context.Background(), resource.ID); !errors.Is(err, services.ErrValidation) {
+				t.Errorf("Expected archiving to be disabled, got %v", err)
+			}
+		})
+	}
+}
+
+func TestArchives_FeedSearchAndPrompts(t *testing.T) {
+	// Setup
+	server := servePages(t, map[string]http.HandlerFunc{"/post": htmlPage(blogPostPage)})
+	index := search.NewIndex()
+	store, err := search.NewIndexedStorage(storage.NewMemoryStorage(), index)
+	if err != nil {
+		t.Fatalf("Failed to create indexed storage: %v", err)
+	}
+	provider := llm.NewFakeProvider()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	metadataService := services.NewMetadataService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}))
+	resourceService.SetMetadataService(metadataService)
+	ideaService := services.NewIdeaService(store, provider)
+	chatService := services.NewChatService(store, provider)
+	searchService := services.NewSearchService(store, index)
+
+	draft, _ := draftService.CreateDraft("Databases", "Notes", nil)
+	resource, _ := resourceService.CreateResource(server.URL+"/post", "Vector stores", "", models.ResourceTypeBlog, "", nil)
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+	metadataService.FetchPending(context.Background())
+
+	results, err := searchService.Search("pgvector postgres", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results.Resources) != 1 || results.Resources[0].ID != resource.ID {
+		t.Fatalf("Expected the resource found by its archived text, got %+v", results.Resources)
+	}
+
+	provider.Enqueue(`{"ideas":[{"title":"Benchmark pgvector","confidence":0.7}]}`)
+	if _, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	provider.Enqueue("Compare them on recall.")
+	session, _ := chatService.CreateSession(draft.ID)
+	if _, err := chatService.SendMessage(context.Background(), session.ID, "Which one?"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	requests := provider.Requests()
+	for i, prompt := range []string{requests[0].Messages[1].Content, requests[1].Messages[0].Content} {
+		if !strings.Contains(prompt, "archived text:\n    Picking a vector database\n\n    Vector databases store embeddings") {
+			t.Errorf("Expected prompt %d to quote the archived page, got %q", i, prompt)
+		}
+	}
+}
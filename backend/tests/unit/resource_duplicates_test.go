@@ -0,0 +1,193 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	cases := map[string]string{
+		"HTTPS://Example.COM:443/Posts/Go/":                       "https://example.com/Posts/Go",
+		"https://example.com/post?utm_source=x&utm_medium=y&id=7": "https://example.com/post?id=7",
+		"https://example.com/post?b=2&fbclid=abc&a=1#comments":    "https://example.com/post?a=1&b=2",
+		"https://m.example.com/post":                              "https://example.com/post",
+		"https://en.m.wikipedia.org/wiki/Go":                      "https://en.wikipedia.org/wiki/Go",
+		"https://mobile.twitter.com/golang/":                      "https://twitter.com/golang",
+		"https://m.com/page":                                      "https://m.com/page",
+		"http://example.com:8080/":                                "http://example.com:8080",
+		"  https://example.com/search?q=go+generics&gclid=x ":     "https://example.com/search?q=go+generics",
+		"https://github.com/golang/go/tree/master/src?ref=go1.22": "https://github.com/golang/go/tree/master/src?ref=go1.22",
+		"https://app.example.com/#/boards/7":                      "https://app.example.com#/boards/7",
+		"https://example.com/#!/settings":                         "https://example.com#!/settings",
+		"ftp://example.com/file/":                                 "ftp://example.com/file/",
+		"not a url":                                               "not a url",
+	}
+	for raw, expected := range cases {
+		if got := services.CanonicalURL(raw); got != expected {
+			t.Errorf("CanonicalURL(%q) = %q, expected %q", raw, got, expected)
+		}
+	}
+}
+
+func TestResourceService_RejectsDuplicates(t *testing.T) {
+	// Setup
+	resourceService := services.NewResourceService(storage.NewMemoryStorage())
+	original, err := resourceService.CreateResource("https://www.example.com/posts/generics/?utm_source=feed", "Understanding Go generics", "",
+		models.ResourceTypeBlog, "", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if original.URL != "https://www.example.com/posts/generics/?utm_source=feed" {
+		t.Errorf("Expected the URL stored as given, got %q", original.URL)
+	}
+
+	duplicates := []struct {
+		url, title, reason string
+	}{
+		{"http://m.example.com/posts/generics#intro", "Another title", services.DuplicateURL},
+		{"https://example.com/posts/generics", "Generics", services.DuplicateURL},
+		{"https://mirror.example.org/generics", "Understanding Go Generics | Mirror Site", services.DuplicateTitle},
+	}
+	for _, duplicate := range duplicates {
+		_, err := resourceService.CreateResource(duplicate.url, duplicate.title, "", models.ResourceTypeBlog, "", nil)
+		if !errors.Is(err, services.ErrDuplicate) || !errors.Is(err, services.ErrConflict) {
+			t.Errorf("Expected %s to be rejected as a duplicate, got %v", duplicate.url, err)
+			continue
+		}
+		var duplicateErr *services.DuplicateResourceError
+		if errors.As(err, &duplicateErr) && (duplicateErr.Existing.ID != original.ID || duplicateErr.Reason != duplicate.reason) {
+			t.Errorf("Expected %s to duplicate %s by %s, got %s by %s", duplicate.url, original.ID, duplicate.reason,
+				duplicateErr.Existing.ID, duplicateErr.Reason)
+		}
+	}
+
+	// Different pages of the same site and short titles are not duplicates
+	if _, err := resourceService.CreateResource("https://example.com/posts/errors", "Understanding Go errors", "", models.ResourceTypeBlog, "", nil); err != nil {
+		t.Errorf("Expected a different page to be collected, got %v", err)
+	}
+	resourceService.CreateResource("https://example.com/a", "Introduction", "", models.ResourceTypeBlog, "", nil)
+	if _, err := resourceService.CreateResource("https://example.org/b", "Introduction", "", models.ResourceTypeBlog, "", nil); err != nil {
+		t.Errorf("Expected a short title not to match on its own, got %v", err)
+	}
+
+	// Nor are other branches of a repository or routes of a single-page app
+	for _, url := range []string{"https://github.com/golang/go?ref=master", "https://github.com/golang/go?ref=go1.22",
+		"https://app.example.com/#/boards/1", "https://app.example.com/#/boards/2"} {
+		if _, err := resourceService.CreateResource(url, url, "", models.ResourceTypeLink, "", nil); err != nil {
+			t.Errorf("Expected %s to be collected, got %v", url, err)
+		}
+	}
+}
+
+func TestResourceService_MergesOrAllowsDuplicates(t *testing.T) {
+	// Setup
+	resourceService := services.NewResourceService(storage.NewMemoryStorage())
+	original, _ := resourceService.CreateResource("https://example.com/post", "Post", "", models.ResourceTypeLink, "", []string{"go"})
+
+	merged, created, err := resourceService.CollectResource("https://example.com/post/", "Post", "Worth a read", models.ResourceTypeLink,
+		"Programming", []string{"go", "testing"}, services.DuplicatesMerge)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if created || merged.ID != original.ID {
+		t.Fatalf("Expected the existing resource back, got created=%v id=%s", created, merged.ID)
+	}
+	if len(merged.Tags) != 2 || merged.Tags[1] != "testing" || merged.Category != "Programming" || merged.Description != "Worth a read" {
+		t.Errorf("Expected tags, category and description merged, got %+v", merged)
+	}
+
+	again, created, _ := resourceService.CollectResource("https://example.com/post", "Post", "Other words", models.ResourceTypeLink,
+		"Other", []string{"go"}, services.DuplicatesMerge)
+	if created || again.Version != merged.Version || again.Category != "Programming" {
+		t.Errorf("Expected nothing to change when there is nothing new, got %+v", again)
+	}
+
+	allowed, created, err := resourceService.CollectResource("https://example.com/post", "Post", "", models.ResourceTypeLink, "", nil,
+		services.DuplicatesAllow)
+	if err != nil || !created || allowed.ID == original.ID {
+		t.Errorf("Expected a second resource to be created, got %v (created=%v)", err, created)
+	}
+
+	if _, _, err := resourceService.CollectResource("https://example.com/new", "New", "", models.ResourceTypeLink, "", nil, "ignore"); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected an unknown policy to be rejected, got %v", err)
+	}
+}
+
+func TestResourceService_Dedupe(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			draftService := services.NewDraftService(store)
+			resourceService := services.NewResourceService(store)
+			ideaService := services.NewIdeaService(store, nil)
+
+			collect := func(url, title, category string, tags ...string) *models.CollectedResource {
+				resource, _, err := resourceService.CollectResource(url, title, "", models.ResourceTypeBlog, category, tags, services.DuplicatesAllow)
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return resource
+			}
+			oldest := collect("https://example.com/generics", "Understanding Go generics", "", "go")
+			sameURL := collect("https://m.example.com/generics/?utm_campaign=x", "Generics", "Programming", "generics")
+			sameTitle := collect("https://blog.example.org/go-generics", "Understanding Go Generics - Blog", "", "go", "types")
+			other := collect("https://example.com/errors", "Understanding Go errors", "")
+
+			draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+			draftService.AddResourceToDraft(draft.ID, oldest.ID)
+			draftService.AddResourceToDraft(draft.ID, sameURL.ID)
+			idea, _ := ideaService.CreateIdea("", "Idea", "", "", 0.5, []string{sameTitle.ID, other.ID}, nil)
+
+			preview, err := resourceService.Dedupe(true)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(preview.Groups) != 1 || preview.Merged != 0 {
+				t.Fatalf("Expected one group and nothing merged on a dry run, got %+v", preview)
+			}
+			group := preview.Groups[0]
+			if group.Kept.ID != oldest.ID || len(group.Duplicates) != 2 ||
+				group.Duplicates[0].Reason != services.DuplicateURL || group.Duplicates[1].Reason != services.DuplicateTitle {
+				t.Errorf("Expected the oldest kept and both duplicates found, got %+v", group)
+			}
+			if _, err := resourceService.GetResource(sameURL.ID); err != nil {
+				t.Errorf("Expected a dry run to change nothing, got %v", err)
+			}
+
+			result, err := resourceService.Dedupe(false)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Merged != 2 {
+				t.Errorf("Expected 2 duplicates merged, got %d", result.Merged)
+			}
+
+			kept, _ := resourceService.GetResource(oldest.ID)
+			if len(kept.Tags) != 3 || kept.Category != "Programming" {
+				t.Errorf("Expected tags and category merged into the kept resource, got %v and %q", kept.Tags, kept.Category)
+			}
+			for _, id := range []string{sameURL.ID, sameTitle.ID} {
+				if _, err := resourceService.GetResource(id); !errors.Is(err, services.ErrNotFound) {
+					t.Errorf("Expected duplicate %s in the trash, got %v", id, err)
+				}
+			}
+
+			linked, _ := draftService.GetDraft(draft.ID)
+			if len(linked.Resources) != 1 || linked.Resources[0] != oldest.ID {
+				t.Errorf("Expected the draft to link the kept resource once, got %v", linked.Resources)
+			}
+			storedIdea, _ := ideaService.GetIdea(idea.ID)
+			if len(storedIdea.Sources) != 2 || storedIdea.Sources[0] != oldest.ID || storedIdea.Sources[1] != other.ID {
+				t.Errorf("Expected the idea to link the kept resource, got %v", storedIdea.Sources)
+			}
+
+			if again, _ := resourceService.Dedupe(false); len(again.Groups) != 0 {
+				t.Errorf("Expected nothing left to merge, got %+v", again.Groups)
+			}
+		})
+	}
+}
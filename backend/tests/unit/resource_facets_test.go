@@ -0,0 +1,105 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// seedFacetResources creates a small library of resources from a few sites
+func seedFacetResources(t *testing.T, resourceService *services.ResourceService) {
+	t.Helper()
+
+	resources := []struct {
+		url, title, description string
+		resourceType            models.ResourceType
+		category                string
+		tags                    []string
+	}{
+		{"https://blog.golang.org/pipelines", "Go Concurrency Patterns", "Pipelines and cancellation", models.ResourceTypeBlog, "go", []string{"go", "concurrency"}},
+		{"https://go.dev/doc/effective_go", "Effective Go", "Writing clear, idiomatic code", models.ResourceTypeDocument, "go", []string{"go"}},
+		{"https://www.youtube.com/watch?v=f6kdp27TYZs", "Concurrency Is Not Parallelism", "Rob Pike's talk", models.ResourceTypeVideo, "talks", []string{"concurrency", "concurrency"}},
+		{"https://notgolang.org/spam", "Unrelated", "Nothing about Go here", models.ResourceTypeLink, "", nil},
+	}
+	for _, r := range resources {
+		if _, err := resourceService.CreateResource(r.url, r.title, r.description, r.resourceType, r.category, r.tags); err != nil {
+			t.Fatalf("Failed to create resource: %v", err)
+		}
+	}
+}
+
+func TestResourceService_QueryByDomainAndText(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			resourceService := services.NewResourceService(store)
+			seedFacetResources(t, resourceService)
+
+			titles := func(query services.ListQuery) string {
+				t.Helper()
+				query.SortBy = services.SortByTitle
+				resources, _, err := resourceService.QueryResources(query)
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				var titles []string
+				for _, resource := range resources {
+					titles = append(titles, resource.Title)
+				}
+				return fmt.Sprint(titles)
+			}
+
+			if got := titles(services.ListQuery{Domain: "golang.org"}); got != "[Go Concurrency Patterns]" {
+				t.Errorf("Expected only the golang.org subdomain to match, got %v", got)
+			}
+			if got := titles(services.ListQuery{Domain: "https://www.YouTube.com/"}); got != "[Concurrency Is Not Parallelism]" {
+				t.Errorf("Expected a URL to be accepted as domain, got %v", got)
+			}
+			if got := titles(services.ListQuery{Text: "CONCURRENCY"}); got != "[Concurrency Is Not Parallelism Go Concurrency Patterns]" {
+				t.Errorf("Expected a case-insensitive title match, got %v", got)
+			}
+			if got := titles(services.ListQuery{Text: "effective_go"}); got != "[Effective Go]" {
+				t.Errorf("Expected a URL match, got %v", got)
+			}
+			if got := titles(services.ListQuery{Text: "concurrency", Type: models.ResourceTypeVideo}); got != "[Concurrency Is Not Parallelism]" {
+				t.Errorf("Expected filters to compose, got %v", got)
+			}
+		})
+	}
+}
+
+func TestResourceService_GetFacets(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	resourceService := services.NewResourceService(store)
+	seedFacetResources(t, resourceService)
+
+	facets, err := resourceService.GetFacets(services.ListQuery{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if facets.Total != 4 {
+		t.Errorf("Expected 4 resources, got %d", facets.Total)
+	}
+	if fmt.Sprint(facets.Categories) != "[{go 2} {talks 1}]" {
+		t.Errorf("Expected categories by count without the empty one, got %v", facets.Categories)
+	}
+	if fmt.Sprint(facets.Tags) != "[{concurrency 2} {go 2}]" {
+		t.Errorf("Expected each resource to count once per tag, got %v", facets.Tags)
+	}
+	if len(facets.Types) != 4 {
+		t.Errorf("Expected 4 types, got %v", facets.Types)
+	}
+
+	filtered, err := resourceService.GetFacets(services.ListQuery{Category: "go", Limit: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if filtered.Total != 2 || fmt.Sprint(filtered.Tags) != "[{go 2} {concurrency 1}]" {
+		t.Errorf("Expected facets of the go category regardless of limit, got %+v", filtered)
+	}
+}
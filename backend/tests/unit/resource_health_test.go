@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// linkPages answers the link checks of the health tests
+func linkPages() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/ok": htmlPage("<html></html>"),
+		"/no-head": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte("<html></html>"))
+		},
+		"/old": func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		},
+		"/new": htmlPage("<html></html>"),
+		"/gone": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "gone", http.StatusGone)
+		},
+		"/private": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+		},
+	}
+}
+
+func TestFetcher_ProbesLinks(t *testing.T) {
+	// Setup
+	server := servePages(t, linkPages())
+	fetcher := fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true})
+
+	cases := map[string]struct {
+		status int
+		url    string
+	}{
+		"/ok":      {http.StatusOK, server.URL + "/ok"},
+		"/no-head": {http.StatusOK, server.URL + "/no-head"},
+		"/old":     {http.StatusOK, server.URL + "/new"},
+		"/gone":    {http.StatusGone, server.URL + "/gone"},
+	}
+	for path, expected := range cases {
+		probe, err := fetcher.Probe(context.Background(), server.URL+path)
+		if err != nil {
+			t.Errorf("Expected %s to answer, got %v", path, err)
+			continue
+		}
+		if probe.StatusCode != expected.status || probe.URL.String() != expected.url {
+			t.Errorf("Expected %s to answer %d from %s, got %d from %s", path, expected.status, expected.url, probe.StatusCode, probe.URL)
+		}
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	if _, err := fetcher.Probe(context.Background(), closed.URL); err == nil {
+		t.Error("Expected an error for a server that does not answer")
+	}
+}
+
+func TestHealthService_FlagsBrokenAndMovedLinks(t *testing.T) {
+	server := servePages(t, linkPages())
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			resourceService := services.NewResourceService(store)
+			healthService := services.NewHealthService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}), time.Hour)
+			resourceService.SetHealthService(healthService)
+
+			ids := make(map[string]string)
+			for _, path := range []string{"/ok", "/no-head", "/old", "/gone", "/private", "/unreachable"} {
+				url := server.URL + path
+				if path == "/unreachable" {
+					url = closed.URL + path
+				}
+				resource, err := resourceService.CreateResource(url, "Link "+path, "", models.ResourceTypeLink, "", nil)
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				ids[path] = resource.ID
+			}
+
+			now := time.Now()
+			report, err := healthService.CheckDue(context.Background(), now)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if report.Checked != 6 || report.OK != 3 || report.Moved != 1 || report.Broken != 2 {
+				t.Errorf("Expected 3 ok, 1 moved and 2 broken, got %+v", report)
+			}
+
+			moved, _ := resourceService.GetResource(ids["/old"])
+			if moved.Health.Status != models.HealthMoved || moved.Health.FinalURL != server.URL+"/new" || moved.Health.CheckedAt == nil {
+				t.Errorf("Expected the redirect recorded as moved, got %+v", moved.Health)
+			}
+			gone, _ := resourceService.GetResource(ids["/gone"])
+			if gone.Health.Status != models.HealthBroken || gone.Health.StatusCode != http.StatusGone || gone.Health.Failures != 1 {
+				t.Errorf("Expected the 410 recorded as broken, got %+v", gone.Health)
+			}
+			if gone.Version != 1 {
+				t.Errorf("Expected a check to leave the version alone, got %d", gone.Version)
+			}
+			private, _ := resourceService.GetResource(ids["/private"])
+			if private.Health.Status != models.HealthOK || private.Health.StatusCode != http.StatusForbidden {
+				t.Errorf("Expected a 403 not to be flagged, got %+v", private.Health)
+			}
+
+			broken, _, err := resourceService.QueryResources(services.ListQuery{Health: models.HealthBroken, SortBy: services.SortByTitle})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(broken) != 2 || broken[0].ID != ids["/gone"] || broken[1].ID != ids["/unreachable"] {
+				t.Errorf("Expected the two broken links listed, got %d", len(broken))
+			}
+			if _, _, err := resourceService.QueryResources(services.ListQuery{Health: "dead"}); err == nil {
+				t.Error("Expected an unknown health filter to be rejected")
+			}
+
+			// Nothing is due until the checks are an hour old
+			if report, _ := healthService.CheckDue(context.Background(), now.Add(time.Minute)); report.Checked != 0 {
+				t.Errorf("Expected nothing due, got %+v", report)
+			}
+			healthService.CheckDue(context.Background(), now.Add(2*time.Hour))
+			gone, _ = resourceService.GetResource(ids["/gone"])
+			if gone.Health.Failures != 2 {
+				t.Errorf("Expected a second failure counted, got %+v", gone.Health)
+			}
+
+			checked, err := resourceService.CheckHealth(context.Background(), ids["/ok"])
+			if err != nil || checked.Health.Status != models.HealthOK || checked.Health.Failures != 0 {
+				t.Errorf("Expected a working link, got %+v (err %v)", checked.Health, err)
+			}
+		})
+	}
+}
+
+func TestHealthService_LimitsChecksPerHost(t *testing.T) {
+	// Setup
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	server := servePages(t, map[string]http.HandlerFunc{
+		"/": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			peak = max(peak, inFlight)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		},
+	})
+	store := storage.NewMemoryStorage()
+	resourceService := services.NewResourceService(store)
+	for i := 0; i < 8; i++ {
+		resourceService.CreateResource(server.URL+"/page/"+strconv.Itoa(i), "Page "+strconv.Itoa(i), "", models.ResourceTypeLink, "", nil)
+	}
+	healthService := services.NewHealthService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}), time.Hour)
+
+	report, err := healthService.CheckDue(context.Background(), time.Now())
+	if err != nil || report.Checked != 8 {
+		t.Fatalf("Expected 8 links checked, got %+v (err %v)", report, err)
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 requests at once to one host, got %d", peak)
+	}
+}
@@ -0,0 +1,308 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// articlePage is a page with every kind of metadata the fetcher reads
+const articlePage = `<!DOCTYPE html>
+<html><head>
+<meta charset="utf-8">
+<title>Picking a vector database - Example Blog</title>
+<meta name="description" content="The plain description">
+<meta property="og:title" content="Picking a   vector database">
+<meta property="og:description" content="Milvus, Qdrant &amp; pgvector compared">
+<meta property="og:site_name" content="Example Blog">
+<meta property="og:image" content="/images/cover.png">
+<meta name="twitter:title" content="Twitter title">
+<meta property="article:published_time" content="2024-03-05T08:30:00+01:00">
+<link rel="canonical" href="https://example.com/posts/vector-databases">
+<link rel="shortcut icon" href="/static/favicon.png">
+</head><body><p>Body</p></body></html>`
+
+// servePages starts a server answering each path with its handler
+func servePages(t *testing.T, pages map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for path, handler := range pages {
+		mux.HandleFunc(path, handler)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func htmlPage(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}
+}
+
+func TestFetcher_ReadsMetadata(t *testing.T) {
+	// Setup
+	server := servePages(t, map[string]http.HandlerFunc{
+		"/article": htmlPage(articlePage),
+		"/old": func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/article", http.StatusMovedPermanently)
+		},
+	})
+	fetcher := fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true})
+
+	metadata, err := fetcher.Metadata(context.Background(), server.URL+"/old")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if metadata.Title != "Picking a vector database" {
+		t.Errorf("Expected the OpenGraph title with whitespace collapsed, got %q", metadata.Title)
+	}
+	if metadata.Description != "Milvus, Qdrant & pgvector compared" {
+		t.Errorf("Expected the OpenGraph description unescaped, got %q", metadata.Description)
+	}
+	if metadata.SiteName != "Example Blog" || metadata.CanonicalURL != "https://example.com/posts/vector-databases" {
+		t.Errorf("Expected the site name and canonical URL, got %+v", metadata)
+	}
+	if metadata.ImageURL != server.URL+"/images/cover.png" || metadata.FaviconURL != server.URL+"/static/favicon.png" {
+		t.Errorf("Expected image and favicon resolved against the final URL, got %q and %q", metadata.ImageURL, metadata.FaviconURL)
+	}
+	expected := time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC)
+	if metadata.PublishedAt == nil || !metadata.PublishedAt.Equal(expected) {
+		t.Errorf("Expected publish date %v, got %v", expected, metadata.PublishedAt)
+	}
+}
+
+func TestFetcher_RefusesPrivateAddresses(t *testing.T) {
+	// Setup
+	server := servePages(t, map[string]http.HandlerFunc{
+		"/article": htmlPage(articlePage),
+	})
+	fetcher := fetch.NewFetcher(fetch.Config{})
+
+	// Host names are checked once resolved
+	local := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/article"
+	for _, rawURL := range []string{server.URL + "/article", local, "http://169.254.169.254/latest/meta-data/", "http://[::]:80/"} {
+		if _, err := fetcher.Get(context.Background(), rawURL); !errors.Is(err, fetch.ErrPrivateAddress) {
+			t.Errorf("Expected %s to be refused, got %v", rawURL, err)
+		}
+	}
+	if _, err := fetcher.Probe(context.Background(), local); !errors.Is(err, fetch.ErrPrivateAddress) {
+		t.Errorf("Expected the probe to be refused, got %v", err)
+	}
+
+	allowed := fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true})
+	if _, err := allowed.Get(context.Background(), local); err != nil {
+		t.Errorf("Expected private networks to be reachable when allowed, got %v", err)
+	}
+}
+
+func TestFetcher_FallsBackToPlainTags(t *testing.T) {
+	// Setup
+	encoded, _ := simplifiedchinese.GBK.NewEncoder().String(`<html><head><title>向量数据库选型</title>
+		<meta name="description" content="比较几种向量数据库"></head>
+		<body><time datetime="2024-05-01">五月一日</time></body></html>`)
+	server := servePages(t, map[string]http.HandlerFunc{
+		"/gbk": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=gbk")
+			w.Write([]byte(encoded))
+		},
+	})
+	fetcher := fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true})
+
+	metadata, err := fetcher.Metadata(context.Background(), server.URL+"/gbk")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if metadata.Title != "向量数据库选型" || metadata.Description != "比较几种向量数据库" {
+		t.Errorf("Expected the <title> and description decoded from GBK, got %q and %q", metadata.Title, metadata.Description)
+	}
+	if metadata.FaviconURL != server.URL+"/favicon.ico" || metadata.CanonicalURL != "" {
+		t.Errorf("Expected the default favicon and no canonical URL, got %+v", metadata)
+	}
+	if metadata.PublishedAt == nil || metadata.PublishedAt.Format(time.DateOnly) != "2024-05-01" {
+		t.Errorf("Expected the date of the <time> tag, got %v", metadata.PublishedAt)
+	}
+}
+
+func TestFetcher_EnforcesLimits(t *testing.T) {
+	// Setup
+	release := make(chan struct{})
+	server := servePages(t, map[string]http.HandlerFunc{
+		"/slow": func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		},
+		"/huge": htmlPage("<html><head>" + strings.Repeat("<!-- padding -->", 1000) + "<title>Too far</title></head></html>"),
+		"/pdf": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		},
+		"/gone": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "gone", http.StatusGone)
+		},
+	})
+	defer close(release)
+	fetcher := fetch.NewFetcher(fetch.Config{Timeout: 100 * time.Millisecond, MaxBytes: 1024, AllowPrivateNetworks: true})
+
+	start := time.Now()
+	if _, err := fetcher.Metadata(context.Background(), server.URL+"/slow"); err == nil {
+		t.Error("Expected a timeout for a slow page")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the request to give up quickly, took %v", elapsed)
+	}
+
+	metadata, err := fetcher.Metadata(context.Background(), server.URL+"/huge")
+	if err != nil || metadata.Title != "" {
+		t.Errorf("Expected the page to be cut before its title, got %+v (err %v)", metadata, err)
+	}
+
+	if _, err := fetcher.Metadata(context.Background(), server.URL+"/pdf"); !errors.Is(err, fetch.ErrNotHTML) {
+		t.Errorf("Expected ErrNotHTML for a PDF, got %v", err)
+	}
+
+	var statusErr *fetch.StatusError
+	if _, err := fetcher.Metadata(context.Background(), server.URL+"/gone"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusGone {
+		t.Errorf("Expected a 410 status error, got %v", err)
+	}
+
+	if _, err := fetcher.Metadata(context.Background(), "ftp://example.com/file"); !errors.Is(err, fetch.ErrUnsupportedURL) {
+		t.Errorf("Expected ErrUnsupportedURL, got %v", err)
+	}
+}
+
+func TestMetadataService_FillsEmptyFields(t *testing.T) {
+	server := servePages(t, map[string]http.HandlerFunc{
+		"/article": htmlPage(articlePage),
+		"/missing": func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		},
+	})
+
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			resourceService := services.NewResourceService(store)
+			metadataService := services.NewMetadataService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}))
+			resourceService.SetMetadataService(metadataService)
+
+			article, err := resourceService.CreateResource(server.URL+"/article", "", "My own notes", models.ResourceTypeBlog, "", nil)
+			if err != nil {
+				t.Fatalf("Expected a resource without a title to be accepted, got %v", err)
+			}
+			missing, _ := resourceService.CreateResource(server.URL+"/missing", "", "", models.ResourceTypeLink, "", nil)
+			if article.Metadata.Status != models.MetadataPending {
+				t.Errorf("Expected the metadata to be pending, got %q", article.Metadata.Status)
+			}
+
+			count, err := metadataService.FetchPending(context.Background())
+			if err != nil || count != 2 {
+				t.Fatalf("Expected 2 resources fetched, got %d (err %v)", count, err)
+			}
+
+			article, _ = resourceService.GetResource(article.ID)
+			if article.Title != "Picking a vector database" || article.Description != "My own notes" {
+				t.Errorf("Expected the title filled in and the description kept, got %q and %q", article.Title, article.Description)
+			}
+			metadata := article.Metadata
+			if metadata.Status != models.MetadataFetched || metadata.SiteName != "Example Blog" || metadata.FetchedAt == nil {
+				t.Errorf("Expected the page metadata stored, got %+v", metadata)
+			}
+			if metadata.ImageURL != server.URL+"/images/cover.png" || metadata.PublishedAt == nil || metadata.PublishedAt.Year() != 2024 {
+				t.Errorf("Expected the image and publish date stored, got %+v", metadata)
+			}
+
+			missing, _ = resourceService.GetResource(missing.ID)
+			if missing.Metadata.Status != models.MetadataFailed || !strings.Contains(missing.Metadata.Error, "404") {
+				t.Errorf("Expected the failure recorded, got %+v", missing.Metadata)
+			}
+			if missing.Title != missing.URL {
+				t.Errorf("Expected a resource without a title to be named after its URL, got %q", missing.Title)
+			}
+
+			if count, _ := metadataService.FetchPending(context.Background()); count != 0 {
+				t.Errorf("Expected nothing left pending, got %d", count)
+			}
+		})
+	}
+}
+
+// editingFetcher edits the resource while its page is being fetched
+type editingFetcher struct {
+	store    storage.Storage
+	id       string
+	metadata *fetch.Metadata
+}
+
+func (f *editingFetcher) Metadata(ctx context.Context, url string) (*fetch.Metadata, error) {
+	resource, _ := f.store.GetResource(f.id)
+	resource.Title = "Typed meanwhile"
+	f.store.UpdateResource(resource)
+	return f.metadata, nil
+}
+
+func TestMetadataService_KeepsConcurrentEdits(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	resource := models.NewCollectedResource("https://example.com", "", "", models.ResourceTypeLink, "", nil)
+	resource.ID = "r1"
+	store.CreateResource(resource)
+	fetcher := &editingFetcher{store: store, id: "r1", metadata: &fetch.Metadata{Title: "From the page", Description: "Page description"}}
+	metadataService := services.NewMetadataService(store, fetcher)
+
+	refreshed, err := metadataService.Refresh(context.Background(), "r1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if refreshed.Title != "Typed meanwhile" || refreshed.Description != "Page description" {
+		t.Errorf("Expected the edit kept and the empty description filled, got %q and %q", refreshed.Title, refreshed.Description)
+	}
+	if refreshed.Version != 3 {
+		t.Errorf("Expected the edit and the metadata as two updates, got version %d", refreshed.Version)
+	}
+}
+
+func TestMetadataService_RunFetchesNewResources(t *testing.T) {
+	// Setup
+	server := servePages(t, map[string]http.HandlerFunc{"/article": htmlPage(articlePage)})
+	store := storage.NewMemoryStorage()
+	resourceService := services.NewResourceService(store)
+	metadataService := services.NewMetadataService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}))
+	resourceService.SetMetadataService(metadataService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go metadataService.Run(ctx, time.Hour)
+
+	resource, _ := resourceService.CreateResource(server.URL+"/article", "", "", models.ResourceTypeBlog, "", nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		current, _ := resourceService.GetResource(resource.ID)
+		if current.Metadata.Status == models.MetadataFetched {
+			if current.Title != "Picking a vector database" {
+				t.Errorf("Expected the title from the page, got %q", current.Title)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Expected the queued resource to be fetched in the background")
+}
@@ -0,0 +1,152 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"inspiration-blog-writer/backend/src/fetch"
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/search"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+const deepWorkQuote = "> Clarity about what matters provides clarity about what does not.\n\nA reminder to cut the **shallow** work first."
+
+func TestResourceService_CollectsNotes(t *testing.T) {
+	for name, store := range queryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Setup
+			resourceService := services.NewResourceService(store)
+			resourceService.SetMetadataService(services.NewMetadataService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true})))
+			source := &models.ResourceSource{Kind: models.SourceBook, Name: "Deep Work", Author: "Cal Newport", Locator: "p. 42"}
+
+			note, created, err := resourceService.CollectNote("", deepWorkQuote, source, "", "Focus", []string{"focus"}, services.DuplicatesReject)
+			if err != nil || !created {
+				t.Fatalf("Expected the note to be created, got %v", err)
+			}
+			if note.Title != "Clarity about what matters provides clarity about what does not." || note.Type != models.ResourceTypeNote {
+				t.Errorf("Expected the title taken from the body, got %q", note.Title)
+			}
+			if note.Metadata.Status != "" {
+				t.Errorf("Expected a note without URL not to be fetched, got %q", note.Metadata.Status)
+			}
+
+			stored, err := resourceService.GetResource(note.ID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if stored.Body != deepWorkQuote || stored.Source == nil || *stored.Source != *source || stored.URL != "" {
+				t.Errorf("Expected body and source stored, got %+v", stored)
+			}
+
+			found, _, err := resourceService.QueryResources(services.ListQuery{Text: "SHALLOW"})
+			if err != nil || len(found) != 1 || found[0].ID != note.ID {
+				t.Errorf("Expected the note found by its body, got %d (err %v)", len(found), err)
+			}
+
+			updated, err := resourceService.UpdateResource(note.ID, "", "", "Shallow work can wait.", nil, models.ResourceTypeNote, "Focus", nil, services.AnyVersion)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if updated.Title != "Shallow work can wait." || updated.Source != nil {
+				t.Errorf("Expected the title and source to follow the update, got %+v", updated)
+			}
+			if _, err := resourceService.UpdateResource(note.ID, "Now a link", "", "", nil, models.ResourceTypeLink, "", nil, services.AnyVersion); !errors.Is(err, services.ErrValidation) {
+				t.Errorf("Expected a link without URL to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResourceService_ValidatesNotes(t *testing.T) {
+	// Setup
+	resourceService := services.NewResourceService(storage.NewMemoryStorage())
+
+	cases := map[string]func() error{
+		"empty body": func() error {
+			_, _, err := resourceService.CollectNote("Title", "  \n", nil, "", "", nil, "")
+			return err
+		},
+		"unknown source kind": func() error {
+			_, _, err := resourceService.CollectNote("", "Text", &models.ResourceSource{Kind: "tweet", Name: "someone"}, "", "", nil, "")
+			return err
+		},
+		"source without name": func() error {
+			_, _, err := resourceService.CollectNote("", "Text", &models.ResourceSource{Kind: models.SourcePerson}, "", "", nil, "")
+			return err
+		},
+		"link without URL": func() error {
+			_, err := resourceService.CreateResource("", "Title", "", models.ResourceTypeLink, "", nil)
+			return err
+		},
+	}
+	for name, create := range cases {
+		if err := create(); !errors.Is(err, services.ErrValidation) {
+			t.Errorf("Expected %s to be rejected, got %v", name, err)
+		}
+	}
+
+	// A note may still point at where it was found
+	note, _, err := resourceService.CollectNote("", "Talk notes", &models.ResourceSource{Kind: models.SourcePodcast, Name: "Go Time"},
+		"https://example.com/episodes/300/?utm_source=feed", "", nil, "")
+	if err != nil || note.URL != "https://example.com/episodes/300/?utm_source=feed" {
+		t.Errorf("Expected the note stored with its URL, got %v", err)
+	}
+}
+
+func TestNotes_FeedSearchPromptsAndSkipLinkChecks(t *testing.T) {
+	// Setup
+	index := search.NewIndex()
+	store, err := search.NewIndexedStorage(storage.NewMemoryStorage(), index)
+	if err != nil {
+		t.Fatalf("Failed to create indexed storage: %v", err)
+	}
+	provider := llm.NewFakeProvider()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	resourceService.SetMetadataService(services.NewMetadataService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true})))
+	ideaService := services.NewIdeaService(store, provider)
+	searchService := services.NewSearchService(store, index)
+	healthService := services.NewHealthService(store, fetch.NewFetcher(fetch.Config{AllowPrivateNetworks: true}), time.Hour)
+
+	draft, _ := draftService.CreateDraft("Focus", "Notes on attention", nil)
+	note, _, _ := resourceService.CollectNote("", deepWorkQuote, &models.ResourceSource{Kind: models.SourceBook, Name: "Deep Work", Author: "Cal Newport"},
+		"", "", nil, "")
+	if err := draftService.AddResourceToDraft(draft.ID, note.ID); err != nil {
+		t.Fatalf("Expected the note to be attached to the draft, got %v", err)
+	}
+
+	for _, query := range []string{"clarity matters", "newport"} {
+		results, err := searchService.Search(query, 0)
+		if err != nil || len(results.Resources) != 1 || results.Resources[0].ID != note.ID {
+			t.Errorf("Expected %q to find the note, got %+v (err %v)", query, results, err)
+		}
+	}
+
+	provider.Enqueue(`{"ideas":[{"title":"Cut shallow work","confidence":0.6}]}`)
+	if _, err := ideaService.GenerateIdeas(context.Background(), draft.ID, nil, "", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	prompt := provider.Requests()[0].Messages[1].Content
+	for _, want := range []string{"source: Deep Work by Cal Newport (book)", "note:\n    > Clarity about what matters", "    A reminder to cut"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the prompt to contain %q, got %q", want, prompt)
+		}
+	}
+
+	report, err := healthService.CheckDue(context.Background(), time.Now())
+	if err != nil || report.Checked != 0 {
+		t.Errorf("Expected notes without URL not to be checked, got %+v (err %v)", report, err)
+	}
+	if _, err := resourceService.CheckHealth(context.Background(), note.ID); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected checking a note without URL to be rejected, got %v", err)
+	}
+	if _, err := resourceService.RefreshMetadata(context.Background(), note.ID); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected fetching a note without URL to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func TestResourceService_PurgeUnlinksReferences(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, nil)
+	trashService := services.NewTrashService(store, 0)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	kept, _ := resourceService.CreateResource("https://example.com/kept", "Kept", "", models.ResourceTypeLink, "", nil)
+	deleted, _ := resourceService.CreateResource("https://example.com/deleted", "Deleted", "", models.ResourceTypeLink, "", nil)
+	draftService.AddResourceToDraft(draft.ID, kept.ID)
+	draftService.AddResourceToDraft(draft.ID, deleted.ID)
+	idea, _ := ideaService.CreateIdea(draft.ID, "Idea", "", "", 0.5, []string{deleted.ID, kept.ID}, nil)
+
+	before, _ := draftService.GetDraft(draft.ID)
+	version := before.Version
+
+	if err := resourceService.DeleteResource(deleted.ID, ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A trashed resource is hidden but stays linked so it can be restored
+	trashed, _ := draftService.GetDraft(draft.ID)
+	if len(trashed.Resources) != 1 || trashed.Resources[0] != kept.ID {
+		t.Errorf("Expected resources [%s] while in the trash, got %v", kept.ID, trashed.Resources)
+	}
+	if trashed.Version != version {
+		t.Errorf("Expected trashing to leave the draft at version %d, got %d", version, trashed.Version)
+	}
+
+	if err := trashService.PurgeResource(deleted.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	after, _ := draftService.GetDraft(draft.ID)
+	if len(after.Resources) != 1 || after.Resources[0] != kept.ID {
+		t.Errorf("Expected resources [%s], got %v", kept.ID, after.Resources)
+	}
+	if after.Version != version+1 {
+		t.Errorf("Expected unlinking to bump the draft version to %d, got %d", version+1, after.Version)
+	}
+
+	storedIdea, _ := ideaService.GetIdea(idea.ID)
+	if len(storedIdea.Sources) != 1 || storedIdea.Sources[0] != kept.ID {
+		t.Errorf("Expected idea sources [%s], got %v", kept.ID, storedIdea.Sources)
+	}
+}
+
+func TestResourceService_DeleteRefusesReferencedResource(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, nil)
+
+	draft, _ := draftService.CreateDraft("Linked Draft", "Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Resource", "", models.ResourceTypeLink, "", nil)
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+	ideaService.CreateIdea("", "Linked Idea", "", "", 0.5, []string{resource.ID}, nil)
+
+	err := resourceService.DeleteResource(resource.ID, services.ReferencesRefuse)
+	if !errors.Is(err, services.ErrConflict) {
+		t.Fatalf("Expected ErrConflict, got %v", err)
+	}
+
+	var inUse *services.ResourceInUseError
+	if !errors.As(err, &inUse) {
+		t.Fatalf("Expected a ResourceInUseError, got %T", err)
+	}
+	if len(inUse.References.Drafts) != 1 || inUse.References.Drafts[0].Title != "Linked Draft" {
+		t.Errorf("Expected the linked draft, got %+v", inUse.References.Drafts)
+	}
+	if len(inUse.References.Ideas) != 1 || inUse.References.Ideas[0].Title != "Linked Idea" {
+		t.Errorf("Expected the linked idea, got %+v", inUse.References.Ideas)
+	}
+
+	if _, err := resourceService.GetResource(resource.ID); err != nil {
+		t.Errorf("Expected the resource to be kept, got %v", err)
+	}
+
+	// Once unlinked the resource can be deleted
+	draftService.RemoveResourceFromDraft(draft.ID, resource.ID)
+	store.DeleteIdea(inUse.References.Ideas[0].ID)
+	if err := resourceService.DeleteResource(resource.ID, services.ReferencesRefuse); err != nil {
+		t.Errorf("Expected no error for an unreferenced resource, got %v", err)
+	}
+
+	if err := resourceService.DeleteResource(resource.ID, "cascade"); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an unknown policy, got %v", err)
+	}
+}
+
+// raceStorage runs a hook once at a chosen point of a resource delete or
+// link, standing in for a concurrent request
+type raceStorage struct {
+	storage.Storage
+	// beforeTrash runs before the first write that trashes a resource
+	beforeTrash func()
+	// afterGetResource runs after the next resource read
+	afterGetResource func()
+}
+
+func (s *raceStorage) GetResource(id string) (*models.CollectedResource, error) {
+	resource, err := s.Storage.GetResource(id)
+	if hook := s.afterGetResource; hook != nil {
+		s.afterGetResource = nil
+		hook()
+	}
+	return resource, err
+}
+
+func (s *raceStorage) UpdateResource(resource *models.CollectedResource) error {
+	if hook := s.beforeTrash; hook != nil && resource.IsTrashed() {
+		s.beforeTrash = nil
+		hook()
+	}
+	return s.Storage.UpdateResource(resource)
+}
+
+func TestResourceService_DeleteRefusesResourceLinkedMeanwhile(t *testing.T) {
+	// Setup
+	store := &raceStorage{Storage: storage.NewMemoryStorage()}
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+
+	draft, _ := draftService.CreateDraft("Linked Draft", "Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Resource", "", models.ResourceTypeLink, "", nil)
+	store.beforeTrash = func() { draftService.AddResourceToDraft(draft.ID, resource.ID) }
+
+	err := resourceService.DeleteResource(resource.ID, services.ReferencesRefuse)
+	var inUse *services.ResourceInUseError
+	if !errors.As(err, &inUse) || len(inUse.References.Drafts) != 1 {
+		t.Fatalf("Expected a ResourceInUseError naming the draft, got %v", err)
+	}
+
+	if _, err := resourceService.GetResource(resource.ID); err != nil {
+		t.Errorf("Expected the resource to be taken out of the trash, got %v", err)
+	}
+	if linked, _ := draftService.GetDraft(draft.ID); len(linked.Resources) != 1 {
+		t.Errorf("Expected the draft to keep the resource, got %v", linked.Resources)
+	}
+}
+
+func TestResourceService_DeleteWinsOverLinkMadeAfterIt(t *testing.T) {
+	// Setup
+	store := &raceStorage{Storage: storage.NewMemoryStorage()}
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, nil)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Resource", "", models.ResourceTypeLink, "", nil)
+
+	// The delete runs, recheck included, after the link has found the
+	// resource but before it is written
+	var deleteErr error
+	store.afterGetResource = func() { deleteErr = resourceService.DeleteResource(resource.ID, services.ReferencesRefuse) }
+	err := draftService.AddResourceToDraft(draft.ID, resource.ID)
+	if deleteErr != nil || !errors.Is(err, services.ErrNotFound) {
+		t.Fatalf("Expected the delete to win over the link, got %v and %v", deleteErr, err)
+	}
+	if stored, _ := store.GetDraft(draft.ID); len(stored.Resources) != 0 {
+		t.Errorf("Expected the link to be backed out, got %v", stored.Resources)
+	}
+
+	other, _ := resourceService.CreateResource("https://example.com/other", "Other", "", models.ResourceTypeLink, "", nil)
+	store.afterGetResource = func() { deleteErr = resourceService.DeleteResource(other.ID, services.ReferencesRefuse) }
+	_, err = ideaService.CreateIdea("", "Idea", "", "", 0.5, []string{other.ID}, nil)
+	if deleteErr != nil || !errors.Is(err, services.ErrNotFound) {
+		t.Fatalf("Expected the delete to win over the citation, got %v and %v", deleteErr, err)
+	}
+	if ideas, _ := store.ListIdeas(); len(ideas) != 0 {
+		t.Errorf("Expected the idea to be backed out, got %v", ideas)
+	}
+}
+
+func TestResourceService_DeleteRetriesBackgroundUpdates(t *testing.T) {
+	// Setup
+	store := &raceStorage{Storage: storage.NewMemoryStorage()}
+	resourceService := services.NewResourceService(store)
+	resource, _ := resourceService.CreateResource("https://example.com", "Resource", "", models.ResourceTypeLink, "", nil)
+
+	// A health check stores its result between the read and the trash write
+	store.beforeTrash = func() {
+		checked, _ := store.GetResource(resource.ID)
+		store.UpdateResource(checked)
+	}
+	if err := resourceService.DeleteResource(resource.ID, services.ReferencesRefuse); err != nil {
+		t.Fatalf("Expected the delete to retry the version conflict, got %v", err)
+	}
+	if _, err := resourceService.GetResource(resource.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected the resource in the trash, got %v", err)
+	}
+}
+
+func TestDraftService_GetDraftHidesDanglingResources(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Resource", "", models.ResourceTypeLink, "", nil)
+
+	// A link left behind by data written before deletes cleaned up
+	stored, _ := store.GetDraft(draft.ID)
+	stored.Resources = []string{"missing-resource", resource.ID}
+	if err := store.UpdateDraft(stored); err != nil {
+		t.Fatalf("Failed to store draft: %v", err)
+	}
+
+	retrieved, err := draftService.GetDraft(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(retrieved.Resources) != 1 || retrieved.Resources[0] != resource.ID {
+		t.Errorf("Expected resources [%s], got %v", resource.ID, retrieved.Resources)
+	}
+
+	drafts, _ := draftService.ListDrafts()
+	if len(drafts) != 1 || len(drafts[0].Resources) != 1 {
+		t.Errorf("Expected listed draft without the dangling ID, got %v", drafts[0].Resources)
+	}
+}
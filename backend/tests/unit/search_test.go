@@ -0,0 +1,243 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/llm"
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/nlp"
+	"inspiration-blog-writer/backend/src/search"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+// joinFragments rebuilds the snippet text of a highlight, marking matches
+// with brackets
+func joinFragments(highlight search.Highlight) string {
+	var sb strings.Builder
+	for _, fragment := range highlight.Fragments {
+		if fragment.Match {
+			sb.WriteString("[" + fragment.Text + "]")
+		} else {
+			sb.WriteString(fragment.Text)
+		}
+	}
+	return sb.String()
+}
+
+func TestIndex_RanksTitleMatchesFirst(t *testing.T) {
+	// Setup
+	index := search.NewIndex()
+	index.Add(&search.Document{Kind: search.KindResource, ID: "body", Title: "Storage notes", Fields: []search.Field{
+		{Name: "title", Text: "Storage notes", Weight: 3},
+		{Name: "description", Text: "Comparing vector databases for embeddings", Weight: 1},
+	}})
+	index.Add(&search.Document{Kind: search.KindResource, ID: "title", Title: "Vector databases explained", Fields: []search.Field{
+		{Name: "title", Text: "Vector databases explained", Weight: 3},
+		{Name: "description", Text: "A primer", Weight: 1},
+	}})
+	index.Add(&search.Document{Kind: search.KindResource, ID: "partial", Title: "Vector graphics", Fields: []search.Field{
+		{Name: "title", Text: "Vector graphics", Weight: 3},
+	}})
+
+	hits, totals := index.Search("Vector Databases", 0, nil)
+
+	if len(hits) != 2 || totals[search.KindResource] != 2 {
+		t.Fatalf("Expected the 2 documents containing both words, got %+v", hits)
+	}
+	if hits[0].ID != "title" || hits[1].ID != "body" {
+		t.Errorf("Expected the title match first, got %s then %s", hits[0].ID, hits[1].ID)
+	}
+	if len(hits[1].Highlights) != 1 || joinFragments(hits[1].Highlights[0]) != "Comparing [vector] [databases] for embeddings" {
+		t.Errorf("Expected the description highlighted, got %+v", hits[1].Highlights)
+	}
+}
+
+func TestIndex_SnippetsLongFields(t *testing.T) {
+	// Setup
+	index := search.NewIndex()
+	content := strings.Repeat("filler words here ", 30) + "the needle sits here " + strings.Repeat("more filler text ", 30)
+	index.Add(&search.Document{Kind: search.KindDraft, ID: "d1", Title: "Long", Fields: []search.Field{
+		{Name: "content", Text: content, Weight: 1},
+	}})
+
+	hits, _ := index.Search("needle", 0, nil)
+	if len(hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(hits))
+	}
+
+	snippet := joinFragments(hits[0].Highlights[0])
+	if !strings.HasPrefix(snippet, "…") || !strings.HasSuffix(snippet, "…") || !strings.Contains(snippet, "[needle]") {
+		t.Errorf("Expected a trimmed snippet around the match, got %q", snippet)
+	}
+	if len(snippet) > 300 {
+		t.Errorf("Expected a short snippet, got %d bytes", len(snippet))
+	}
+}
+
+func TestIndexedStorage_KeepsIndexCurrent(t *testing.T) {
+	// Setup
+	index := search.NewIndex()
+	store, err := search.NewIndexedStorage(storage.NewMemoryStorage(), index)
+	if err != nil {
+		t.Fatalf("Failed to create indexed storage: %v", err)
+	}
+	draftService := services.NewDraftService(store)
+	trashService := services.NewTrashService(store, 0)
+	searchService := services.NewSearchService(store, index)
+
+	draft, _ := draftService.CreateDraft("Gardening log", "Tomatoes need sun", []string{"garden"})
+
+	results, err := searchService.Search("tomatoes", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results.Drafts) != 1 || results.Drafts[0].ID != draft.ID {
+		t.Fatalf("Expected the new draft to be found, got %+v", results.Drafts)
+	}
+
+	if _, err := draftService.UpdateDraft(draft.ID, "Gardening log", "Peppers need sun", nil, models.RevisionSourceUser, services.AnyVersion); err != nil {
+		t.Fatalf("Failed to update draft: %v", err)
+	}
+	if results, _ := searchService.Search("tomatoes", 0); len(results.Drafts) != 0 {
+		t.Errorf("Expected the old text to be gone after an update, got %+v", results.Drafts)
+	}
+	if results, _ := searchService.Search("peppers", 0); len(results.Drafts) != 1 {
+		t.Errorf("Expected the new text to be found, got %+v", results.Drafts)
+	}
+
+	draftService.DeleteDraft(draft.ID)
+	if results, _ := searchService.Search("peppers", 0); len(results.Drafts) != 0 {
+		t.Errorf("Expected a trashed draft not to be found, got %+v", results.Drafts)
+	}
+	trashService.RestoreDraft(draft.ID)
+	if results, _ := searchService.Search("peppers", 0); len(results.Drafts) != 1 {
+		t.Errorf("Expected a restored draft to be found again, got %+v", results.Drafts)
+	}
+}
+
+func TestIndexedStorage_IndexesExistingContentAndChat(t *testing.T) {
+	// Setup
+	base := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(base)
+	resourceService := services.NewResourceService(base)
+	draft, _ := draftService.CreateDraft("Databases", "Notes", nil)
+	resource, _ := resourceService.CreateResource("https://example.com/pgvector", "pgvector", "Vector similarity search for Postgres", models.ResourceTypeLink, "", nil)
+
+	index := search.NewIndex()
+	store, err := search.NewIndexedStorage(base, index)
+	if err != nil {
+		t.Fatalf("Failed to create indexed storage: %v", err)
+	}
+	chatService := services.NewChatService(store, llm.NewFakeProvider())
+	searchService := services.NewSearchService(store, index)
+
+	results, _ := searchService.Search("postgres similarity", 0)
+	if len(results.Resources) != 1 || results.Resources[0].ID != resource.ID {
+		t.Fatalf("Expected the resource stored before indexing to be found, got %+v", results.Resources)
+	}
+
+	session, _ := chatService.CreateSession(draft.ID)
+	if _, err := chatService.SendMessage(context.Background(), session.ID, "Should I compare Milvus too?"); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	// The fake provider echoes the question, so both messages match
+	results, _ = searchService.Search("milvus", 0)
+	if len(results.Messages) != 2 {
+		t.Fatalf("Expected the question and the reply, got %+v", results.Messages)
+	}
+	for _, hit := range results.Messages {
+		if hit.SessionID != session.ID || hit.DraftID != draft.ID {
+			t.Errorf("Expected the message to point at its session and draft, got %+v", hit)
+		}
+	}
+}
+
+func TestSearchService_ValidatesQuery(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	searchService := services.NewSearchService(store, search.NewIndex())
+
+	if _, err := searchService.Search("  ", 0); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an empty query, got %v", err)
+	}
+	if _, err := searchService.Search("go", 51); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an oversized limit, got %v", err)
+	}
+
+	results, err := searchService.Search("the", 0)
+	if err != nil || len(results.Drafts) != 0 {
+		t.Errorf("Expected no hits for a query of stop words, got %+v (err %v)", results, err)
+	}
+}
+
+func TestIndex_SearchesChineseAndStems(t *testing.T) {
+	// Setup
+	index := search.NewIndex()
+	index.Add(&search.Document{Kind: search.KindDraft, ID: "zh", Title: "向量数据库选型", Fields: []search.Field{
+		{Name: "title", Text: "向量数据库选型", Weight: 3},
+		{Name: "content", Text: "我们比较了几种向量数据库的检索性能，包括 Milvus 和 pgvector。", Weight: 1},
+	}})
+	index.Add(&search.Document{Kind: search.KindDraft, ID: "en", Title: "Picking a vector database", Fields: []search.Field{
+		{Name: "title", Text: "Picking a vector database", Weight: 3},
+	}})
+
+	hits, _ := index.Search("数据库", 0, nil)
+	if len(hits) != 1 || hits[0].ID != "zh" {
+		t.Fatalf("Expected the Chinese draft, got %+v", hits)
+	}
+	if joinFragments(hits[0].Highlights[0]) != "向量[数据库]选型" {
+		t.Errorf("Expected the word highlighted, got %q", joinFragments(hits[0].Highlights[0]))
+	}
+
+	if hits, _ := index.Search("检索性能", 0, nil); len(hits) != 1 || joinFragments(hits[0].Highlights[0]) != "我们比较了几种向量数据库的[检索性能]，包括 Milvus 和 pgvector。" {
+		t.Errorf("Expected adjacent words highlighted as one match, got %+v", hits)
+	}
+	if hits, _ := index.Search("milvus 数据库", 0, nil); len(hits) != 1 {
+		t.Errorf("Expected mixed queries to match, got %+v", hits)
+	}
+	if hits, _ := index.Search("databases", 0, nil); len(hits) != 1 || hits[0].ID != "en" {
+		t.Errorf("Expected \"databases\" to find \"database\", got %+v", hits)
+	}
+	if hits, _ := index.Search("数据仓库", 0, nil); len(hits) != 0 {
+		t.Errorf("Expected no match for a different word, got %+v", hits)
+	}
+}
+
+func TestIndex_MatchesExactWordsInUnstemmedLanguages(t *testing.T) {
+	// Setup
+	index := search.NewIndex()
+	text := "Les bases vectorielles stockent des plongements et retrouvent très vite les documents proches dans un grand corpus."
+	if language := nlp.DetectLanguage(text); language != nlp.LanguageOther {
+		t.Fatalf("Expected the French text to be read as unstemmed, got %q", language)
+	}
+	index.Add(&search.Document{Kind: search.KindDraft, ID: "fr", Title: "Bases vectorielles", Fields: []search.Field{
+		{Name: "content", Text: text, Weight: 1},
+	}})
+	index.Add(&search.Document{Kind: search.KindDraft, ID: "en", Title: "Vector databases", Fields: []search.Field{
+		{Name: "content", Text: "Vector databases store embeddings and find similar documents.", Weight: 1},
+	}})
+
+	for _, query := range []string{"documents", "bases", "plongements", "Plongements vectorielles"} {
+		hits, _ := index.Search(query, 0, nil)
+		found := false
+		for _, hit := range hits {
+			found = found || hit.ID == "fr"
+		}
+		if !found {
+			t.Errorf("Expected %q to find the French draft, got %+v", query, hits)
+		}
+	}
+
+	// The English draft is still found by other forms of its words
+	if hits, _ := index.Search("embedding", 0, nil); len(hits) != 1 || hits[0].ID != "en" {
+		t.Errorf("Expected \"embedding\" to find \"embeddings\", got %+v", hits)
+	}
+	if hits, _ := index.Search("plongements", 0, nil); len(hits) != 1 || joinFragments(hits[0].Highlights[0]) != strings.Replace(text, "plongements", "[plongements]", 1) {
+		t.Errorf("Expected the word highlighted once, got %+v", hits)
+	}
+}
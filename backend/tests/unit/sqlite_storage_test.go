@@ -0,0 +1,342 @@
+package unit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestSQLite(t *testing.T) (*storage.SQLiteStorage, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := storage.NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store, path
+}
+
+func TestSQLiteStorage_DraftRoundTrip(t *testing.T) {
+	// Setup
+	store, _ := openTestSQLite(t)
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+
+	draft, err := draftService.CreateDraft("SQLite Draft", "Content", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Failed to create draft: %v", err)
+	}
+	resource, _ := resourceService.CreateResource("https://example.com", "Test Resource", "Test Description", models.ResourceTypeLink, "test", []string{"test"})
+
+	if err := draftService.AddResourceToDraft(draft.ID, resource.ID); err != nil {
+		t.Fatalf("Failed to add resource: %v", err)
+	}
+
+	retrieved, err := draftService.GetDraft(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if retrieved.Title != "SQLite Draft" {
+		t.Errorf("Expected title 'SQLite Draft', got %s", retrieved.Title)
+	}
+
+	if len(retrieved.Tags) != 2 || retrieved.Tags[1] != "b" {
+		t.Errorf("Expected tags [a, b], got %v", retrieved.Tags)
+	}
+
+	if len(retrieved.Resources) != 1 || retrieved.Resources[0] != resource.ID {
+		t.Errorf("Expected resources [%s], got %v", resource.ID, retrieved.Resources)
+	}
+
+	if !retrieved.CreatedAt.Equal(draft.CreatedAt) {
+		t.Errorf("Expected CreatedAt %v, got %v", draft.CreatedAt, retrieved.CreatedAt)
+	}
+
+	// Delete and verify
+	if err := draftService.DeleteDraft(draft.ID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if _, err := draftService.GetDraft(draft.ID); err == nil {
+		t.Error("Expected error when getting deleted draft")
+	}
+}
+
+func TestSQLiteStorage_DuplicateAndMissing(t *testing.T) {
+	// Setup
+	store, _ := openTestSQLite(t)
+
+	idea := models.NewInterestIdea("Idea", "Description", "Content", 0.7, []string{"r1"}, []string{"t1"})
+	idea.ID = "idea-1"
+
+	if err := store.CreateIdea(idea); err != nil {
+		t.Fatalf("Failed to create idea: %v", err)
+	}
+
+	if err := store.CreateIdea(idea); err == nil || err.Error() != "idea already exists" {
+		t.Errorf("Expected 'idea already exists' error, got %v", err)
+	}
+
+	if err := store.UpdateDraft(&models.BlogDraft{ID: "missing"}); err == nil || err.Error() != "draft not found" {
+		t.Errorf("Expected 'draft not found' error, got %v", err)
+	}
+
+	if err := store.DeleteResource("missing"); err == nil || err.Error() != "resource not found" {
+		t.Errorf("Expected 'resource not found' error, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_VersionConflict(t *testing.T) {
+	// Setup
+	store, _ := openTestSQLite(t)
+
+	resource := models.NewCollectedResource("https://example.com", "Title", "", models.ResourceTypeLink, "", nil)
+	resource.ID = "resource-1"
+	if err := store.CreateResource(resource); err != nil {
+		t.Fatalf("Failed to create resource: %v", err)
+	}
+
+	first, _ := store.GetResource(resource.ID)
+	second, _ := store.GetResource(resource.ID)
+
+	first.Title = "First"
+	if err := store.UpdateResource(first); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("Expected version 2 after an update, got %d", first.Version)
+	}
+
+	second.Title = "Second"
+	if err := store.UpdateResource(second); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale update, got %v", err)
+	}
+
+	stored, _ := store.GetResource(resource.ID)
+	if stored.Title != "First" || stored.Version != 2 {
+		t.Errorf("Expected the first update to be kept, got %q at version %d", stored.Title, stored.Version)
+	}
+}
+
+func TestSQLiteStorage_DeleteResourceUnlinks(t *testing.T) {
+	// Setup
+	store, _ := openTestSQLite(t)
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	ideaService := services.NewIdeaService(store, nil)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	kept, _ := resourceService.CreateResource("https://example.com/kept", "Kept", "", models.ResourceTypeLink, "", nil)
+	deleted, _ := resourceService.CreateResource("https://example.com/deleted", "Deleted", "", models.ResourceTypeLink, "", nil)
+	draftService.AddResourceToDraft(draft.ID, deleted.ID)
+	draftService.AddResourceToDraft(draft.ID, kept.ID)
+	idea, err := ideaService.CreateIdea(draft.ID, "Idea", "", "", 0.5, []string{deleted.ID}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create idea: %v", err)
+	}
+
+	draftIDs, ideaIDs, err := store.ResourceReferences(deleted.ID)
+	if err != nil || len(draftIDs) != 1 || len(ideaIDs) != 1 {
+		t.Fatalf("Expected one draft and one idea reference, got %v %v (%v)", draftIDs, ideaIDs, err)
+	}
+
+	before, _ := store.GetDraft(draft.ID)
+	if err := store.DeleteResource(deleted.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	after, _ := store.GetDraft(draft.ID)
+	if len(after.Resources) != 1 || after.Resources[0] != kept.ID {
+		t.Errorf("Expected resources [%s], got %v", kept.ID, after.Resources)
+	}
+	if after.Version != before.Version+1 {
+		t.Errorf("Expected version %d after unlinking, got %d", before.Version+1, after.Version)
+	}
+	if !after.UpdatedAt.After(before.UpdatedAt) {
+		t.Errorf("Expected UpdatedAt to move past %v after unlinking, got %v", before.UpdatedAt, after.UpdatedAt)
+	}
+
+	storedIdea, _ := store.GetIdea(idea.ID)
+	if len(storedIdea.Sources) != 0 {
+		t.Errorf("Expected no idea sources, got %v", storedIdea.Sources)
+	}
+	if !storedIdea.UpdatedAt.After(idea.UpdatedAt) {
+		t.Errorf("Expected idea UpdatedAt to move past %v after unlinking, got %v", idea.UpdatedAt, storedIdea.UpdatedAt)
+	}
+}
+
+func TestSQLiteStorage_TrashTimestamp(t *testing.T) {
+	// Setup
+	store, _ := openTestSQLite(t)
+	draftService := services.NewDraftService(store)
+	trashService := services.NewTrashService(store, 0)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	if err := draftService.DeleteDraft(draft.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stored, err := store.GetDraft(draft.ID)
+	if err != nil || stored.DeletedAt == nil {
+		t.Fatalf("Expected a stored trash timestamp, got %+v (%v)", stored, err)
+	}
+
+	if _, err := trashService.RestoreDraft(draft.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stored, _ := store.GetDraft(draft.ID); stored.DeletedAt != nil {
+		t.Errorf("Expected the trash timestamp to be cleared, got %v", stored.DeletedAt)
+	}
+}
+
+func TestSQLiteStorage_SessionMessages(t *testing.T) {
+	// Setup
+	store, _ := openTestSQLite(t)
+
+	session := models.NewChatSession("draft-1")
+	session.ID = "session-1"
+	session.AddMessage(models.MessageTypeUser, "first")
+	if err := store.CreateSession(session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	reply := session.AddMessage(models.MessageTypeAssistant, "second")
+	reply.Model = "test-model"
+	reply.PromptTokens = 12
+	reply.CompletionTokens = 3
+	reply.LatencyMs = 250
+	reply.ResourceIDs = []string{"resource-1"}
+	session.Deactivate()
+	if err := store.UpdateSession(session); err != nil {
+		t.Fatalf("Failed to update session: %v", err)
+	}
+
+	retrieved, err := store.GetSession("session-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if retrieved.Active {
+		t.Error("Expected session to be inactive")
+	}
+
+	if len(retrieved.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(retrieved.Messages))
+	}
+
+	if retrieved.Messages[0].Content != "first" || retrieved.Messages[1].Type != models.MessageTypeAssistant {
+		t.Errorf("Expected messages in order, got %v", retrieved.Messages)
+	}
+
+	stored := retrieved.Messages[1]
+	if stored.ID != reply.ID || stored.ParentID != retrieved.Messages[0].ID {
+		t.Errorf("Expected message IDs to round-trip, got %+v", stored)
+	}
+	if stored.Model != "test-model" || stored.PromptTokens != 12 || stored.CompletionTokens != 3 ||
+		stored.LatencyMs != 250 || len(stored.ResourceIDs) != 1 {
+		t.Errorf("Expected message metadata to round-trip, got %+v", stored)
+	}
+
+	other := models.NewChatSession("draft-2")
+	other.ID = "session-2"
+	store.CreateSession(other)
+
+	sessions, err := store.ListSessions("draft-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sessions) != 1 || len(sessions[0].Messages) != 2 {
+		t.Errorf("Expected the draft's session with its messages, got %v", sessions)
+	}
+}
+
+func TestSQLiteStorage_MigrationsAreIdempotent(t *testing.T) {
+	// Setup
+	store, path := openTestSQLite(t)
+
+	resource := models.NewCollectedResource("https://example.com", "Kept", "", models.ResourceTypeBlog, "", nil)
+	resource.ID = "resource-1"
+	if err := store.CreateResource(resource); err != nil {
+		t.Fatalf("Failed to create resource: %v", err)
+	}
+	store.Close()
+
+	// Reopening must not re-run migrations or lose data
+	reopened, err := storage.NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen SQLite storage: %v", err)
+	}
+	defer reopened.Close()
+
+	if _, err := reopened.GetResource("resource-1"); err != nil {
+		t.Errorf("Expected resource to survive reopen, got %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var applied int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = 1`).Scan(&applied); err != nil {
+		t.Fatalf("Failed to read schema_migrations: %v", err)
+	}
+
+	if applied != 1 {
+		t.Errorf("Expected migration 1 to be recorded once, got %d", applied)
+	}
+}
+
+func TestSQLiteStorage_Revisions(t *testing.T) {
+	// Setup
+	store, _ := openTestSQLite(t)
+
+	draft := models.NewBlogDraft("Title", "Content", []string{"go"})
+	draft.ID = "draft-1"
+	store.CreateDraft(draft)
+
+	for number := 1; number <= 2; number++ {
+		revision := models.NewDraftRevision(draft, number, models.RevisionSourceUser)
+		revision.ID = fmt.Sprintf("revision-%d", number)
+		if err := store.CreateRevision(revision); err != nil {
+			t.Fatalf("Failed to create revision: %v", err)
+		}
+	}
+
+	duplicate := models.NewDraftRevision(draft, 2, models.RevisionSourceUser)
+	duplicate.ID = "revision-dup"
+	if err := store.CreateRevision(duplicate); !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for a reused number, got %v", err)
+	}
+
+	orphan := models.NewDraftRevision(&models.BlogDraft{ID: "missing"}, 1, models.RevisionSourceUser)
+	orphan.ID = "revision-orphan"
+	if err := store.CreateRevision(orphan); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing draft, got %v", err)
+	}
+
+	revisions, err := store.ListRevisions("draft-1")
+	if err != nil || len(revisions) != 2 || revisions[1].Number != 2 || revisions[0].Tags[0] != "go" {
+		t.Fatalf("Expected 2 ordered revisions, got %v %v", revisions, err)
+	}
+
+	// Revisions go away with their draft
+	store.DeleteDraft("draft-1")
+	revisions, _ = store.ListRevisions("draft-1")
+	if len(revisions) != 0 {
+		t.Errorf("Expected revisions to be deleted with the draft, got %d", len(revisions))
+	}
+}
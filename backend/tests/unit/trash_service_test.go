@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"inspiration-blog-writer/backend/src/models"
+	"inspiration-blog-writer/backend/src/services"
+	"inspiration-blog-writer/backend/src/storage"
+)
+
+func TestTrashService_DeleteAndRestoreDraft(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	trashService := services.NewTrashService(store, 0)
+
+	draft, _ := draftService.CreateDraft("Trashed Draft", "Content", nil)
+	draftService.CreateDraft("Kept Draft", "Content", nil)
+
+	if err := draftService.DeleteDraft(draft.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := draftService.GetDraft(draft.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected a trashed draft to be not found, got %v", err)
+	}
+	if drafts, _ := draftService.ListDrafts(); len(drafts) != 1 || drafts[0].Title != "Kept Draft" {
+		t.Errorf("Expected only the kept draft to be listed, got %d drafts", len(drafts))
+	}
+
+	trash, err := trashService.List()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(trash.Drafts) != 1 || trash.Drafts[0].ID != draft.ID || trash.Drafts[0].DeletedAt == nil {
+		t.Fatalf("Expected the trashed draft in the trash, got %+v", trash.Drafts)
+	}
+
+	restored, err := trashService.RestoreDraft(draft.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.IsTrashed() {
+		t.Error("Expected the restored draft to be out of the trash")
+	}
+	if _, err := draftService.GetDraft(draft.ID); err != nil {
+		t.Errorf("Expected the restored draft to be found, got %v", err)
+	}
+
+	// Only trashed items can be restored or purged
+	if _, err := trashService.RestoreDraft(draft.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound restoring a draft outside the trash, got %v", err)
+	}
+	if err := trashService.PurgeDraft(draft.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound purging a draft outside the trash, got %v", err)
+	}
+}
+
+func TestTrashService_TrashedResourceIsHiddenFromDrafts(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	trashService := services.NewTrashService(store, 0)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Resource", "", models.ResourceTypeLink, "", nil)
+	draftService.AddResourceToDraft(draft.ID, resource.ID)
+
+	resourceService.DeleteResource(resource.ID, "")
+
+	if resources, _ := resourceService.ListResources(); len(resources) != 0 {
+		t.Errorf("Expected no listed resources, got %d", len(resources))
+	}
+	if retrieved, _ := draftService.GetDraft(draft.ID); len(retrieved.Resources) != 0 {
+		t.Errorf("Expected the trashed resource to be hidden from the draft, got %v", retrieved.Resources)
+	}
+	if err := draftService.AddResourceToDraft(draft.ID, resource.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound linking a trashed resource, got %v", err)
+	}
+
+	// Restoring brings the link back
+	if _, err := trashService.RestoreResource(resource.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if retrieved, _ := draftService.GetDraft(draft.ID); len(retrieved.Resources) != 1 {
+		t.Errorf("Expected the restored resource to be linked again, got %v", retrieved.Resources)
+	}
+}
+
+func TestTrashService_TrashedResourceIsHiddenFromIdeas(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	ideaService := services.NewIdeaService(store, nil)
+	resourceService := services.NewResourceService(store)
+	trashService := services.NewTrashService(store, 0)
+
+	resource, _ := resourceService.CreateResource("https://example.com/a", "Trashed", "", models.ResourceTypeLink, "", nil)
+	kept, _ := resourceService.CreateResource("https://example.com/b", "Kept", "", models.ResourceTypeLink, "", nil)
+	idea, _ := ideaService.CreateIdea("", "Idea", "", "", 0.5, []string{resource.ID, kept.ID}, nil)
+
+	resourceService.DeleteResource(resource.ID, "")
+
+	retrieved, err := ideaService.GetIdea(idea.ID)
+	if err != nil || len(retrieved.Sources) != 1 || retrieved.Sources[0] != kept.ID {
+		t.Errorf("Expected the trashed resource to be hidden from the idea, got %+v (err %v)", retrieved, err)
+	}
+	if ideas, _, _ := ideaService.QueryIdeas(services.ListQuery{}); len(ideas) != 1 || len(ideas[0].Sources) != 1 {
+		t.Errorf("Expected the trashed resource to be hidden from listed ideas, got %+v", ideas)
+	}
+
+	// Writing back the sources as they were, or as read, still works
+	for _, sources := range [][]string{{resource.ID, kept.ID}, retrieved.Sources} {
+		updated, err := ideaService.UpdateIdea(idea.ID, "Idea", "", "", 0.5, sources, nil)
+		if err != nil || len(updated.Sources) != 1 || updated.Sources[0] != kept.ID {
+			t.Errorf("Expected the update to keep only the live source, got %+v (err %v)", updated, err)
+		}
+	}
+
+	// A trashed resource cannot be newly cited
+	if _, err := ideaService.UpdateIdea(idea.ID, "Idea", "", "", 0.5, []string{kept.ID, resource.ID}, nil); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound citing a trashed resource again, got %v", err)
+	}
+	if _, err := ideaService.CreateIdea("", "Other", "", "", 0.5, []string{resource.ID}, nil); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound citing a trashed resource, got %v", err)
+	}
+
+	// Purging leaves nothing to prune
+	if err := trashService.PurgeResource(resource.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stored, _ := store.GetIdea(idea.ID); len(stored.Sources) != 1 {
+		t.Errorf("Expected only the live source stored, got %v", stored.Sources)
+	}
+}
+
+func TestTrashService_PurgeExpired(t *testing.T) {
+	// Setup
+	store := storage.NewMemoryStorage()
+	draftService := services.NewDraftService(store)
+	resourceService := services.NewResourceService(store)
+	trashService := services.NewTrashService(store, 24*time.Hour)
+
+	draft, _ := draftService.CreateDraft("Draft", "Content", nil)
+	resource, _ := resourceService.CreateResource("https://example.com", "Resource", "", models.ResourceTypeLink, "", nil)
+	draftService.DeleteDraft(draft.ID)
+	resourceService.DeleteResource(resource.ID, "")
+
+	purged, err := trashService.PurgeExpired(time.Now().Add(time.Hour))
+	if err != nil || purged != 0 {
+		t.Fatalf("Expected nothing to expire within the retention period, got %d (%v)", purged, err)
+	}
+
+	purged, err = trashService.PurgeExpired(time.Now().Add(25 * time.Hour))
+	if err != nil || purged != 2 {
+		t.Fatalf("Expected 2 expired items, got %d (%v)", purged, err)
+	}
+
+	if _, err := store.GetDraft(draft.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the expired draft to be purged, got %v", err)
+	}
+	if _, err := store.GetResource(resource.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the expired resource to be purged, got %v", err)
+	}
+
+	// Without a retention period nothing expires
+	keeper := services.NewTrashService(store, 0)
+	other, _ := draftService.CreateDraft("Other", "Content", nil)
+	draftService.DeleteDraft(other.ID)
+	if purged, _ := keeper.PurgeExpired(time.Now().Add(365 * 24 * time.Hour)); purged != 0 {
+		t.Errorf("Expected no purge without retention, got %d", purged)
+	}
+}
@@ -8,6 +8,71 @@ export interface BlogDraft {
   created_at: string;
   updated_at: string;
   resource_ids: string[];
+  version: number;
+}
+
+export interface ResourceMetadata {
+  status?: 'pending' | 'fetched' | 'failed';
+  canonicalUrl?: string;
+  siteName?: string;
+  imageUrl?: string;
+  faviconUrl?: string;
+  publishedAt?: string;
+  fetchedAt?: string;
+  error?: string;
+  archivedAt?: string;
+}
+
+export interface ResourceArchive {
+  resourceId: string;
+  url: string;
+  title: string;
+  text: string;
+  archivedAt: string;
+}
+
+// Start and end are character offsets into the archived text or a note's body
+export interface AnnotationSelector {
+  start: number;
+  end: number;
+  prefix?: string;
+  suffix?: string;
+}
+
+export interface Annotation {
+  id: string;
+  resourceId: string;
+  quote: string;
+  selector?: AnnotationSelector;
+  note: string;
+  tags: string[];
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface AnnotationRequest {
+  quote: string;
+  selector?: AnnotationSelector;
+  note?: string;
+  tags?: string[];
+}
+
+export interface ResourceHealth {
+  status?: 'ok' | 'moved' | 'broken';
+  statusCode?: number;
+  finalUrl?: string;
+  checkedAt?: string;
+  error?: string;
+  failures?: number;
+}
+
+export type ResourceType = 'link' | 'blog' | 'document' | 'video' | 'other' | 'note';
+
+export interface ResourceSource {
+  kind: 'book' | 'podcast' | 'person' | 'other';
+  name: string;
+  author?: string;
+  locator?: string;
 }
 
 export interface CollectedResource {
@@ -15,9 +80,15 @@ export interface CollectedResource {
   title: string;
   url: string;
   description: string;
+  type: ResourceType;
+  body?: string;
+  source?: ResourceSource;
   category: string;
+  metadata: ResourceMetadata;
+  health: ResourceHealth;
   created_at: string;
   updated_at: string;
+  version: number;
 }
 
 export interface InterestIdea {
@@ -52,10 +123,14 @@ export interface UpdateDraftRequest {
   content?: string;
 }
 
+// Notes need a body instead of a url
 export interface CreateResourceRequest {
-  title: string;
-  url: string;
+  title?: string;
+  url?: string;
   description: string;
+  type?: ResourceType;
+  body?: string;
+  source?: ResourceSource;
   category: string;
 }
 
@@ -63,9 +138,72 @@ export interface UpdateResourceRequest {
   title?: string;
   url?: string;
   description?: string;
+  type?: ResourceType;
+  body?: string;
+  source?: ResourceSource;
   category?: string;
 }
 
+export type DuplicatePolicy = 'reject' | 'merge' | 'allow';
+
+export interface DedupeResult {
+  groups: {
+    kept: CollectedResource;
+    duplicates: { id: string; url: string; title: string; reason: 'url' | 'title' }[];
+  }[];
+  merged: number;
+  dryRun: boolean;
+}
+
+export interface SearchHit {
+  kind: 'draft' | 'resource' | 'idea' | 'message';
+  id: string;
+  title: string;
+  draftId?: string;
+  sessionId?: string;
+  score: number;
+  highlights: { field: string; fragments: { text: string; match?: boolean }[] }[];
+}
+
+export interface SearchResults {
+  query: string;
+  drafts: SearchHit[];
+  resources: SearchHit[];
+  ideas: SearchHit[];
+  messages: SearchHit[];
+  totals: Record<string, number>;
+}
+
+export interface ListParams {
+  limit?: number;
+  cursor?: string;
+  sort?: 'createdAt' | 'updatedAt' | 'title';
+  order?: 'asc' | 'desc';
+  tag?: string[];
+  createdAfter?: string;
+  createdBefore?: string;
+  category?: string;
+  type?: string;
+  domain?: string;
+  q?: string;
+  health?: 'unchecked' | 'ok' | 'moved' | 'broken';
+  draftId?: string;
+  source?: string;
+}
+
+const queryString = (params: ListParams = {}): string => {
+  const search = new URLSearchParams();
+  Object.entries(params).forEach(([key, value]) => {
+    if (value === undefined || value === '') return;
+    (Array.isArray(value) ? value : [value]).forEach((item) => search.append(key, String(item)));
+  });
+  const query = search.toString();
+  return query ? `?${query}` : '';
+};
+
+const ifMatch = (version?: number): string =>
+  version === undefined ? '*' : `"${version}"`;
+
 class ApiService {
   private async request<T>(
     endpoint: string,
@@ -74,11 +212,11 @@ class ApiService {
     const url = `${API_BASE_URL}${endpoint}`;
 
     const config: RequestInit = {
+      ...options,
       headers: {
         'Content-Type': 'application/json',
         ...options.headers,
       },
-      ...options,
     };
 
     try {
@@ -101,8 +239,8 @@ class ApiService {
   }
 
   // Blog Drafts
-  async getDrafts(): Promise<BlogDraft[]> {
-    return this.request('/api/drafts');
+  async getDrafts(params?: ListParams): Promise<BlogDraft[]> {
+    return this.request(`/api/drafts${queryString(params)}`);
   }
 
   async getDraft(id: string): Promise<BlogDraft> {
@@ -116,9 +254,12 @@ class ApiService {
     });
   }
 
-  async updateDraft(id: string, data: UpdateDraftRequest): Promise<BlogDraft> {
+  // Pass the version the edit was based on so concurrent edits are rejected
+  // instead of overwritten; without it the update applies to any version
+  async updateDraft(id: string, data: UpdateDraftRequest, version?: number): Promise<BlogDraft> {
     return this.request(`/api/drafts/${id}`, {
       method: 'PUT',
+      headers: { 'If-Match': ifMatch(version) },
       body: JSON.stringify(data),
     });
   }
@@ -143,24 +284,32 @@ class ApiService {
   }
 
   // Resources
-  async getResources(): Promise<CollectedResource[]> {
-    return this.request('/api/resources');
+  async getResources(params?: ListParams): Promise<CollectedResource[]> {
+    return this.request(`/api/resources${queryString(params)}`);
   }
 
   async getResource(id: string): Promise<CollectedResource> {
     return this.request(`/api/resources/${id}`);
   }
 
-  async createResource(data: CreateResourceRequest): Promise<CollectedResource> {
-    return this.request('/api/resources', {
+  async createResource(data: CreateResourceRequest, duplicates?: DuplicatePolicy): Promise<CollectedResource> {
+    const query = duplicates ? `?duplicates=${duplicates}` : '';
+    return this.request(`/api/resources${query}`, {
       method: 'POST',
       body: JSON.stringify(data),
     });
   }
 
-  async updateResource(id: string, data: UpdateResourceRequest): Promise<CollectedResource> {
+  async dedupeResources(dryRun = false): Promise<{ dedupe: DedupeResult }> {
+    return this.request(`/api/resources/dedupe${dryRun ? '?dryRun=true' : ''}`, {
+      method: 'POST',
+    });
+  }
+
+  async updateResource(id: string, data: UpdateResourceRequest, version?: number): Promise<CollectedResource> {
     return this.request(`/api/resources/${id}`, {
       method: 'PUT',
+      headers: { 'If-Match': ifMatch(version) },
       body: JSON.stringify(data),
     });
   }
@@ -171,9 +320,60 @@ class ApiService {
     });
   }
 
+  async refreshResourceMetadata(id: string): Promise<CollectedResource> {
+    return this.request(`/api/resources/${id}/metadata`, {
+      method: 'POST',
+    });
+  }
+
+  async checkResourceHealth(id: string): Promise<CollectedResource> {
+    return this.request(`/api/resources/${id}/health`, {
+      method: 'POST',
+    });
+  }
+
+  async getResourceArchive(id: string): Promise<{ archive: ResourceArchive }> {
+    return this.request(`/api/resources/${id}/archive`);
+  }
+
+  // The page as downloaded, sandboxed by the server; suitable for an iframe
+  resourceArchiveHtmlUrl(id: string): string {
+    return `${API_BASE_URL}/api/resources/${id}/archive?format=html`;
+  }
+
+  async archiveResourcePage(id: string): Promise<{ archive: ResourceArchive }> {
+    return this.request(`/api/resources/${id}/archive`, {
+      method: 'POST',
+    });
+  }
+
+  async getAnnotations(resourceId: string): Promise<{ annotations: Annotation[] }> {
+    return this.request(`/api/resources/${resourceId}/annotations`);
+  }
+
+  async createAnnotation(resourceId: string, data: AnnotationRequest): Promise<{ annotation: Annotation }> {
+    return this.request(`/api/resources/${resourceId}/annotations`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async updateAnnotation(resourceId: string, id: string, data: AnnotationRequest): Promise<{ annotation: Annotation }> {
+    return this.request(`/api/resources/${resourceId}/annotations/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async deleteAnnotation(resourceId: string, id: string): Promise<void> {
+    return this.request(`/api/resources/${resourceId}/annotations/${id}`, {
+      method: 'DELETE',
+    });
+  }
+
   // Ideas (placeholder implementations)
-  async getIdeas(): Promise<InterestIdea[]> {
-    return this.request('/api/ideas');
+  async getIdeas(params?: ListParams): Promise<InterestIdea[]> {
+    return this.request(`/api/ideas${queryString(params)}`);
   }
 
   async createIdea(data: Partial<InterestIdea>): Promise<InterestIdea> {
@@ -205,6 +405,13 @@ class ApiService {
     });
   }
 
+  // Search
+  async search(q: string, limit?: number): Promise<{ results: SearchResults }> {
+    const params = new URLSearchParams({ q });
+    if (limit !== undefined) params.set('limit', String(limit));
+    return this.request(`/api/search?${params.toString()}`);
+  }
+
   // AI Analysis (placeholder implementation)
   async analyzeContent(data: { content: string; resources: string[] }): Promise<any> {
     return this.request('/api/analyze', {